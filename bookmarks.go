@@ -0,0 +1,185 @@
+package golangNeo4jBoltDriver
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// bookmarkPrefix is the prefix of the bookmarks returned by Neo4j 3.x
+const bookmarkPrefix = "neo4j:bookmark:v1:tx"
+
+// DefaultBookmarkChain is the name of the bookmark chain used when none is given
+const DefaultBookmarkChain = ""
+
+// BookmarkManager stores the bookmarks returned from committed transactions
+// and supplies them when beginning the next transaction in the same chain,
+// giving causal (read-your-writes) consistency without passing bookmarks
+// around manually.
+//
+// A chain is a named sequence of causally dependent work, e.g. a user session.
+// Connections are attached to a chain with Conn.SetBookmarkManager.
+//
+// BookmarkManager objects ARE THREAD SAFE, so a single manager can be shared
+// by all of the connections opened by a driver or pool.
+type BookmarkManager struct {
+	chains map[string][]string
+	lock   sync.Mutex
+}
+
+// NewBookmarkManager creates a new, empty BookmarkManager
+func NewBookmarkManager() *BookmarkManager {
+	return &BookmarkManager{chains: map[string][]string{}}
+}
+
+// Bookmarks gets the bookmarks to wait for before beginning a transaction in the chain
+func (m *BookmarkManager) Bookmarks(chain string) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	bookmarks := m.chains[chain]
+	output := make([]string, len(bookmarks))
+	copy(output, bookmarks)
+	return output
+}
+
+// UpdateBookmark records the bookmark of a committed transaction in the chain.
+// Transactions in a chain can overlap, so the bookmark is added to the chain's
+// bookmarks instead of replacing them.  Neo4j 3.x bookmarks are ordered, so of
+// those only the one with the highest transaction id is kept.
+func (m *BookmarkManager) UpdateBookmark(chain string, bookmark string) {
+	m.advance(chain, nil, bookmark)
+}
+
+// advance records the bookmark of a committed transaction that waited on the
+// given bookmarks when it began.  Those are implied by the new bookmark, so
+// they're dropped, but bookmarks of transactions that committed meanwhile are kept.
+func (m *BookmarkManager) advance(chain string, waited []string, bookmark string) {
+	if bookmark == "" {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	implied := map[string]bool{bookmark: true}
+	for _, waitedOn := range waited {
+		implied[waitedOn] = true
+	}
+	bookmarks := []string{}
+	for _, existing := range m.chains[chain] {
+		if !implied[existing] {
+			bookmarks = append(bookmarks, existing)
+		}
+	}
+	m.chains[chain] = collapseBookmarks(append(bookmarks, bookmark))
+}
+
+// Forget removes all bookmarks stored for the chain
+func (m *BookmarkManager) Forget(chain string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.chains, chain)
+}
+
+// ValidateBookmark checks that the bookmark can be sent to Neo4j.  Bookmarks
+// are opaque: Neo4j 3.x returns e.g. `neo4j:bookmark:v1:tx42`, but later
+// versions have their own formats, so only empty bookmarks are invalid.
+func ValidateBookmark(bookmark string) error {
+	if bookmark == "" {
+		return errors.New("Invalid bookmark: bookmarks can't be empty")
+	}
+	return nil
+}
+
+// MergeBookmarks combines the bookmarks from multiple sets, e.g. from parallel writers,
+// into a single set that can be passed to BeginWithBookmarks. Duplicate bookmarks are
+// removed, and an error is returned for the first invalid bookmark.
+func MergeBookmarks(sets ...[]string) ([]string, error) {
+	seen := map[string]bool{}
+	merged := []string{}
+	for _, set := range sets {
+		for _, bookmark := range set {
+			if err := ValidateBookmark(bookmark); err != nil {
+				return nil, err
+			}
+			if !seen[bookmark] {
+				seen[bookmark] = true
+				merged = append(merged, bookmark)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// bookmarkTxID parses the transaction id out of a bookmark
+func bookmarkTxID(bookmark string) (int64, bool) {
+	if !strings.HasPrefix(bookmark, bookmarkPrefix) {
+		return 0, false
+	}
+	txID, err := strconv.ParseInt(bookmark[len(bookmarkPrefix):], 10, 64)
+	if err != nil || txID < 0 {
+		return 0, false
+	}
+	return txID, true
+}
+
+// maxBookmark gets the Neo4j 3.x bookmark with the highest transaction id.
+// Waiting on it implies waiting on all of the others.  Other bookmarks can't
+// be compared, so without a 3.x bookmark the last one is used.
+func maxBookmark(bookmarks []string) string {
+	var max string
+	var maxTxID int64 = -1
+	for _, bookmark := range bookmarks {
+		if txID, ok := bookmarkTxID(bookmark); ok && txID > maxTxID {
+			max = bookmark
+			maxTxID = txID
+		}
+	}
+	if max == "" {
+		return bookmarks[len(bookmarks)-1]
+	}
+	return max
+}
+
+// collapseBookmarks keeps only the Neo4j 3.x bookmark with the highest
+// transaction id, as waiting on it implies waiting on the others.  Other
+// bookmarks can't be compared, so they're all kept.
+func collapseBookmarks(bookmarks []string) []string {
+	var max string
+	var maxTxID int64 = -1
+	for _, bookmark := range bookmarks {
+		if txID, ok := bookmarkTxID(bookmark); ok && txID > maxTxID {
+			max = bookmark
+			maxTxID = txID
+		}
+	}
+
+	collapsed := []string{}
+	for _, bookmark := range bookmarks {
+		if _, ok := bookmarkTxID(bookmark); !ok || bookmark == max {
+			collapsed = append(collapsed, bookmark)
+		}
+	}
+	return collapsed
+}
+
+// bookmarkParams builds the parameters for a BEGIN statement waiting on the given bookmarks
+func bookmarkParams(bookmarks []string) map[string]interface{} {
+	if len(bookmarks) == 0 {
+		return nil
+	}
+
+	list := make([]interface{}, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		list[i] = bookmark
+	}
+
+	return map[string]interface{}{
+		// Neo4j 3.1 only understands a single bookmark
+		"bookmark":  maxBookmark(bookmarks),
+		"bookmarks": list,
+	}
+}
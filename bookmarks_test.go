@@ -0,0 +1,119 @@
+package golangNeo4jBoltDriver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBookmarkManager(t *testing.T) {
+	manager := NewBookmarkManager()
+	if bookmarks := manager.Bookmarks("foo"); len(bookmarks) != 0 {
+		t.Fatalf("Expected no bookmarks for new chain. Got: %#v", bookmarks)
+	}
+
+	manager.UpdateBookmark("foo", "neo4j:bookmark:v1:tx1")
+	manager.UpdateBookmark("foo", "neo4j:bookmark:v1:tx2")
+	manager.UpdateBookmark("bar", "neo4j:bookmark:v1:tx3")
+
+	expected := []string{"neo4j:bookmark:v1:tx2"}
+	if bookmarks := manager.Bookmarks("foo"); !reflect.DeepEqual(bookmarks, expected) {
+		t.Fatalf("Unexpected bookmarks. Expected %#v. Got: %#v", expected, bookmarks)
+	}
+
+	manager.Forget("foo")
+	if bookmarks := manager.Bookmarks("foo"); len(bookmarks) != 0 {
+		t.Fatalf("Expected no bookmarks for forgotten chain. Got: %#v", bookmarks)
+	}
+	if bookmarks := manager.Bookmarks("bar"); len(bookmarks) != 1 {
+		t.Fatalf("Expected other chains to be untouched. Got: %#v", bookmarks)
+	}
+}
+
+func TestBookmarkManager_InterleavedTransactions(t *testing.T) {
+	for _, bookmarks := range [][]string{
+		{"FB:first", "FB:second", "FB:third"},
+		{"neo4j:bookmark:v1:tx1", "neo4j:bookmark:v1:tx2", "neo4j:bookmark:v1:tx3"},
+	} {
+		manager := NewBookmarkManager()
+		manager.UpdateBookmark("foo", bookmarks[0])
+
+		// Both transactions begin before either commits
+		first, second := createBoltConn(""), createBoltConn("")
+		first.SetBookmarkManager(manager, "foo")
+		second.SetBookmarkManager(manager, "foo")
+		firstWaited, secondWaited := manager.Bookmarks("foo"), manager.Bookmarks("foo")
+
+		first.updateBookmark(map[string]interface{}{"bookmark": bookmarks[1]}, firstWaited)
+		second.updateBookmark(map[string]interface{}{"bookmark": bookmarks[2]}, secondWaited)
+
+		// Opaque bookmarks are all kept, but 3.x bookmarks collapse to the latest
+		expected := bookmarks[1:]
+		if _, ok := bookmarkTxID(bookmarks[0]); ok {
+			expected = bookmarks[2:]
+		}
+		if got := manager.Bookmarks("foo"); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Expected the bookmarks of both transactions to be kept. Expected %#v. Got: %#v", expected, got)
+		}
+
+		// A transaction waiting on both replaces them
+		first.updateBookmark(map[string]interface{}{"bookmark": "FB:fourth"}, manager.Bookmarks("foo"))
+		if got := manager.Bookmarks("foo"); !reflect.DeepEqual(got, []string{"FB:fourth"}) {
+			t.Fatalf("Expected the bookmarks waited on to be replaced. Got: %#v", got)
+		}
+	}
+}
+
+func TestBoltConn_UpdateBookmark(t *testing.T) {
+	manager := NewBookmarkManager()
+	c := createBoltConn("")
+	c.SetBookmarkManager(manager, "foo")
+
+	c.updateBookmark(map[string]interface{}{"bookmark": "neo4j:bookmark:v1:tx4"}, nil)
+	if c.LastBookmark() != "neo4j:bookmark:v1:tx4" {
+		t.Fatalf("Unexpected last bookmark: %s", c.LastBookmark())
+	}
+
+	expected := map[string]interface{}{
+		"bookmark":  "neo4j:bookmark:v1:tx4",
+		"bookmarks": []interface{}{"neo4j:bookmark:v1:tx4"},
+	}
+	if params := bookmarkParams(manager.Bookmarks("foo")); !reflect.DeepEqual(params, expected) {
+		t.Fatalf("Unexpected BEGIN params. Expected %#v. Got: %#v", expected, params)
+	}
+}
+
+func TestMergeBookmarks(t *testing.T) {
+	merged, err := MergeBookmarks(
+		[]string{"neo4j:bookmark:v1:tx10", "neo4j:bookmark:v1:tx2"},
+		[]string{"neo4j:bookmark:v1:tx2", "neo4j:bookmark:v1:tx7"},
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error merging bookmarks: %s", err)
+	}
+
+	expected := []string{"neo4j:bookmark:v1:tx10", "neo4j:bookmark:v1:tx2", "neo4j:bookmark:v1:tx7"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("Unexpected merged bookmarks. Expected %#v. Got: %#v", expected, merged)
+	}
+
+	if max := maxBookmark(merged); max != "neo4j:bookmark:v1:tx10" {
+		t.Fatalf("Unexpected max bookmark: %s", max)
+	}
+
+	if _, err := MergeBookmarks([]string{""}); err == nil {
+		t.Fatal("Expected error merging an empty bookmark")
+	}
+
+	// Bookmarks from later versions of Neo4j are opaque
+	opaque := []string{"FB:kcwQnRK2rMmuTNWL1cbwThcdLQSQ", "neo4j:bookmark:v1:tx3"}
+	merged, err = MergeBookmarks(opaque, []string{"FB:kcwQnRK2rMmuTNWL1cbwThcdLQSQ"})
+	if err != nil {
+		t.Fatalf("Unexpected error merging opaque bookmarks: %s", err)
+	}
+	if !reflect.DeepEqual(merged, opaque) {
+		t.Fatalf("Unexpected merged bookmarks. Expected %#v. Got: %#v", opaque, merged)
+	}
+	if max := maxBookmark(merged); max != "neo4j:bookmark:v1:tx3" {
+		t.Fatalf("Unexpected max bookmark: %s", max)
+	}
+}
@@ -0,0 +1,84 @@
+package golangNeo4jBoltDriver
+
+import (
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// BoundStatement is a query bound to its parameters by Statement.Bind.  It
+// isn't tied to a connection, and can't be changed once bound, so it can be
+// run any number of times, at the same time, on connections from a pool
+// with DriverPool.ExecStmt, or on a connection with Run.
+type BoundStatement struct {
+	query  string
+	params map[string]interface{}
+}
+
+// Bind binds the statement's query to the given parameters, checking once
+// that they can be sent to Neo4j.  The parameters are copied, so changing
+// the given maps and lists after doesn't change the bound statement.  The
+// statement's own Params are used if params is nil.
+func (s Statement) Bind(params map[string]interface{}) (BoundStatement, error) {
+	if params == nil {
+		params = s.Params
+	}
+	if _, err := encoding.Marshal(params); err != nil {
+		return BoundStatement{}, errors.Wrap(err, "An error occurred binding parameters for query %s", s.Query)
+	}
+	bound, _ := copyParam(params).(map[string]interface{})
+	return BoundStatement{query: s.Query, params: bound}, nil
+}
+
+// Query gets the query of the bound statement
+func (b BoundStatement) Query() string {
+	return b.query
+}
+
+// Params gets a copy of the parameters of the bound statement
+func (b BoundStatement) Params() map[string]interface{} {
+	params, _ := copyParam(b.params).(map[string]interface{})
+	return params
+}
+
+// Run executes the bound statement on the connection
+func (b BoundStatement) Run(conn Conn) (Result, error) {
+	// A copy, so the connection's defaults and converters can't change the bound params
+	return conn.ExecNeo(b.query, b.Params())
+}
+
+// ExecStmt executes the bound statement on a connection from the pool,
+// closing the connection after.  It's safe to call with the same bound
+// statement from many goroutines.
+func (d *boltDriverPool) ExecStmt(bound BoundStatement) (Result, error) {
+	conn, err := d.OpenPool()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return bound.Run(conn)
+}
+
+// copyParam copies the maps and lists in a parameter value
+func copyParam(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyParam(item)
+		}
+		return copied
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyParam(item)
+		}
+		return copied
+	}
+	return value
+}
@@ -0,0 +1,63 @@
+package golangNeo4jBoltDriver
+
+import (
+	"sync"
+	"testing"
+)
+
+// boundConn records the params of each statement it executes
+type boundConn struct {
+	Conn
+	lock   *sync.Mutex
+	params *[]map[string]interface{}
+}
+
+func (c boundConn) ExecNeo(query string, params map[string]interface{}) (Result, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	*c.params = append(*c.params, params)
+	params["mutated"] = true
+	return newResult(map[string]interface{}{"query": query}), nil
+}
+
+func TestStatement_Bind(t *testing.T) {
+	tags := []interface{}{"a"}
+	params := map[string]interface{}{"name": "Ann", "tags": tags}
+	bound, err := Statement{Query: "CREATE (n {name: $name, tags: $tags})"}.Bind(params)
+	if err != nil {
+		t.Fatalf("An error occurred binding the statement: %s", err)
+	}
+	params["name"] = "Bob"
+	tags[0] = "b"
+
+	var lock sync.Mutex
+	var ran []map[string]interface{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := bound.Run(boundConn{lock: &lock, params: &ran}); err != nil {
+				t.Errorf("An error occurred running the bound statement: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, params := range ran {
+		if params["name"] != "Ann" || params["tags"].([]interface{})[0] != "a" {
+			t.Fatalf("Expected each run to use the params as bound. Got: %#v", params)
+		}
+	}
+	if _, ok := bound.Params()["mutated"]; ok || bound.Query() != "CREATE (n {name: $name, tags: $tags})" {
+		t.Fatalf("Expected runs not to change the bound statement. Got: %#v", bound.Params())
+	}
+
+	if _, err := (Statement{Query: "RETURN $c"}).Bind(map[string]interface{}{"c": make(chan int)}); err == nil {
+		t.Fatal("Expected an error binding a parameter that can't be sent")
+	}
+	bound, err = Statement{Query: "RETURN $x", Params: map[string]interface{}{"x": int64(1)}}.Bind(nil)
+	if err != nil || bound.Params()["x"] != int64(1) {
+		t.Fatalf("Expected the statement's own params to be bound. Got: %#v, %v", bound.Params(), err)
+	}
+}
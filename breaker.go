@@ -0,0 +1,62 @@
+package golangNeo4jBoltDriver
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitOpenError is returned by a pool that is failing fast, after too
+// many consecutive failures connecting to the server
+type CircuitOpenError struct {
+	Failures int
+	Until    time.Time
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("Circuit breaker is open after %d consecutive connection failures. Failing fast until %s", e.Failures, e.Until.Format(time.RFC3339))
+}
+
+// circuitBreaker fails fast after threshold consecutive connection failures,
+// until cooldown has passed.  Then a single connection attempt is let through
+// as a probe: success closes the breaker, failure opens it again.
+//
+// circuitBreaker objects ARE NOT THREAD SAFE. The pool guards its breaker with its lock.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns an error if connection attempts should fail fast
+func (b *circuitBreaker) allow() error {
+	if b == nil || b.failures < b.threshold {
+		return nil
+	}
+	if time.Now().Before(b.openUntil) {
+		return &CircuitOpenError{Failures: b.failures, Until: b.openUntil}
+	}
+	// Half open: let this attempt through as a probe
+	return nil
+}
+
+func (b *circuitBreaker) success() {
+	if b != nil {
+		b.failures = 0
+	}
+}
+
+func (b *circuitBreaker) failure() {
+	if b == nil {
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
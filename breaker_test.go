@@ -0,0 +1,80 @@
+package golangNeo4jBoltDriver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoltDriverPool_CircuitBreaker(t *testing.T) {
+	// Nothing listens on port 1, so every connection attempt fails
+	pool, err := NewDriverPool("bolt://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	pool.SetCircuitBreaker(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_, err := pool.OpenPool()
+		if _, ok := err.(*CircuitOpenError); err == nil || ok {
+			t.Fatalf("Expected a connection error before the breaker opens. Got: %#v", err)
+		}
+	}
+
+	if _, err := pool.OpenPool(); err == nil {
+		t.Fatal("Expected the breaker to be open")
+	} else if _, ok := err.(*CircuitOpenError); !ok {
+		t.Fatalf("Expected a circuit open error. Got: %#v", err)
+	}
+}
+
+func TestBoltDriverPool_PartitionCircuitBreaker(t *testing.T) {
+	pool, err := createDriverPool("bolt://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	pool.SetCircuitBreaker(2, time.Hour)
+
+	batch, err := pool.Partition("batch", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating partition: %s", err)
+	}
+	breaker := batch.(*boltDriverPool).breaker
+	if breaker == nil || breaker == pool.breaker || breaker.threshold != 2 || breaker.cooldown != time.Hour {
+		t.Fatalf("Expected the partition to have its own breaker with the pool's settings. Got: %#v", breaker)
+	}
+
+	// Each counts its own failures, so they can be used at once
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			batch.OpenPool()
+		}
+		close(done)
+	}()
+	for i := 0; i < 3; i++ {
+		pool.OpenPool()
+	}
+	<-done
+	if _, err := batch.OpenPool(); err == nil {
+		t.Fatal("Expected the partition's breaker to be open")
+	} else if _, ok := err.(*CircuitOpenError); !ok {
+		t.Fatalf("Expected a circuit open error. Got: %#v", err)
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	b := newCircuitBreaker(1, time.Millisecond)
+	b.failure()
+	if b.allow() == nil {
+		t.Fatal("Expected the breaker to be open")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("Expected a probe to be allowed after the cooldown. Got: %s", err)
+	}
+	b.success()
+	if err := b.allow(); err != nil {
+		t.Fatalf("Expected the breaker to close after a successful probe. Got: %s", err)
+	}
+}
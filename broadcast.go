@@ -0,0 +1,105 @@
+package golangNeo4jBoltDriver
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// MemberResult is the outcome of a statement run on one server by ExecOnAll.
+// If the statement failed, Err is set and Result is nil.
+type MemberResult struct {
+	Address string
+	Result  Result
+	Err     error
+}
+
+// poolMember is a server the pool, or one of its partitions, connects to
+type poolMember struct {
+	address string
+	open    func() (Conn, error)
+}
+
+// ExecOnAll runs the statement once on every distinct server the pool and its
+// partitions connect to, at the same time, returning the results sorted by
+// server address.  Each server gets a connection from the first pool
+// connecting to it.
+func (d *boltDriverPool) ExecOnAll(query string, params map[string]interface{}) ([]MemberResult, error) {
+	return execOnAll(d.members(), query, params)
+}
+
+// members lists the distinct servers of the pool and its partitions
+func (d *boltDriverPool) members() []poolMember {
+	pools := []*boltDriverPool{d}
+	d.partLock.Lock()
+	names := make([]string, 0, len(d.partitions))
+	for name := range d.partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pools = append(pools, d.partitions[name])
+	}
+	d.partLock.Unlock()
+
+	var members []poolMember
+	seen := map[string]bool{}
+	for _, pool := range pools {
+		address := memberAddress(pool.connStr)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		members = append(members, poolMember{address: address, open: pool.OpenPool})
+	}
+	return members
+}
+
+// memberAddress gets the host and port from the connection string, leaving
+// out the credentials
+func memberAddress(connStr string) string {
+	parsed, err := url.Parse(connStr)
+	if err != nil || parsed.Host == "" {
+		return connStr
+	}
+	return parsed.Host
+}
+
+func execOnAll(members []poolMember, query string, params map[string]interface{}) ([]MemberResult, error) {
+	results := make([]MemberResult, len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		results[i].Address = member.address
+		wg.Add(1)
+		go func(result *MemberResult, open func() (Conn, error)) {
+			defer wg.Done()
+			result.Err = safely("running a statement on "+result.Address, func() error {
+				conn, err := open()
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+				result.Result, err = conn.ExecNeo(query, params)
+				return err
+			})
+		}(&results[i], member.open)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Address < results[j].Address })
+
+	var fanOutErr *FanOutError
+	for i, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if fanOutErr == nil {
+			fanOutErr = &FanOutError{First: result.Err}
+		}
+		fanOutErr.Failed = append(fanOutErr.Failed, i)
+	}
+	if fanOutErr != nil {
+		return results, fanOutErr
+	}
+	return results, nil
+}
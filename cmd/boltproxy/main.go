@@ -0,0 +1,177 @@
+/*Command boltproxy forwards Bolt traffic to a Neo4j server, logging every decoded message.
+
+It works with any Bolt client, not only this driver, which makes it useful
+for debugging what a client is actually sending:
+
+	boltproxy -listen localhost:7688 -target localhost:7687
+
+If -record is given, each proxied connection is also written to
+<dir>/<name>-<n>.json in the same format as the driver's test recordings.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+var (
+	listen = flag.String("listen", "localhost:7688", "Address to listen on for Bolt clients")
+	target = flag.String("target", "localhost:7687", "Address of the Neo4j server to forward to")
+	record = flag.String("record", "", "Directory to write connection recordings to")
+	name   = flag.String("name", "boltproxy", "Name prefix for recording files")
+	level  = flag.String("log", "info", "Log level: error, info or trace")
+)
+
+func main() {
+	flag.Parse()
+	log.SetLevel(*level)
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("Couldn't listen on %s: %s", *listen, err)
+	}
+	log.Infof("Forwarding Bolt traffic from %s to %s", *listen, *target)
+
+	for id := 1; ; id++ {
+		client, err := listener.Accept()
+		if err != nil {
+			log.Errorf("Error accepting connection: %s", err)
+			continue
+		}
+		go proxy(id, client)
+	}
+}
+
+// session is a single proxied client connection
+type session struct {
+	id     int
+	lock   sync.Mutex
+	events []*bolt.Event
+}
+
+func proxy(id int, client net.Conn) {
+	defer client.Close()
+
+	server, err := net.Dial("tcp", *target)
+	if err != nil {
+		log.Errorf("[%d] Couldn't connect to %s: %s", id, *target, err)
+		return
+	}
+	defer server.Close()
+
+	s := &session{id: id}
+	log.Infof("[%d] Client connected from %s", id, client.RemoteAddr())
+
+	if err := s.handShake(client, server); err != nil {
+		log.Errorf("[%d] Handshake failed: %s", id, err)
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go s.forward(client, server, true, done)
+	go s.forward(server, client, false, done)
+	<-done
+
+	log.Infof("[%d] Connection closed", id)
+	if *record != "" {
+		if err := s.writeRecording(); err != nil {
+			log.Errorf("[%d] Couldn't write recording: %s", id, err)
+		}
+	}
+}
+
+// handShake forwards the version negotiation, which isn't chunked
+func (s *session) handShake(client, server net.Conn) error {
+	proposal := make([]byte, 20)
+	if _, err := io.ReadFull(client, proposal); err != nil {
+		return err
+	}
+	if _, err := server.Write(proposal); err != nil {
+		return err
+	}
+	s.record(proposal, true)
+	log.Infof("[%d] CLIENT HANDSHAKE: % x", s.id, proposal)
+
+	version := make([]byte, 4)
+	if _, err := io.ReadFull(server, version); err != nil {
+		return err
+	}
+	if _, err := client.Write(version); err != nil {
+		return err
+	}
+	s.record(version, false)
+	log.Infof("[%d] SERVER HANDSHAKE: % x", s.id, version)
+
+	return nil
+}
+
+// forward copies messages from src to dst as they're read, logging each one
+// decoded.  Structures this driver doesn't know are logged as structures.Raw.
+func (s *session) forward(src, dst net.Conn, isWrite bool, done chan<- struct{}) {
+	defer func() {
+		src.Close()
+		dst.Close()
+		done <- struct{}{}
+	}()
+
+	from := "SERVER"
+	if isWrite {
+		from = "CLIENT"
+	}
+
+	message := &bytes.Buffer{}
+	decoder := encoding.NewDecoder(io.TeeReader(src, io.MultiWriter(dst, message)))
+	decoder.SetRawStructures(true)
+	for {
+		decoded, err := decoder.Decode()
+		if message.Len() > 0 {
+			s.record(message.Bytes(), isWrite)
+			message.Reset()
+		}
+		if err != nil {
+			log.Tracef("[%d] %s stream ended: %s", s.id, from, err)
+			return
+		}
+		log.Infof("[%d] %s: %+v", s.id, from, decoded)
+	}
+}
+
+func (s *session) record(data []byte, isWrite bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.events = append(s.events, &bolt.Event{
+		Timestamp: time.Now().UnixNano(),
+		Event:     append([]byte{}, data...),
+		IsWrite:   isWrite,
+		Completed: true,
+	})
+}
+
+func (s *session) writeRecording() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	path := filepath.Join(*record, fmt.Sprintf("%s-%d.json", *name, s.id))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	log.Infof("[%d] Writing recording to %s", s.id, path)
+	return json.NewEncoder(file).Encode(s.events)
+}
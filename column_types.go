@@ -0,0 +1,118 @@
+package golangNeo4jBoltDriver
+
+import (
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+// ColumnType is the type of a column's values, as observed in the rows
+// returned from Neo
+type ColumnType string
+
+const (
+	// ColumnNull is a column whose values have all been null so far
+	ColumnNull ColumnType = "Null"
+	// ColumnBoolean is a column of booleans
+	ColumnBoolean ColumnType = "Boolean"
+	// ColumnInteger is a column of integers, read as int64
+	ColumnInteger ColumnType = "Integer"
+	// ColumnFloat is a column of floats, read as float64
+	ColumnFloat ColumnType = "Float"
+	// ColumnString is a column of strings
+	ColumnString ColumnType = "String"
+	// ColumnList is a column of lists, read as []interface{}
+	ColumnList ColumnType = "List"
+	// ColumnMap is a column of maps, read as map[string]interface{}
+	ColumnMap ColumnType = "Map"
+	// ColumnNode is a column of graph.Node
+	ColumnNode ColumnType = "Node"
+	// ColumnRelationship is a column of graph.Relationship
+	ColumnRelationship ColumnType = "Relationship"
+	// ColumnUnboundRelationship is a column of graph.UnboundRelationship
+	ColumnUnboundRelationship ColumnType = "UnboundRelationship"
+	// ColumnPath is a column of graph.Path
+	ColumnPath ColumnType = "Path"
+	// ColumnPoint2D is a column of graph.Point2D
+	ColumnPoint2D ColumnType = "Point2D"
+	// ColumnPoint3D is a column of graph.Point3D
+	ColumnPoint3D ColumnType = "Point3D"
+	// ColumnDate is a column of temporal.Date
+	ColumnDate ColumnType = "Date"
+	// ColumnTime is a column of temporal.Time
+	ColumnTime ColumnType = "Time"
+	// ColumnLocalTime is a column of temporal.LocalTime
+	ColumnLocalTime ColumnType = "LocalTime"
+	// ColumnDateTime is a column of date times, read as time.Time
+	ColumnDateTime ColumnType = "DateTime"
+	// ColumnLocalDateTime is a column of temporal.LocalDateTime
+	ColumnLocalDateTime ColumnType = "LocalDateTime"
+	// ColumnDuration is a column of temporal.Duration
+	ColumnDuration ColumnType = "Duration"
+	// ColumnUnknown is a column of values of any other type
+	ColumnUnknown ColumnType = "Unknown"
+)
+
+// columnType gets the column type of a value from a row
+func columnType(value interface{}) ColumnType {
+	switch value.(type) {
+	case nil:
+		return ColumnNull
+	case bool:
+		return ColumnBoolean
+	case int64:
+		return ColumnInteger
+	case float64:
+		return ColumnFloat
+	case string:
+		return ColumnString
+	case []interface{}:
+		return ColumnList
+	case map[string]interface{}:
+		return ColumnMap
+	case graph.Node:
+		return ColumnNode
+	case graph.Relationship:
+		return ColumnRelationship
+	case graph.UnboundRelationship:
+		return ColumnUnboundRelationship
+	case graph.Path:
+		return ColumnPath
+	case graph.Point2D:
+		return ColumnPoint2D
+	case graph.Point3D:
+		return ColumnPoint3D
+	case temporal.Date:
+		return ColumnDate
+	case temporal.Time:
+		return ColumnTime
+	case temporal.LocalTime:
+		return ColumnLocalTime
+	case time.Time:
+		return ColumnDateTime
+	case temporal.LocalDateTime:
+		return ColumnLocalDateTime
+	case temporal.Duration:
+		return ColumnDuration
+	default:
+		return ColumnUnknown
+	}
+}
+
+// observeColumnTypes records the column types of a row.  Columns that were
+// null in earlier rows take the type of the first non-null value.
+func observeColumnTypes(types []ColumnType, row []interface{}) []ColumnType {
+	if types == nil {
+		types = make([]ColumnType, len(row))
+		for i := range types {
+			types[i] = ColumnNull
+		}
+	}
+	for i, value := range row {
+		if i < len(types) && types[i] == ColumnNull {
+			types[i] = columnType(value)
+		}
+	}
+	return types
+}
@@ -3,6 +3,7 @@ package golangNeo4jBoltDriver
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/binary"
 	"io/ioutil"
 	"net"
 	"time"
@@ -15,7 +16,12 @@ import (
 
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
 	"strconv"
+	"syscall"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
@@ -23,6 +29,102 @@ import (
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
 )
 
+// goodbyeTimeout is how long to wait on sending GOODBYE before giving up and closing the connection
+const goodbyeTimeout = 100 * time.Millisecond
+
+const (
+	// defaultMaxIgnored is the default number of IGNORED messages drained while
+	// acknowledging a failure before giving up on the connection
+	defaultMaxIgnored = 100000
+	// defaultIgnoreTimeout is the default time spent draining IGNORED messages
+	// while acknowledging a failure before giving up on the connection
+	defaultIgnoreTimeout = time.Minute
+	// defaultErrQueryLen is the default maximum length of the query text in errors
+	defaultErrQueryLen = 200
+	// defaultPipeWindow is the default maximum number of pipelined queries
+	// sent before their results are read
+	defaultPipeWindow = 100
+	// minWriteBatch and maxWriteBatch bound the bytes written at once with adaptive chunking
+	minWriteBatch = 1 << 16
+	maxWriteBatch = 1 << 22
+	// minRateSample is the smallest write used to measure write throughput,
+	// as smaller writes measure latency more than throughput
+	minRateSample = 1 << 12
+)
+
+// FailureAck selects how a connection acknowledges a FAILURE from the server
+type FailureAck int
+
+const (
+	// FailureAckAuto picks the acknowledgement based on the negotiated protocol
+	// version: ACK_FAILURE before Bolt v3, RESET from Bolt v3 on
+	FailureAckAuto FailureAck = iota
+	// FailureAckAckFailure always acknowledges failures with ACK_FAILURE
+	FailureAckAckFailure
+	// FailureAckReset always acknowledges failures with RESET
+	FailureAckReset
+)
+
+// ServerClosedError is returned when the server closes the connection,
+// e.g. when Neo4j is restarted. Metadata is the last known metadata about
+// the server, from the response to INIT.
+type ServerClosedError struct {
+	Metadata map[string]interface{}
+	Err      error
+}
+
+// Error implements the error interface
+func (e *ServerClosedError) Error() string {
+	if server, ok := e.Metadata["server"]; ok {
+		return fmt.Sprintf("Connection closed by server %v: %s", server, e.Err)
+	}
+	return fmt.Sprintf("Connection closed by server: %s", e.Err)
+}
+
+// IgnoredFloodError is returned when the server sends more IGNORED messages
+// while acknowledging a failure than the connection allows. The connection is
+// closed, as the state of the stream is unknown.
+type IgnoredFloodError struct {
+	Op      string
+	Drained int
+	Elapsed time.Duration
+}
+
+// Error implements the error interface
+func (e *IgnoredFloodError) Error() string {
+	return fmt.Sprintf("Gave up %s after draining %d IGNORED messages in %s. CLOSING SESSION!", e.Op, e.Drained, e.Elapsed)
+}
+
+// TxFailedError is returned when running a statement in a transaction after
+// a statement in it failed, as the transaction can only be rolled back.
+// Committing such a transaction rolls it back, and returns a TxFailedError
+// with RolledBack set.
+type TxFailedError struct {
+	Failure    messages.FailureMessage
+	RolledBack bool
+}
+
+// Error implements the error interface
+func (e *TxFailedError) Error() string {
+	if e.RolledBack {
+		return fmt.Sprintf("Transaction rolled back, as a statement in it failed: %s", e.Failure)
+	}
+	return fmt.Sprintf("Transaction can only be rolled back, as a statement in it failed: %s", e.Failure)
+}
+
+// QueryInterruptedError is returned when a query runs past the maximum
+// execution time of the connection, and is interrupted with RESET.
+// Fingerprint identifies the query in the logs without its text.
+type QueryInterruptedError struct {
+	Fingerprint string
+	Elapsed     time.Duration
+}
+
+// Error implements the error interface
+func (e *QueryInterruptedError) Error() string {
+	return fmt.Sprintf("Query %s interrupted after running for %s", e.Fingerprint, e.Elapsed)
+}
+
 // Conn represents a connection to Neo4J
 //
 // Implements a neo-friendly interface.
@@ -41,7 +143,12 @@ type Conn interface {
 	// QueryNeo queries using the neo4j-specific interface
 	QueryNeo(query string, params map[string]interface{}) (Rows, error)
 	// QueryNeoAll queries using the neo4j-specific interface and returns all row data and output metadata
+	//
+	// Deprecated: Use QueryNeoAllSummary, which returns the metadata as a typed Summary
 	QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error)
+	// QueryNeoAllSummary queries using the neo4j-specific interface and returns all row data,
+	// the column names and the summary of the query
+	QueryNeoAllSummary(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error)
 	// QueryPipeline queries using the neo4j-specific interface
 	// pipelining multiple statements
 	QueryPipeline(query []string, params ...map[string]interface{}) (PipelineRows, error)
@@ -54,12 +161,41 @@ type Conn interface {
 	Close() error
 	// Begin starts a new transaction
 	Begin() (driver.Tx, error)
+	// BeginWithBookmarks starts a new transaction that waits until the
+	// database has caught up to the given bookmarks. See MergeBookmarks.
+	BeginWithBookmarks(bookmarks ...string) (driver.Tx, error)
+	// BeginReadWithBookmarks starts a new read transaction that waits until
+	// the database has caught up to the given bookmarks.  From Bolt v3 on it's
+	// begun in read access mode, so it can be sent to a read replica.
+	BeginReadWithBookmarks(bookmarks ...string) (driver.Tx, error)
 	// SetChunkSize is used to set the max chunk size of the
 	// bytes to send to Neo4j at once
 	SetChunkSize(uint16)
 	// SetTimeout sets the read/write timeouts for the
 	// connection to Neo4j
 	SetTimeout(time.Duration)
+	// SetBookmarkManager attaches the connection to a bookmark chain. Transactions
+	// begun on the connection wait on the chain's bookmarks, and committed
+	// transactions update them.
+	SetBookmarkManager(manager *BookmarkManager, chain string)
+	// LastBookmark gets the bookmark returned by the last transaction
+	// committed on this connection
+	LastBookmark() string
+	// StatementCacheStats gets the hit rate of the statement cache
+	StatementCacheStats() StatementCacheStats
+	// Stats gets when the connection was opened and last used, and how
+	// many queries it has run, to help track down connection leaks
+	Stats() ConnStats
+	// Supports checks whether the connection supports a feature, from the
+	// negotiated Bolt version and the server version, so applications can
+	// branch on it instead of trying and catching failures.  See Feature.
+	Supports(Feature) bool
+	// Options gets the settings of the connection that can be changed
+	// once it's open. See ConnOptions.
+	Options() ConnOptions
+	// SetOptions changes the settings of the connection. Start from the
+	// settings from Options, so the ones not being changed are left as they are.
+	SetOptions(ConnOptions)
 }
 
 type boltConn struct {
@@ -69,8 +205,16 @@ type boltConn struct {
 	password      string
 	conn          net.Conn
 	connErr       error
+	defunctAt     time.Time
+	created       time.Time
+	lastUsed      int64
+	queries       int64
 	serverVersion []byte
+	serverMeta    map[string]interface{}
 	timeout       time.Duration
+	opDeadline    time.Time
+	maxLifetime   time.Duration
+	idleTimeout   time.Duration
 	chunkSize     uint16
 	closed        bool
 	useTLS        bool
@@ -78,6 +222,49 @@ type boltConn struct {
 	caCertFile    string
 	keyFile       string
 	tlsNoVerify   bool
+	validateProps bool
+	maxDepth      int
+	maxSize       int
+	bookmarks     *BookmarkManager
+	bookmarkChain string
+	lastBookmark  string
+	failureAck    FailureAck
+	idleMonitor   bool
+	monitor       chan error
+	receiver      *receiver
+	readRetries   int
+	boltVersion   uint32
+	compatMode    bool
+	graphEncoding string
+	appName       string
+	failed        bool
+	maxIgnored    int
+	ignoreTimeout time.Duration
+	errQueryLen   int
+	legacyTxFail  bool
+	pipeWindow    int
+	adaptiveChunk bool
+	writeRate     float64
+	maxExecTime   time.Duration
+	execStart     time.Time
+	execQuery     string
+	inFlight      []*QueryMetrics
+	runMetrics    *QueryMetrics
+	metricsHook   MetricsHook
+	queryGuard    QueryGuard
+	readOnly      bool
+	lazyMeta      bool
+	missingFields MissingFields
+	stmtCache     *stmtCache
+	encoder       *encoding.Encoder
+	decoder       *encoding.Decoder
+	stream        *streamReader
+	defaults      *connDefaults
+	rawStructs    bool
+	defaultParams map[string]interface{}
+	asyncClose    bool
+	logger        *log.Logger
+	draining      chan error
 	transaction   *boltTx
 	statement     *boltStmt
 	driver        *boltDriver
@@ -90,6 +277,10 @@ func createBoltConn(connStr string) *boltConn {
 		timeout:       time.Second * time.Duration(60),
 		chunkSize:     math.MaxUint16,
 		serverVersion: make([]byte, 4),
+		maxIgnored:    defaultMaxIgnored,
+		ignoreTimeout: defaultIgnoreTimeout,
+		errQueryLen:   defaultErrQueryLen,
+		pipeWindow:    defaultPipeWindow,
 	}
 }
 
@@ -98,6 +289,10 @@ func newBoltConn(connStr string, driver *boltDriver) (*boltConn, error) {
 
 	c := createBoltConn(connStr)
 	c.driver = driver
+	c.logger = driver.logger
+	c.appName = driver.appName
+	c.metricsHook = driver.metricsHook
+	c.queryGuard = driver.queryGuard
 
 	err := c.initialize()
 	if err != nil {
@@ -145,6 +340,88 @@ func (c *boltConn) parseURL() (*url.URL, error) {
 		c.timeout = time.Duration(timeoutInt) * time.Second
 	}
 
+	chunkSize := url.Query().Get("chunk_size")
+	if strings.ToLower(chunkSize) == "auto" {
+		c.adaptiveChunk = true
+	} else if chunkSize != "" {
+		chunkSizeInt, err := strconv.ParseUint(chunkSize, 10, 16)
+		if err != nil || chunkSizeInt == 0 {
+			return url, errors.New("Invalid format for chunk_size: %s.  Must be an integer between 1 and %d", chunkSize, math.MaxUint16)
+		}
+
+		c.chunkSize = uint16(chunkSizeInt)
+	}
+
+	maxExecTime := url.Query().Get("max_execution_time")
+	if maxExecTime != "" {
+		maxExecTimeInt, err := strconv.Atoi(maxExecTime)
+		if err != nil || maxExecTimeInt < 0 {
+			return url, errors.New("Invalid format for max_execution_time: %s.  Must be a positive integer", maxExecTime)
+		}
+
+		c.maxExecTime = time.Duration(maxExecTimeInt) * time.Second
+	}
+
+	if appName := url.Query().Get("application_name"); appName != "" {
+		c.appName = appName
+	}
+
+	// Fetching results in batches and selecting a database need Bolt v4, so
+	// they fail clearly instead of being silently ignored
+	if fetchSize := url.Query().Get("fetch_size"); fetchSize != "" {
+		return url, errors.New("Unsupported fetch_size: %s.  Results are streamed in full until Bolt v4, which the driver doesn't support", fetchSize)
+	}
+	if database := url.Query().Get("database"); database != "" {
+		return url, errors.New("Unsupported database: %s.  Selecting a database needs Bolt v4, which the driver doesn't support", database)
+	}
+
+	readRetries := url.Query().Get("read_retries")
+	if readRetries != "" {
+		readRetriesInt, err := strconv.Atoi(readRetries)
+		if err != nil || readRetriesInt < 0 {
+			return url, errors.New("Invalid format for read_retries: %s.  Must be a positive integer", readRetries)
+		}
+
+		c.readRetries = readRetriesInt
+	}
+
+	boltVersion := url.Query().Get("bolt_version")
+	if boltVersion != "" {
+		boltVersionInt, err := strconv.ParseUint(boltVersion, 10, 32)
+		if err != nil {
+			return url, errors.New("Invalid format for bolt_version: %s.  Must be a positive integer", boltVersion)
+		}
+
+		version := make([]byte, 4)
+		binary.BigEndian.PutUint32(version, uint32(boltVersionInt))
+		if !isProposedVersion(version) || boltVersionInt == 0 {
+			return url, errors.New("Unsupported bolt_version: %s.  Supported versions: %s", boltVersion, strings.Join(proposedVersions(), ", "))
+		}
+		c.boltVersion = uint32(boltVersionInt)
+	}
+
+	c.graphEncoding = strings.ToLower(url.Query().Get("graph_encoding"))
+	if c.graphEncoding != "" && c.graphEncoding != "bolt" && c.graphEncoding != "json" {
+		return url, errors.New("Invalid graph_encoding: %s.  Must be bolt or json", c.graphEncoding)
+	}
+
+	c.missingFields = MissingFields(strings.ToLower(url.Query().Get("missing_fields")))
+	if c.missingFields != "" && c.missingFields != MissingFieldsEmpty && c.missingFields != MissingFieldsColumns && c.missingFields != MissingFieldsError {
+		return url, errors.New("Invalid missing_fields: %s.  Must be empty, columns or error", c.missingFields)
+	}
+
+	compatMode := url.Query().Get("compat_mode")
+	c.compatMode = strings.HasPrefix(strings.ToLower(compatMode), "t") || compatMode == "1"
+
+	readOnly := url.Query().Get("read_only")
+	c.readOnly = strings.HasPrefix(strings.ToLower(readOnly), "t") || readOnly == "1"
+
+	lazyMeta := url.Query().Get("lazy_metadata")
+	c.lazyMeta = strings.HasPrefix(strings.ToLower(lazyMeta), "t") || lazyMeta == "1"
+
+	idleMonitor := url.Query().Get("idle_monitor")
+	c.idleMonitor = strings.HasPrefix(strings.ToLower(idleMonitor), "t") || idleMonitor == "1"
+
 	useTLS := url.Query().Get("tls")
 	c.useTLS = strings.HasPrefix(strings.ToLower(useTLS), "t") || useTLS == "1"
 
@@ -156,15 +433,27 @@ func (c *boltConn) parseURL() (*url.URL, error) {
 		c.tlsNoVerify = strings.HasPrefix(strings.ToLower(noVerify), "t") || noVerify == "1"
 	}
 
-	log.Trace("Bolt Host: ", url.Host)
-	log.Trace("Timeout: ", c.timeout)
-	log.Trace("User: ", user)
-	log.Trace("Password: ", password)
-	log.Trace("TLS: ", c.useTLS)
-	log.Trace("TLS No Verify: ", c.tlsNoVerify)
-	log.Trace("Cert File: ", c.certFile)
-	log.Trace("Key File: ", c.keyFile)
-	log.Trace("CA Cert File: ", c.caCertFile)
+	c.logger.Trace("Bolt Host: ", url.Host)
+	c.logger.Trace("Timeout: ", c.timeout)
+	c.logger.Trace("User: ", user)
+	c.logger.Trace("Password: ", password)
+	c.logger.Trace("Chunk Size: ", c.chunkSize)
+	c.logger.Trace("Adaptive Chunking: ", c.adaptiveChunk)
+	c.logger.Trace("Max Execution Time: ", c.maxExecTime)
+	c.logger.Trace("Application Name: ", c.appName)
+	c.logger.Trace("Read Retries: ", c.readRetries)
+	c.logger.Trace("Bolt Version: ", c.boltVersion)
+	c.logger.Trace("Compatibility Mode: ", c.compatMode)
+	c.logger.Trace("Graph Encoding: ", c.graphEncoding)
+	c.logger.Trace("Missing Fields: ", c.missingFields)
+	c.logger.Trace("Read Only: ", c.readOnly)
+	c.logger.Trace("Lazy Metadata: ", c.lazyMeta)
+	c.logger.Trace("Idle Monitor: ", c.idleMonitor)
+	c.logger.Trace("TLS: ", c.useTLS)
+	c.logger.Trace("TLS No Verify: ", c.tlsNoVerify)
+	c.logger.Trace("Cert File: ", c.certFile)
+	c.logger.Trace("Key File: ", c.keyFile)
+	c.logger.Trace("CA Cert File: ", c.caCertFile)
 
 	return url, nil
 }
@@ -184,7 +473,9 @@ func (c *boltConn) createConn() (net.Conn, error) {
 			return nil, errors.Wrap(err, "An error occurred setting up TLS configuration")
 		}
 		conn, err = tls.Dial("tcp", c.url.Host, config)
-		if err != nil {
+		if _, ok := err.(tls.RecordHeaderError); ok {
+			return nil, errors.Wrap(err, "An error occurred dialing to neo4j. The server doesn't appear to use TLS, try connecting without tls=true")
+		} else if err != nil {
 			return nil, errors.Wrap(err, "An error occurred dialing to neo4j")
 		}
 	} else {
@@ -237,9 +528,17 @@ func (c *boltConn) tlsConfig() (*tls.Config, error) {
 
 func (c *boltConn) handShake() error {
 
-	numWritten, err := c.Write(handShake)
+	proposal := handShake
+	if c.boltVersion != 0 {
+		// Only propose the pinned version
+		proposal = make([]byte, len(handShake))
+		copy(proposal, magicPreamble)
+		binary.BigEndian.PutUint32(proposal[len(magicPreamble):], c.boltVersion)
+	}
+
+	numWritten, err := c.Write(proposal)
 	if numWritten != 20 {
-		log.Errorf("Couldn't write expected bytes for magic preamble + supported versions. Written: %d. Expected: 4", numWritten)
+		c.logger.Errorf("Couldn't write expected bytes for magic preamble + supported versions. Written: %d. Expected: 4", numWritten)
 		if err != nil {
 			err = errors.Wrap(err, "An error occurred writing magic preamble + supported versions")
 		}
@@ -248,18 +547,54 @@ func (c *boltConn) handShake() error {
 
 	numRead, err := c.Read(c.serverVersion)
 	if numRead != 4 {
-		log.Errorf("Could not read server version response. Read %d bytes. Expected 4 bytes. Output: %s", numRead, c.serverVersion)
-		if err != nil {
+		c.logger.Errorf("Could not read server version response. Read %d bytes. Expected 4 bytes. Output: %s", numRead, c.serverVersion)
+		if err != nil && !c.useTLS {
+			err = errors.Wrap(err, "An error occurred reading server version. The server may require TLS, try connecting with tls=true")
+		} else if err != nil {
 			err = errors.Wrap(err, "An error occurred reading server version")
 		}
 		return err
+	} else if bytes.Equal(c.serverVersion, []byte("HTTP")) {
+		return errors.New("Server responded with HTTP. Check that the port is the Bolt port (7687 by default), not the HTTP port")
 	} else if bytes.Equal(c.serverVersion, noVersionSupported) {
-		return errors.New("Server responded with no supported version")
+		return errors.New("Server responded with no supported version. It doesn't support any of the proposed Bolt versions: %s", strings.Join(proposedVersions(), ", "))
+	} else if !isProposedVersion(c.serverVersion) {
+		return errors.New("Server responded with Bolt version %s, which wasn't proposed. Proposed versions: %s", formatBoltVersion(c.serverVersion), strings.Join(proposedVersions(), ", "))
 	}
 
 	return nil
 }
 
+// formatBoltVersion formats a version from the handshake.  From Bolt v4 on,
+// the minor version is in the third byte.
+func formatBoltVersion(version []byte) string {
+	if version[3] >= 4 {
+		return fmt.Sprintf("%d.%d", version[3], version[2])
+	}
+	return fmt.Sprintf("%d", binary.BigEndian.Uint32(version))
+}
+
+// proposedVersions gets the formatted versions this driver proposes during the handshake
+func proposedVersions() []string {
+	versions := []string{}
+	for i := 0; i < len(supportedVersions); i += 4 {
+		if version := supportedVersions[i : i+4]; !bytes.Equal(version, noVersionSupported) {
+			versions = append(versions, formatBoltVersion(version))
+		}
+	}
+	return versions
+}
+
+// isProposedVersion checks whether this driver proposed the version the server chose
+func isProposedVersion(version []byte) bool {
+	for i := 0; i < len(supportedVersions); i += 4 {
+		if bytes.Equal(version, supportedVersions[i:i+4]) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *boltConn) initialize() error {
 
 	// Handle recorder. If there is no conn string, assume we're playing back a recording.
@@ -273,7 +608,7 @@ func (c *boltConn) initialize() error {
 		if err != nil {
 			// Return the connection back into the pool
 			if e := c.Close(); e != nil {
-				log.Errorf("An error occurred closing connection: %s", e)
+				c.logger.Errorf("An error occurred closing connection: %s", e)
 			}
 			return err
 		}
@@ -283,7 +618,7 @@ func (c *boltConn) initialize() error {
 		if err != nil {
 			// Return the connection back into the pool
 			if e := c.Close(); e != nil {
-				log.Errorf("An error occurred closing connection: %s", e)
+				c.logger.Errorf("An error occurred closing connection: %s", e)
 			}
 			return err
 		}
@@ -291,7 +626,7 @@ func (c *boltConn) initialize() error {
 
 	if err := c.handShake(); err != nil {
 		if e := c.Close(); e != nil {
-			log.Errorf("An error occurred closing connection: %s", e)
+			c.logger.Errorf("An error occurred closing connection: %s", e)
 		}
 		return err
 	}
@@ -299,18 +634,21 @@ func (c *boltConn) initialize() error {
 	respInt, err := c.sendInit()
 	if err != nil {
 		if e := c.Close(); e != nil {
-			log.Errorf("An error occurred closing connection: %s", e)
+			c.logger.Errorf("An error occurred closing connection: %s", e)
 		}
 		return err
 	}
 
 	switch resp := respInt.(type) {
 	case messages.SuccessMessage:
-		log.Infof("Successfully initiated Bolt connection: %+v", resp)
+		c.logger.Infof("Successfully initiated Bolt connection: %+v", resp)
+		c.serverMeta = resp.Metadata
+		c.created = time.Now()
+		c.saveDefaults()
 		return nil
 	default:
-		log.Errorf("Got an unrecognized message when initializing connection :%+v", resp)
-		c.connErr = errors.New("Unrecognized response from the server: %#v", resp)
+		c.logger.Errorf("Got an unrecognized message when initializing connection :%+v", resp)
+		c.markDefunct(errors.New("Unrecognized response from the server: %#v", resp))
 		c.Close()
 		return driver.ErrBadConn
 	}
@@ -318,39 +656,95 @@ func (c *boltConn) initialize() error {
 
 // Read reads the data from the underlying connection
 func (c *boltConn) Read(b []byte) (n int, err error) {
-	if err := c.conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
-		c.connErr = errors.Wrap(err, "An error occurred setting read deadline")
+	return c.read(b, false, c.markDefunct)
+}
+
+// read reads the data from the underlying connection, passing the error
+// that breaks the connection, if any, to broken.  If awaiting a message, a
+// timeout before anything is read is retried.
+func (c *boltConn) read(b []byte, awaiting bool, broken func(error)) (n int, err error) {
+	if err := c.conn.SetReadDeadline(c.ioDeadline()); err != nil {
+		broken(errors.Wrap(err, "An error occurred setting read deadline"))
 		return 0, driver.ErrBadConn
 	}
 
 	n, err = c.conn.Read(b)
 
-	if log.GetLevel() >= log.TraceLevel {
-		log.Tracef("Read %d bytes from stream:\n\n%s\n", n, sprintByteHex(b))
+	// A timeout before any of the message is read leaves the stream
+	// in a consistent state, so it's safe to try again
+	for retry := 1; awaiting && n == 0 && isTimeout(err) && retry <= c.readRetries; retry++ {
+		c.logger.Infof("Timed out waiting for message, retrying read (%d/%d)", retry, c.readRetries)
+		if err := c.conn.SetReadDeadline(c.ioDeadline()); err != nil {
+			broken(errors.Wrap(err, "An error occurred setting read deadline"))
+			return 0, driver.ErrBadConn
+		}
+		n, err = c.conn.Read(b)
+	}
+
+	if c.logger.GetLevel() >= log.TraceLevel {
+		c.logger.Tracef("Read %d bytes from stream:\n\n%s\n", n, sprintByteHex(b))
 	}
 
-	if err != nil && err != io.EOF {
-		c.connErr = errors.Wrap(err, "An error occurred reading from stream")
+	if isServerClosed(err) {
+		closed := &ServerClosedError{Metadata: c.serverMeta, Err: err}
+		broken(closed)
+		err = closed
+	} else if err != nil {
+		broken(errors.Wrap(err, "An error occurred reading from stream"))
 		err = driver.ErrBadConn
 	}
 	return n, err
 }
 
+// ioDeadline gets the deadline for the next read or write: the connection
+// timeout, unless the operation budget of a pooled connection runs out first
+func (c *boltConn) ioDeadline() time.Time {
+	deadline := time.Now().Add(c.timeout)
+	if !c.opDeadline.IsZero() && c.opDeadline.Before(deadline) {
+		return c.opDeadline
+	}
+	return deadline
+}
+
+// isTimeout checks if a read error was caused by the read deadline
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// isServerClosed checks if a read error means the server closed the connection
+func isServerClosed(err error) bool {
+	if err == io.EOF {
+		return true
+	}
+
+	opErr, ok := err.(*net.OpError)
+	if !ok {
+		return false
+	}
+	sysErr, ok := opErr.Err.(*os.SyscallError)
+	return ok && sysErr.Err == syscall.ECONNRESET
+}
+
 // Write writes the data to the underlying connection
 func (c *boltConn) Write(b []byte) (n int, err error) {
-	if err := c.conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
-		c.connErr = errors.Wrap(err, "An error occurred setting write deadline")
+	if err := c.conn.SetWriteDeadline(c.ioDeadline()); err != nil {
+		c.markDefunct(errors.Wrap(err, "An error occurred setting write deadline"))
 		return 0, driver.ErrBadConn
 	}
 
+	start := time.Now()
 	n, err = c.conn.Write(b)
+	if c.adaptiveChunk && n >= minRateSample {
+		c.sampleWriteRate(n, time.Since(start))
+	}
 
-	if log.GetLevel() >= log.TraceLevel {
-		log.Tracef("Wrote %d of %d bytes to stream:\n\n%s\n", len(b), n, sprintByteHex(b[:n]))
+	if c.logger.GetLevel() >= log.TraceLevel {
+		c.logger.Tracef("Wrote %d of %d bytes to stream:\n\n%s\n", len(b), n, sprintByteHex(b[:n]))
 	}
 
 	if err != nil {
-		c.connErr = errors.Wrap(err, "An error occurred writing to stream")
+		c.markDefunct(errors.Wrap(err, "An error occurred writing to stream"))
 		err = driver.ErrBadConn
 	}
 	return n, err
@@ -364,6 +758,16 @@ func (c *boltConn) Close() error {
 		return nil
 	}
 
+	if err := c.awaitDrain(); err != nil {
+		c.logger.Errorf("An error occurred closing rows in the background: %s", err)
+	}
+
+	if c.connErr != nil {
+		// Nothing more can be sent on a defunct connection
+		c.statement = nil
+		c.transaction = nil
+	}
+
 	if c.statement != nil {
 		if err := c.statement.Close(); err != nil {
 			return err
@@ -380,18 +784,18 @@ func (c *boltConn) Close() error {
 		// If using connection pooling, don't close connection, just reclaim it
 		err := c.poolDriver.reclaim(c)
 		if err != nil {
-			log.Errorf("An error occurred reclaiming connection for pool: %s", err)
-			c.connErr = errors.Wrap(err, "An error occurred closing the connection")
+			c.logger.Errorf("An error occurred reclaiming connection for pool: %s", err)
+			c.markDefunct(errors.Wrap(err, "An error occurred closing the connection"))
 			return driver.ErrBadConn
 		}
 		return nil
 	}
 
 	if c.conn != nil {
-		err := c.conn.Close()
+		err := c.closeConn()
 		c.closed = true
 		if err != nil {
-			c.connErr = errors.Wrap(err, "An error occurred closing the connection")
+			c.markDefunct(errors.Wrap(err, "An error occurred closing the connection"))
 			return driver.ErrBadConn
 		}
 	}
@@ -399,72 +803,166 @@ func (c *boltConn) Close() error {
 	return nil
 }
 
+// closeConn closes the underlying connection, saying GOODBYE first
+// when the negotiated protocol supports it so the server sees a clean disconnect
+func (c *boltConn) closeConn() error {
+	if c.conn == nil {
+		c.stopReceiver()
+		return nil
+	}
+	if c.connErr == nil && len(c.serverVersion) == 4 && c.featureVersion() >= 3 {
+		c.logger.Info("Sending GOODBYE message")
+		// Bypass Write, so a hung connection only holds up the close for a moment
+		if err := c.conn.SetWriteDeadline(time.Now().Add(goodbyeTimeout)); err == nil {
+			if err := encoding.NewEncoder(c.conn, c.chunkSize).Encode(messages.NewGoodbyeMessage()); err != nil {
+				c.logger.Errorf("An error occurred sending GOODBYE: %s", err)
+			}
+		}
+	}
+
+	// Closing the connection ends a read the receive loop is waiting on, so
+	// it can be stopped
+	err := c.conn.Close()
+	c.stopReceiver()
+	return err
+}
+
+// protocolVersion gets the Bolt protocol version negotiated during the handshake
+func (c *boltConn) protocolVersion() uint32 {
+	return binary.BigEndian.Uint32(c.serverVersion)
+}
+
+// featureVersion gets the Bolt protocol version whose features the connection
+// uses.  In compatibility mode, features newer than Bolt v1 are disabled even
+// when a newer version was negotiated.
+func (c *boltConn) featureVersion() uint32 {
+	if c.compatMode {
+		return 1
+	}
+	return c.protocolVersion()
+}
+
+// SetFailureAck overrides how failures from the server are acknowledged
+func (c *boltConn) SetFailureAck(failureAck FailureAck) {
+	c.failureAck = failureAck
+}
+
 func (c *boltConn) ackFailure(failure messages.FailureMessage) error {
-	log.Infof("Acknowledging Failure: %#v", failure)
+	switch c.failureAck {
+	case FailureAckReset:
+		return c.resetFailure(failure)
+	case FailureAckAckFailure:
+	default:
+		// ACK_FAILURE was removed in Bolt v3, so compatibility mode can't bring it back
+		if c.protocolVersion() >= 3 {
+			return c.resetFailure(failure)
+		}
+	}
+
+	c.logger.Infof("Acknowledging Failure: %#v", failure)
 
 	ack := messages.NewAckFailureMessage()
-	err := encoding.NewEncoder(c, c.chunkSize).Encode(ack)
+	err := c.encode(ack)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred encoding ack failure message")
 	}
 
+	drained, start := 0, time.Now()
 	for {
-		respInt, err := encoding.NewDecoder(c).Decode()
+		respInt, err := c.receive()
 		if err != nil {
 			return errors.Wrap(err, "An error occurred decoding ack failure message response")
 		}
 
 		switch resp := respInt.(type) {
 		case messages.IgnoredMessage:
-			log.Infof("Got ignored message when acking failure: %#v", resp)
+			c.logger.Infof("Got ignored message when acking failure: %#v", resp)
+			drained++
+			if err := c.checkIgnored("acking failure", drained, start); err != nil {
+				return err
+			}
 			continue
 		case messages.SuccessMessage:
-			log.Infof("Got success message when acking failure: %#v", resp)
+			c.logger.Infof("Got success message when acking failure: %#v", resp)
 			return nil
 		case messages.FailureMessage:
-			log.Errorf("Got failure message when acking failure: %#v", resp)
+			c.logger.Errorf("Got failure message when acking failure: %#v", resp)
 			return c.reset()
 		default:
-			log.Errorf("Got unrecognized response from acking failure: %#v", resp)
-			c.connErr = errors.New("Got unrecognized response from acking failure: %#v. CLOSING SESSION!", resp)
+			c.logger.Errorf("Got unrecognized response from acking failure: %#v", resp)
+			c.markDefunct(errors.New("Got unrecognized response from acking failure: %#v. CLOSING SESSION!", resp))
 			c.Close()
 			return driver.ErrBadConn
 		}
 	}
 }
 
+// SetIgnoredLimit bounds the IGNORED messages drained while acknowledging a failure
+func (c *boltConn) SetIgnoredLimit(max int, timeout time.Duration) {
+	c.maxIgnored = max
+	c.ignoreTimeout = timeout
+}
+
+// checkIgnored closes the connection if too many IGNORED messages have been
+// drained, or for too long, so a confused server can't keep us reading forever
+func (c *boltConn) checkIgnored(op string, drained int, start time.Time) error {
+	elapsed := time.Since(start)
+	if (c.maxIgnored <= 0 || drained <= c.maxIgnored) && (c.ignoreTimeout <= 0 || elapsed <= c.ignoreTimeout) {
+		return nil
+	}
+
+	err := &IgnoredFloodError{Op: op, Drained: drained, Elapsed: elapsed}
+	c.logger.Error(err)
+	c.markDefunct(err)
+	c.Close()
+	return err
+}
+
+func (c *boltConn) resetFailure(failure messages.FailureMessage) error {
+	c.logger.Infof("Acknowledging Failure with reset: %#v", failure)
+	return c.reset()
+}
+
 func (c *boltConn) reset() error {
-	log.Info("Resetting session")
+	c.logger.Info("Resetting session")
 
 	reset := messages.NewResetMessage()
-	err := encoding.NewEncoder(c, c.chunkSize).Encode(reset)
+	err := c.encode(reset)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred encoding reset message")
 	}
 
+	drained, start := 0, time.Now()
 	for {
-		respInt, err := encoding.NewDecoder(c).Decode()
+		respInt, err := c.receive()
 		if err != nil {
 			return errors.Wrap(err, "An error occurred decoding reset message response")
 		}
 
 		switch resp := respInt.(type) {
 		case messages.IgnoredMessage:
-			log.Infof("Got ignored message when resetting session: %#v", resp)
+			c.logger.Infof("Got ignored message when resetting session: %#v", resp)
+			drained++
+			if err := c.checkIgnored("resetting session", drained, start); err != nil {
+				return err
+			}
 			continue
 		case messages.SuccessMessage:
-			log.Infof("Got success message when resetting session: %#v", resp)
+			c.logger.Infof("Got success message when resetting session: %#v", resp)
+			if c.transaction != nil {
+				c.transaction.reset = true
+			}
 			return nil
 		case messages.FailureMessage:
-			log.Errorf("Got failure message when resetting session: %#v", resp)
+			c.logger.Errorf("Got failure message when resetting session: %#v", resp)
 			err = c.Close()
 			if err != nil {
-				log.Errorf("An error occurred closing the session: %s", err)
+				c.logger.Errorf("An error occurred closing the session: %s", err)
 			}
 			return errors.Wrap(resp, "Error resetting session. CLOSING SESSION!")
 		default:
-			log.Errorf("Got unrecognized response from resetting session: %#v", resp)
-			c.connErr = errors.New("Got unrecognized response from resetting session: %#v. CLOSING SESSION!", resp)
+			c.logger.Errorf("Got unrecognized response from resetting session: %#v", resp)
+			c.markDefunct(errors.New("Got unrecognized response from resetting session: %#v. CLOSING SESSION!", resp))
 			c.Close()
 			return driver.ErrBadConn
 		}
@@ -483,22 +981,16 @@ func (c *boltConn) PrepareNeo(query string) (Stmt, error) {
 
 // PreparePipeline prepares a new pipeline statement for a query.
 func (c *boltConn) PreparePipeline(queries ...string) (PipelineStmt, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
-	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	if err := c.checkState("prepare a pipeline", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 	c.statement = newPipelineStmt(queries, c)
 	return c.statement, nil
 }
 
 func (c *boltConn) prepare(query string) (*boltStmt, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
-	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	if err := c.checkState("prepare a statement", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 	c.statement = newStmt(query, c)
 	return c.statement, nil
@@ -506,17 +998,52 @@ func (c *boltConn) prepare(query string) (*boltStmt, error) {
 
 // Begin begins a new transaction with the Neo4J Database
 func (c *boltConn) Begin() (driver.Tx, error) {
-	if c.transaction != nil {
-		return nil, errors.New("An open transaction already exists")
+	return c.begin(nil, false)
+}
+
+// BeginWithBookmarks begins a new transaction with the Neo4J Database that
+// waits until the database has caught up to the given bookmarks
+func (c *boltConn) BeginWithBookmarks(bookmarks ...string) (driver.Tx, error) {
+	merged, err := MergeBookmarks(bookmarks)
+	if err != nil {
+		return nil, err
 	}
-	if c.statement != nil {
-		return nil, errors.New("Cannot open a transaction when you already have an open statement")
+	return c.begin(merged, false)
+}
+
+// BeginReadWithBookmarks begins a new read transaction with the Neo4J Database
+// that waits until the database has caught up to the given bookmarks
+func (c *boltConn) BeginReadWithBookmarks(bookmarks ...string) (driver.Tx, error) {
+	merged, err := MergeBookmarks(bookmarks)
+	if err != nil {
+		return nil, err
 	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	return c.begin(merged, true)
+}
+
+// begin begins a transaction.  A read transaction is marked as such from
+// Bolt v3 on, so a server or proxy can send it to a read replica.
+func (c *boltConn) begin(bookmarks []string, read bool) (driver.Tx, error) {
+	if err := c.checkState("begin a transaction", stateReady); err != nil {
+		return nil, err
+	}
+
+	if c.bookmarks != nil {
+		var err error
+		bookmarks, err = MergeBookmarks(c.bookmarks.Bookmarks(c.bookmarkChain), bookmarks)
+		if err != nil {
+			return nil, errors.Wrap(err, "An error occurred merging bookmarks from the bookmark manager")
+		}
+	}
+	params := bookmarkParams(bookmarks)
+	if read && c.Supports(FeatureTransactionMessages) {
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		params["mode"] = "r"
 	}
 
-	successInt, pullInt, err := c.sendRunPullAllConsumeSingle("BEGIN", nil)
+	successInt, pullInt, err := c.sendTxControl("BEGIN", params)
 	if err != nil {
 		return nil, errors.Wrap(err, "An error occurred beginning transaction")
 	}
@@ -526,21 +1053,60 @@ func (c *boltConn) Begin() (driver.Tx, error) {
 		return nil, errors.New("Unrecognized response type beginning transaction: %#v", success)
 	}
 
-	log.Infof("Got success message beginning transaction: %#v", success)
+	c.logger.Infof("Got success message beginning transaction: %#v", success)
 
 	success, ok = pullInt.(messages.SuccessMessage)
 	if !ok {
 		return nil, errors.New("Unrecognized response type pulling transaction:  %#v", success)
 	}
 
-	log.Infof("Got success message pulling transaction: %#v", success)
+	c.logger.Infof("Got success message pulling transaction: %#v", success)
 
-	return newTx(c), nil
+	c.transaction = newTx(c)
+	c.transaction.bookmarks = bookmarks
+	return c.transaction, nil
 }
 
 // Sets the size of the chunks to write to the stream
 func (c *boltConn) SetChunkSize(chunkSize uint16) {
 	c.chunkSize = chunkSize
+	c.adaptiveChunk = false
+	c.encoder = nil
+}
+
+// SetAdaptiveChunking makes the connection size its writes to the messages and the connection
+func (c *boltConn) SetAdaptiveChunking(adaptive bool) {
+	c.adaptiveChunk = adaptive
+	c.encoder = nil
+}
+
+// sampleWriteRate updates the moving average of the write throughput
+func (c *boltConn) sampleWriteRate(n int, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	rate := float64(n) / elapsed.Seconds()
+	if c.writeRate == 0 {
+		c.writeRate = rate
+	} else {
+		c.writeRate = 0.8*c.writeRate + 0.2*rate
+	}
+}
+
+// writeBatch gets the number of bytes to write at once with adaptive chunking,
+// so a write at the throughput seen so far takes a quarter of the timeout
+func (c *boltConn) writeBatch() int {
+	if c.writeRate == 0 || c.timeout <= 0 {
+		return minWriteBatch
+	}
+
+	batch := int(c.writeRate * c.timeout.Seconds() / 4)
+	if batch < minWriteBatch {
+		return minWriteBatch
+	} else if batch > maxWriteBatch {
+		return maxWriteBatch
+	}
+	return batch
 }
 
 // Sets the timeout for reading and writing to the stream
@@ -548,24 +1114,318 @@ func (c *boltConn) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
 }
 
+// SetPropertyValidation enables checking query parameters against
+// Neo4j's property storage rules before sending them
+func (c *boltConn) SetPropertyValidation(validate bool) {
+	c.validateProps = validate
+}
+
+// SetEncodingLimits sets the maximum nesting depth and encoded size in bytes
+// of the messages sent to Neo4j. 0 means no limit.
+func (c *boltConn) SetEncodingLimits(maxDepth int, maxSize int) {
+	c.maxDepth = maxDepth
+	c.maxSize = maxSize
+	c.encoder = nil
+}
+
+// SetBookmarkManager attaches the connection to a bookmark chain
+func (c *boltConn) SetBookmarkManager(manager *BookmarkManager, chain string) {
+	c.bookmarks = manager
+	c.bookmarkChain = chain
+}
+
+// LastBookmark gets the bookmark returned by the last transaction committed on this connection
+func (c *boltConn) LastBookmark() string {
+	return c.lastBookmark
+}
+
+// updateBookmark records the bookmark from the metadata of a commit of a
+// transaction that began waiting on the given bookmarks
+func (c *boltConn) updateBookmark(metadata map[string]interface{}, waited []string) {
+	bookmark, ok := messages.NewSuccessMessage(metadata).Bookmark()
+	if !ok {
+		return
+	}
+
+	c.lastBookmark = bookmark
+	if c.bookmarks != nil {
+		c.bookmarks.advance(c.bookmarkChain, waited, bookmark)
+	}
+}
+
+// SetStatementCacheSize sets the number of prepared queries remembered by the connection
+func (c *boltConn) SetStatementCacheSize(size int) {
+	if size <= 0 {
+		c.stmtCache = nil
+		return
+	}
+	if c.stmtCache == nil {
+		c.stmtCache = newStmtCache(size)
+		return
+	}
+	c.stmtCache.maxSize = size
+}
+
+// StatementCacheStats gets the hit rate of the statement cache
+func (c *boltConn) StatementCacheStats() StatementCacheStats {
+	if c.stmtCache == nil {
+		return StatementCacheStats{}
+	}
+	return c.stmtCache.stats()
+}
+
+// SetDefaultParams sets parameters that are merged into the parameters of every query on the connection
+func (c *boltConn) SetDefaultParams(params map[string]interface{}) {
+	c.defaultParams = params
+}
+
+// SetAsyncClose makes closing unread rows discard the rest of the stream in the background
+func (c *boltConn) SetAsyncClose(async bool) {
+	c.asyncClose = async
+}
+
+// SetLogger sets the logger for the connection
+func (c *boltConn) SetLogger(logger *log.Logger) {
+	c.logger = logger
+}
+
+// SetRawStructures makes the connection return unrecognized structures as structures.Raw
+func (c *boltConn) SetRawStructures(raw bool) {
+	c.rawStructs = raw
+	if c.decoder != nil {
+		c.decoder.SetRawStructures(raw)
+	}
+}
+
+// SetLazyMetadata makes the connection decode large SUCCESS metadata values on first use
+func (c *boltConn) SetLazyMetadata(lazy bool) {
+	c.lazyMeta = lazy
+	if c.decoder != nil {
+		c.decoder.SetLazyMetadata(c.lazyMetadataKeys()...)
+	}
+}
+
+// SetMissingFields sets what rows do when Neo4j doesn't return the names of their columns
+func (c *boltConn) SetMissingFields(missing MissingFields) {
+	c.missingFields = missing
+}
+
+// checkFields fails a query with a *NoFieldsError, closing its rows, when
+// Neo4j didn't return the names of its columns and the connection is set
+// to MissingFieldsError
+func (c *boltConn) checkFields(query string, rows *boltRows) error {
+	if c.missingFields != MissingFieldsError {
+		return nil
+	}
+	if _, ok := metadataFields(rows.metadata); ok {
+		return nil
+	}
+	if err := rows.Close(); err != nil {
+		c.logger.Errorf("An error occurred closing rows with no fields: %s", err)
+	}
+	return &NoFieldsError{Query: query}
+}
+
+// lazyMetadataKeys gets the SUCCESS metadata keys the decoder keeps encoded
+func (c *boltConn) lazyMetadataKeys() []string {
+	if !c.lazyMeta {
+		return nil
+	}
+	return encoding.DefaultLazyMetadata
+}
+
+// awaitDrain waits for rows closed in the background to finish
+// discarding their stream, so the connection can be used again
+func (c *boltConn) awaitDrain() error {
+	if c.draining == nil {
+		return nil
+	}
+
+	err := <-c.draining
+	c.draining = nil
+	if err != nil {
+		c.markDefunct(errors.Wrap(err, "An error occurred closing rows in the background"))
+		return c.connErr
+	}
+	return nil
+}
+
+// withDefaults merges the default parameters of the connection into the query parameters
+func (c *boltConn) withDefaults(params map[string]interface{}) map[string]interface{} {
+	if len(c.defaultParams) == 0 {
+		return params
+	}
+
+	merged := make(map[string]interface{}, len(c.defaultParams)+len(params))
+	for k, v := range c.defaultParams {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+	return merged
+}
+
+// SetPipelineWindow sets the maximum number of pipelined queries sent before their results are read
+func (c *boltConn) SetPipelineWindow(window int) {
+	c.pipeWindow = window
+}
+
+// SetLegacyTxFailures keeps a transaction usable after a statement in it fails
+func (c *boltConn) SetLegacyTxFailures(legacy bool) {
+	c.legacyTxFail = legacy
+}
+
+// SetMaxExecutionTime sets how long a query may run before it's interrupted
+func (c *boltConn) SetMaxExecutionTime(max time.Duration) {
+	c.maxExecTime = max
+}
+
+// queryFingerprint identifies a query without its text, which may be sensitive
+func queryFingerprint(query string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(strings.Join(strings.Fields(query), " ")))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// SetErrorQueryLength sets how much of the query text is included in errors
+func (c *boltConn) SetErrorQueryLength(length int) {
+	c.errQueryLen = length
+}
+
+// queryError adds the query text and parameter keys to an error from running a
+// query.  Parameter values are left out, as they may be sensitive.  Only errors
+// from this package are wrapped, so that errors like driver.ErrBadConn can
+// still be compared against.
+func (c *boltConn) queryError(err error, query string, params map[string]interface{}) error {
+	e, ok := err.(*errors.Error)
+	if !ok {
+		return err
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if c.errQueryLen == 0 {
+		return errors.Wrap(e, "Error running query with parameter keys: %v", keys)
+	}
+
+	if runes := []rune(query); c.errQueryLen > 0 && len(runes) > c.errQueryLen {
+		query = string(runes[:c.errQueryLen]) + "..."
+	}
+	return errors.Wrap(e, "Error running query:\n\n%s\n\nWith parameter keys: %v", query, keys)
+}
+
+// encode encodes a message to the stream, with the encoder owned by the
+// connection.  It's created on first use with the chunk size and limits
+// configured on the connection, and rebuilt when they change.
+func (c *boltConn) encode(message interface{}) error {
+	if c.connErr != nil {
+		return c.defunctError("send a message")
+	}
+	if c.encoder == nil {
+		chunkSize := c.chunkSize
+		if c.adaptiveChunk {
+			chunkSize = math.MaxUint16
+		}
+		c.encoder = encoding.NewEncoder(c, chunkSize)
+		c.encoder.SetMaxDepth(c.maxDepth)
+		c.encoder.SetMaxSize(c.maxSize)
+		// Recordings should be byte for byte the same when re-recorded
+		c.encoder.SetSortedMaps(c.driver != nil && c.driver.recorder != nil)
+	}
+	if c.adaptiveChunk {
+		c.encoder.SetWriteBatch(c.writeBatch())
+	}
+	if err := c.encoder.Encode(message); err != nil {
+		if c.encoder.Partial() {
+			// The server has part of a message, so nothing else can be sent
+			c.markDefunct(errors.Wrap(err, "An error occurred after part of a message was sent"))
+		}
+		return err
+	}
+	// Every request gets a summary in response, in the order they're sent
+	c.inFlight = append(c.inFlight, nil)
+	return nil
+}
+
+// decode decodes the next message from the stream.  It runs on the receive
+// loop's goroutine when a watchdog is set, so it doesn't change the state of
+// the connection: the error that broke the connection, if any, is returned
+// for the connection's goroutine to record.
+func (c *boltConn) decode() received {
+	if c.decoder == nil {
+		c.stream = &streamReader{conn: c}
+		c.decoder = encoding.NewDecoder(c.stream)
+		c.decoder.SetRawStructures(c.rawStructs)
+		c.decoder.SetLazyMetadata(c.lazyMetadataKeys()...)
+	}
+	c.stream.awaiting = true
+	c.stream.defunct = nil
+
+	var msg interface{}
+	err := safely("decoding a message", func() (err error) {
+		msg, err = c.decoder.Decode()
+		return err
+	})
+	defunct := c.stream.defunct
+	if panicErr, ok := err.(*PanicError); ok {
+		// The rest of the message is still on the stream
+		defunct = panicErr
+		c.decoder = nil
+	}
+	return received{msg: msg, err: err, defunct: defunct}
+}
+
+// streamReader reads from the connection for its decoder, keeping the error
+// that broke the connection for decode to return
+type streamReader struct {
+	conn     *boltConn
+	awaiting bool
+	defunct  error
+}
+
+// Read reads the data from the underlying connection
+func (r *streamReader) Read(b []byte) (int, error) {
+	awaiting := r.awaiting
+	r.awaiting = false
+	return r.conn.read(b, awaiting, r.broken)
+}
+
+// broken keeps the first error that broke the connection
+func (r *streamReader) broken(err error) {
+	if r.defunct == nil {
+		r.defunct = err
+	}
+}
+
 func (c *boltConn) consume() (interface{}, error) {
-	log.Info("Consuming response from bolt stream")
+	c.logger.Info("Consuming response from bolt stream")
 
-	respInt, err := encoding.NewDecoder(c).Decode()
+	respInt, err := c.receive()
 	if err != nil {
 		return respInt, err
 	}
 
-	if log.GetLevel() >= log.TraceLevel {
-		log.Tracef("Consumed Response: %#v", respInt)
+	if c.logger.GetLevel() >= log.TraceLevel {
+		c.logger.Tracef("Consumed Response: %#v", respInt)
 	}
 
 	if failure, isFail := respInt.(messages.FailureMessage); isFail {
-		log.Errorf("Got failure message: %#v", failure)
+		c.logger.Errorf("Got failure message: %#v", failure)
+		// The connection stays failed if the failure can't be acknowledged
+		c.failed = true
 		err := c.ackFailure(failure)
 		if err != nil {
 			return nil, err
 		}
+		c.failed = false
+		if c.transaction != nil && !c.legacyTxFail {
+			c.transaction.failure = &failure
+		}
 		return failure, errors.Wrap(failure, "Neo4J reported a failure for the query")
 	}
 
@@ -573,7 +1433,7 @@ func (c *boltConn) consume() (interface{}, error) {
 }
 
 func (c *boltConn) consumeAll() ([]interface{}, interface{}, error) {
-	log.Info("Consuming all responses until success/failure")
+	c.logger.Info("Consuming all responses until success/failure")
 
 	responses := []interface{}{}
 	for {
@@ -583,7 +1443,7 @@ func (c *boltConn) consumeAll() ([]interface{}, interface{}, error) {
 		}
 
 		if success, isSuccess := respInt.(messages.SuccessMessage); isSuccess {
-			log.Infof("Got success message: %#v", success)
+			c.logger.Infof("Got success message: %#v", success)
 			return responses, success, nil
 		}
 
@@ -592,7 +1452,7 @@ func (c *boltConn) consumeAll() ([]interface{}, interface{}, error) {
 }
 
 func (c *boltConn) consumeAllMultiple(mult int) ([][]interface{}, []interface{}, error) {
-	log.Infof("Consuming all responses %d times until success/failure", mult)
+	c.logger.Infof("Consuming all responses %d times until success/failure", mult)
 
 	responses := make([][]interface{}, mult)
 	successes := make([]interface{}, mult)
@@ -610,11 +1470,54 @@ func (c *boltConn) consumeAllMultiple(mult int) ([][]interface{}, []interface{},
 	return responses, successes, nil
 }
 
+// userAgent gets the client name sent to the server, which includes the
+// application name, if set, so the server can tell applications apart
+func (c *boltConn) userAgent() string {
+	if c.appName == "" {
+		return ClientID
+	}
+	return ClientID + " (" + c.appName + ")"
+}
+
+// tagQuery prefixes a query with a comment naming the application, so the
+// server's query listings and logs show where it came from.  Transaction
+// control statements are sent as is.  Only used before Bolt v3, which names
+// the application in the transaction metadata instead.  See txMetadata.
+func (c *boltConn) tagQuery(query string) string {
+	if c.appName == "" {
+		return query
+	}
+	switch query {
+	case "BEGIN", "COMMIT", "ROLLBACK":
+		return query
+	}
+	return "/* " + strings.Replace(c.appName, "*/", "", -1) + " */ " + query
+}
+
+// txMetadata gets the transaction metadata naming the application, sent on
+// BEGIN and auto-commit RUN messages from Bolt v3 on
+func (c *boltConn) txMetadata() map[string]interface{} {
+	if c.appName == "" {
+		return nil
+	}
+	return map[string]interface{}{"application_name": c.appName}
+}
+
 func (c *boltConn) sendInit() (interface{}, error) {
-	log.Infof("Sending INIT Message. ClientID: %s User: %s", ClientID, c.user)
+	userAgent := c.userAgent()
+	if c.protocolVersion() >= 3 {
+		// HELLO replaces INIT from Bolt v3 on
+		c.logger.Infof("Sending HELLO Message. ClientID: %s User: %s", userAgent, c.user)
+		if err := c.encode(messages.NewHelloMessage(userAgent, c.user, c.password)); err != nil {
+			return nil, errors.Wrap(err, "An error occurred sending hello message")
+		}
+		return c.consume()
+	}
+
+	c.logger.Infof("Sending INIT Message. ClientID: %s User: %s", userAgent, c.user)
 
-	initMessage := messages.NewInitMessage(ClientID, c.user, c.password)
-	if err := encoding.NewEncoder(c, c.chunkSize).Encode(initMessage); err != nil {
+	initMessage := messages.NewInitMessage(userAgent, c.user, c.password)
+	if err := c.encode(initMessage); err != nil {
 		return nil, errors.Wrap(err, "An error occurred sending init message")
 	}
 
@@ -622,12 +1525,42 @@ func (c *boltConn) sendInit() (interface{}, error) {
 }
 
 func (c *boltConn) sendRun(query string, args map[string]interface{}) error {
-	log.Infof("Sending RUN message: query %s (args: %#v)", query, args)
-	runMessage := messages.NewRunMessage(query, args)
-	if err := encoding.NewEncoder(c, c.chunkSize).Encode(runMessage); err != nil {
+	if err := c.awaitDrain(); err != nil {
+		return err
+	}
+
+	prepared := c.prepareQuery(query)
+	if err := c.checkQuery(prepared, args); err != nil {
+		return err
+	}
+	c.trackUse()
+
+	c.logger.Infof("Sending RUN message: query %s (args: %#v)", query, args)
+	if c.validateProps {
+		if err := ValidateProperties(args); err != nil {
+			return errors.Wrap(err, "Query parameters failed property validation")
+		}
+	}
+	var runMessage messages.RunMessage
+	if c.protocolVersion() >= 3 {
+		metadata := map[string]interface{}{}
+		// Queries in an explicit transaction take its metadata from BEGIN
+		if txMetadata := c.txMetadata(); txMetadata != nil && c.transaction == nil {
+			metadata["tx_metadata"] = txMetadata
+		}
+		runMessage = messages.NewRunMessageWithMetadata(query, args, metadata)
+	} else {
+		runMessage = messages.NewRunMessage(prepared.taggedQuery(c), args)
+	}
+	if err := c.encode(runMessage); err != nil {
 		return errors.Wrap(err, "An error occurred running query")
 	}
 
+	if c.maxExecTime > 0 && c.execStart.IsZero() {
+		c.execStart = time.Now()
+		c.execQuery = query
+	}
+	c.startMetrics(prepared, args)
 	return nil
 }
 
@@ -640,13 +1573,14 @@ func (c *boltConn) sendRunConsume(query string, args map[string]interface{}) (in
 }
 
 func (c *boltConn) sendPullAll() error {
-	log.Infof("Sending PULL_ALL message")
+	c.logger.Infof("Sending PULL_ALL message")
 
 	pullAllMessage := messages.NewPullAllMessage()
-	err := encoding.NewEncoder(c, c.chunkSize).Encode(pullAllMessage)
+	err := c.encode(pullAllMessage)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred encoding pull all query")
 	}
+	c.attachMetrics()
 
 	return nil
 }
@@ -692,29 +1626,59 @@ func (c *boltConn) sendRunPullAllConsumeSingle(query string, args map[string]int
 	return runSuccess, pullSuccess, err
 }
 
-func (c *boltConn) sendRunPullAllConsumeAll(query string, args map[string]interface{}) (interface{}, interface{}, []interface{}, error) {
-	err := c.sendRunPullAll(query, args)
-	if err != nil {
-		return nil, nil, nil, err
+// sendTxControl begins, commits or rolls back a transaction.  Before Bolt v3,
+// that's running the statement, and the responses to RUN and PULL_ALL are
+// returned.  From v3 on, it's a message of its own with a single response,
+// which is returned for both.
+func (c *boltConn) sendTxControl(statement string, params map[string]interface{}) (interface{}, interface{}, error) {
+	if !c.Supports(FeatureTransactionMessages) {
+		return c.sendRunPullAllConsumeSingle(statement, params)
 	}
 
-	runSuccess, err := c.consume()
-	if err != nil {
-		return runSuccess, nil, nil, err
+	if err := c.awaitDrain(); err != nil {
+		return nil, nil, err
 	}
 
-	records, pullSuccess, err := c.consumeAll()
-	return runSuccess, pullSuccess, records, err
+	var message interface{}
+	switch statement {
+	case "BEGIN":
+		metadata := map[string]interface{}{}
+		if bookmarks, ok := params["bookmarks"]; ok {
+			metadata["bookmarks"] = bookmarks
+		}
+		if mode, ok := params["mode"]; ok {
+			metadata["mode"] = mode
+		}
+		if txMetadata := c.txMetadata(); txMetadata != nil {
+			metadata["tx_metadata"] = txMetadata
+		}
+		message = messages.NewBeginMessage(metadata)
+	case "COMMIT":
+		message = messages.NewCommitMessage()
+	case "ROLLBACK":
+		message = messages.NewRollbackMessage()
+	default:
+		return nil, nil, errors.New("Unrecognized transaction control statement: %s", statement)
+	}
+
+	c.logger.Infof("Sending %s message", statement)
+	if err := c.encode(message); err != nil {
+		return nil, nil, errors.Wrap(err, "An error occurred sending %s message", statement)
+	}
+
+	resp, err := c.consume()
+	return resp, resp, err
 }
 
 func (c *boltConn) sendDiscardAll() error {
-	log.Infof("Sending DISCARD_ALL message")
+	c.logger.Infof("Sending DISCARD_ALL message")
 
 	discardAllMessage := messages.NewDiscardAllMessage()
-	err := encoding.NewEncoder(c, c.chunkSize).Encode(discardAllMessage)
+	err := c.encode(discardAllMessage)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred encoding discard all query")
 	}
+	c.attachMetrics()
 
 	return nil
 }
@@ -724,7 +1688,23 @@ func (c *boltConn) sendDiscardAllConsume() (interface{}, error) {
 		return nil, err
 	}
 
-	return c.consume()
+	return c.consumeDiscard()
+}
+
+// consumeDiscard consumes the response to a DISCARD_ALL.  The server sends no
+// records after DISCARD_ALL, so a record means the stream is out of sync.
+func (c *boltConn) consumeDiscard() (interface{}, error) {
+	respInt, err := c.consume()
+	if err != nil {
+		return respInt, err
+	}
+
+	if _, isRecord := respInt.(messages.RecordMessage); isRecord {
+		err := errors.New("Got a record in response to DISCARD_ALL: %#v", respInt)
+		c.markDefunct(err)
+		return nil, err
+	}
+	return respInt, nil
 }
 
 func (c *boltConn) sendRunDiscardAll(query string, args map[string]interface{}) error {
@@ -736,6 +1716,21 @@ func (c *boltConn) sendRunDiscardAll(query string, args map[string]interface{})
 	return c.sendDiscardAll()
 }
 
+func (c *boltConn) sendRunDiscardAllConsumeAll(query string, args map[string]interface{}) (interface{}, interface{}, error) {
+	err := c.sendRunDiscardAll(query, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runSuccess, err := c.consume()
+	if err != nil {
+		return runSuccess, nil, err
+	}
+
+	discardSuccess, err := c.consumeDiscard()
+	return runSuccess, discardSuccess, err
+}
+
 func (c *boltConn) sendRunDiscardAllConsume(query string, args map[string]interface{}) (interface{}, interface{}, error) {
 	runResp, err := c.sendRunConsume(query, args)
 	if err != nil {
@@ -766,46 +1761,64 @@ func (c *boltConn) QueryNeoAll(query string, params map[string]interface{}) ([][
 	defer rows.Close()
 
 	data, metadata, err := rows.All()
+	if err != nil {
+		err = c.queryError(err, query, c.withDefaults(params))
+	}
 	return data, rows.metadata, metadata, err
 }
 
-func (c *boltConn) queryNeo(query string, params map[string]interface{}) (*boltRows, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
+func (c *boltConn) QueryNeoAllSummary(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	rows, err := c.queryNeo(query, params)
+	if err != nil {
+		return nil, nil, Summary{}, err
 	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	defer rows.Close()
+
+	data, metadata, err := rows.All()
+	if err != nil {
+		err = c.queryError(err, query, c.withDefaults(params))
+	}
+	return data, rows.Columns(), newSummary(rows.metadata, metadata), err
+}
+
+func (c *boltConn) queryNeo(query string, params map[string]interface{}) (*boltRows, error) {
+	if err := c.checkState("run a query", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 
 	c.statement = newStmt(query, c)
 
 	// Pipeline the run + pull all for this
+	params = c.withDefaults(params)
 	successResp, err := c.sendRunPullAllConsumeRun(c.statement.query, params)
 	if err != nil {
 		c.statement.Close()
-		return nil, err
+		return nil, c.queryError(err, query, params)
 	}
 	success, ok := successResp.(messages.SuccessMessage)
 	if !ok {
 		c.statement.Close()
-		return nil, errors.New("Unexpected response querying neo from connection: %#v", successResp)
+		return nil, c.queryError(errors.New("Unexpected response querying neo from connection: %#v", successResp), query, params)
 	}
 
-	c.statement.rows = newQueryRows(c.statement, success.Metadata)
-	return c.statement.rows, nil
+	c.statement.learn(success.Metadata)
+	rows := newQueryRows(c.statement, success.Metadata)
+	c.statement.rows = rows
+	if err := c.checkFields(query, rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 
 func (c *boltConn) QueryPipeline(queries []string, params ...map[string]interface{}) (PipelineRows, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
-	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	if err := c.checkState("run a pipeline", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 
 	c.statement = newPipelineStmt(queries, c)
 	rows, err := c.statement.QueryPipeline(params...)
 	if err != nil {
+		c.statement.Close()
 		return nil, err
 	}
 
@@ -818,11 +1831,8 @@ func (c *boltConn) QueryPipeline(queries []string, params ...map[string]interfac
 // Exec executes a query that returns no rows. See sql/driver.Stmt.
 // You must bolt encode a map to pass as []bytes for the driver value
 func (c *boltConn) Exec(query string, args []driver.Value) (driver.Result, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
-	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	if err := c.checkState("run a query", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 
 	stmt := newStmt(query, c)
@@ -833,11 +1843,8 @@ func (c *boltConn) Exec(query string, args []driver.Value) (driver.Result, error
 
 // ExecNeo executes a query that returns no rows. Implements a Neo-friendly alternative to sql/driver.
 func (c *boltConn) ExecNeo(query string, params map[string]interface{}) (Result, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
-	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	if err := c.checkState("run a query", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 
 	stmt := newStmt(query, c)
@@ -847,11 +1854,8 @@ func (c *boltConn) ExecNeo(query string, params map[string]interface{}) (Result,
 }
 
 func (c *boltConn) ExecPipeline(queries []string, params ...map[string]interface{}) ([]Result, error) {
-	if c.statement != nil {
-		return nil, errors.New("An open statement already exists")
-	}
-	if c.closed {
-		return nil, errors.New("Connection already closed")
+	if err := c.checkState("run a pipeline", stateReady, stateTxReady); err != nil {
+		return nil, err
 	}
 
 	stmt := newPipelineStmt(queries, c)
@@ -0,0 +1,109 @@
+package golangNeo4jBoltDriver
+
+import (
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// connState is the state of a connection, derived from the statement,
+// transaction and error the connection holds
+type connState int
+
+const (
+	// stateReady means the connection can run queries and begin transactions
+	stateReady connState = iota
+	// stateStreaming means a statement is open, and may be streaming rows
+	stateStreaming
+	// stateTxReady means a transaction is open and can run queries
+	stateTxReady
+	// stateTxFailed means a statement in the open transaction failed,
+	// so the transaction can only be rolled back
+	stateTxFailed
+	// stateFailed means the server reported a failure that couldn't be acknowledged
+	stateFailed
+	// stateDefunct means the connection is closed or broken
+	stateDefunct
+)
+
+func (s connState) String() string {
+	switch s {
+	case stateReady:
+		return "Ready"
+	case stateStreaming:
+		return "Streaming"
+	case stateTxReady:
+		return "TxReady"
+	case stateTxFailed:
+		return "TxFailed"
+	case stateFailed:
+		return "Failed"
+	case stateDefunct:
+		return "Defunct"
+	default:
+		return "Unknown"
+	}
+}
+
+// state gets the current state of the connection
+func (c *boltConn) state() connState {
+	switch {
+	case c.closed || c.connErr != nil:
+		return stateDefunct
+	case c.failed:
+		return stateFailed
+	case c.statement != nil:
+		return stateStreaming
+	case c.transaction != nil && c.transaction.failure != nil:
+		return stateTxFailed
+	case c.transaction != nil:
+		return stateTxReady
+	default:
+		return stateReady
+	}
+}
+
+// checkState returns an error explaining why op can't be run,
+// if the connection isn't in one of the allowed states
+func (c *boltConn) checkState(op string, allowed ...connState) error {
+	state := c.state()
+	for _, s := range allowed {
+		if s == state {
+			return nil
+		}
+	}
+
+	switch state {
+	case stateStreaming:
+		return errors.New("Can't %s: an open statement already exists. Close it, or its rows, first", op)
+	case stateTxReady:
+		return errors.New("Can't %s: an open transaction already exists", op)
+	case stateTxFailed:
+		return &TxFailedError{Failure: *c.transaction.failure}
+	case stateFailed:
+		return errors.New("Can't %s: the server reported a failure that couldn't be acknowledged", op)
+	case stateDefunct:
+		if c.connErr != nil {
+			return c.defunctError(op)
+		}
+		return errors.New("Can't %s: connection already closed", op)
+	default:
+		return errors.New("Can't %s: the connection is %s", op, state)
+	}
+}
+
+// markDefunct records the error that broke the connection.  Only the first
+// error is kept, as the errors after it are usually caused by it.
+func (c *boltConn) markDefunct(err error) {
+	if c.connErr != nil {
+		return
+	}
+	c.connErr = err
+	c.defunctAt = time.Now()
+}
+
+// defunctError explains that op can't be run because the connection broke,
+// with the error that broke it, so the cause isn't lost in later errors
+func (c *boltConn) defunctError(op string) error {
+	return errors.Wrap(c.connErr, "Can't %s: the connection is defunct since %s", op, c.defunctAt.Format(time.RFC3339Nano))
+}
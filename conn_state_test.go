@@ -0,0 +1,77 @@
+package golangNeo4jBoltDriver
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+func TestBoltConn_State(t *testing.T) {
+	c := createBoltConn("")
+	if c.state() != stateReady {
+		t.Fatalf("Expected a new connection to be ready. Got: %s", c.state())
+	}
+
+	c.transaction = &boltTx{conn: c}
+	if c.state() != stateTxReady {
+		t.Fatalf("Expected an open transaction to be TxReady. Got: %s", c.state())
+	}
+	if _, err := c.Begin(); err == nil || !strings.Contains(err.Error(), "open transaction") {
+		t.Fatalf("Expected an error beginning a second transaction. Got: %v", err)
+	}
+
+	if _, err := c.PrepareNeo("RETURN 1"); err != nil {
+		t.Fatalf("Unexpected error preparing a statement in a transaction: %s", err)
+	}
+	if c.state() != stateStreaming {
+		t.Fatalf("Expected an open statement to be Streaming. Got: %s", c.state())
+	}
+	if _, err := c.ExecNeo("RETURN 1", nil); err == nil || !strings.Contains(err.Error(), "open statement") {
+		t.Fatalf("Expected an error running a query with an open statement. Got: %v", err)
+	}
+
+	c.statement = nil
+	c.failed = true
+	if _, err := c.QueryNeo("RETURN 1", nil); err == nil || !strings.Contains(err.Error(), "failure") {
+		t.Fatalf("Expected an error running a query on a failed connection. Got: %v", err)
+	}
+
+	c.closed = true
+	if _, err := c.PrepareNeo("RETURN 1"); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Fatalf("Expected an error preparing a statement on a closed connection. Got: %v", err)
+	}
+}
+
+func TestBoltConn_Defunct(t *testing.T) {
+	c := createBoltConn("")
+	client, _ := net.Pipe()
+	c.conn = closedConn{client}
+	c.statement = newStmt("RETURN 1", c)
+	rows := newRows(c.statement, nil)
+	rows.consumed = true
+
+	// The first read finds the server closed the connection
+	if _, _, err := rows.NextNeo(); err == nil {
+		t.Fatalf("Expected an error reading from a closed connection")
+	}
+	c.markDefunct(errors.New("a later error"))
+	if _, ok := c.connErr.(*ServerClosedError); !ok {
+		t.Fatalf("Expected the first error to be kept. Got: %#v", c.connErr)
+	}
+
+	// Everything after fails fast with the root cause
+	_, _, err := rows.NextNeo()
+	if err == nil || !strings.Contains(err.Error(), "defunct since") {
+		t.Fatalf("Expected a defunct connection error. Got: %v", err)
+	}
+	if _, ok := err.(*errors.Error).InnerMost().(*ServerClosedError); !ok {
+		t.Fatalf("Expected the root cause to be wrapped. Got: %#v", err.(*errors.Error).InnerMost())
+	}
+
+	// Closing doesn't try to clean up over the broken connection
+	if err := c.Close(); err != nil {
+		t.Fatalf("Expected a defunct connection to close. Got: %s", err)
+	}
+}
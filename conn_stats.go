@@ -0,0 +1,141 @@
+package golangNeo4jBoltDriver
+
+import (
+	"runtime/debug"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats describes the use of a connection, to help track down leaks
+type ConnStats struct {
+	// Created is when the connection to the server was opened
+	Created time.Time
+	// LastUsed is when the last query was sent
+	LastUsed time.Time
+	// Queries is the number of queries sent, including BEGIN, COMMIT and ROLLBACK
+	Queries int64
+}
+
+// Age gets how long the connection has been open
+func (s ConnStats) Age() time.Duration {
+	if s.Created.IsZero() {
+		return 0
+	}
+	return time.Since(s.Created)
+}
+
+// BorrowedConn describes a connection borrowed from a pool, and not yet closed
+type BorrowedConn struct {
+	// Partition is the name of the pool partition the connection is from,
+	// or empty for the pool itself
+	Partition string
+	// Borrowed is when the connection was borrowed, and Out how long ago that was
+	Borrowed time.Time
+	Out      time.Duration
+	Stats    ConnStats
+	// Stack is the stack trace of the borrower, when leak detection is on
+	Stack []byte
+}
+
+// borrowing is a connection borrowed from a pool
+type borrowing struct {
+	at    time.Time
+	stack []byte
+	leak  *time.Timer
+}
+
+// Stats gets the age and usage of the connection.  The usage is updated
+// atomically, so the pool can report on connections while they're in use.
+func (c *boltConn) Stats() ConnStats {
+	stats := ConnStats{
+		Created: c.created,
+		Queries: atomic.LoadInt64(&c.queries),
+	}
+	if lastUsed := atomic.LoadInt64(&c.lastUsed); lastUsed > 0 {
+		stats.LastUsed = time.Unix(0, lastUsed)
+	}
+	return stats
+}
+
+// trackUse records a query being sent on the connection
+func (c *boltConn) trackUse() {
+	atomic.StoreInt64(&c.lastUsed, time.Now().UnixNano())
+	atomic.AddInt64(&c.queries, 1)
+}
+
+// Borrowed lists the connections borrowed from the pool, and its
+// partitions, that haven't been closed yet, longest out first
+func (d *boltDriverPool) Borrowed() []BorrowedConn {
+	borrowed := d.borrowedConns("")
+
+	d.partLock.Lock()
+	for name, partition := range d.partitions {
+		borrowed = append(borrowed, partition.borrowedConns(name)...)
+	}
+	d.partLock.Unlock()
+
+	now := time.Now()
+	for i := range borrowed {
+		borrowed[i].Out = now.Sub(borrowed[i].Borrowed)
+	}
+	sort.Slice(borrowed, func(i, j int) bool { return borrowed[i].Borrowed.Before(borrowed[j].Borrowed) })
+	return borrowed
+}
+
+// borrowedConns lists the connections borrowed from this pool
+func (d *boltDriverPool) borrowedConns(partition string) []BorrowedConn {
+	d.borrowLock.Lock()
+	defer d.borrowLock.Unlock()
+
+	borrowed := make([]BorrowedConn, 0, len(d.borrowed))
+	for conn, b := range d.borrowed {
+		borrowed = append(borrowed, BorrowedConn{
+			Partition: partition,
+			Borrowed:  b.at,
+			Stats:     conn.Stats(),
+			Stack:     b.stack,
+		})
+	}
+	return borrowed
+}
+
+// borrow records a connection being borrowed from the pool.  With leak
+// detection on, the borrower's stack is logged if it's out for too long.
+func (d *boltDriverPool) borrow(conn *boltConn) {
+	b := &borrowing{at: time.Now()}
+	d.confLock.Lock()
+	logger, after := d.logger, d.leakAfter
+	d.confLock.Unlock()
+	if after > 0 {
+		b.stack = debug.Stack()
+		b.leak = time.AfterFunc(after, func() {
+			logger.Errorf("Connection borrowed from the pool at %s hasn't been closed after %s, and may have leaked. Borrowed by:\n\n%s", b.at.Format(time.RFC3339), after, b.stack)
+		})
+	}
+
+	d.borrowLock.Lock()
+	defer d.borrowLock.Unlock()
+	if d.borrowed == nil {
+		d.borrowed = map[*boltConn]*borrowing{}
+	}
+	d.borrowed[conn] = b
+}
+
+// giveBack records a borrowed connection being returned to the pool
+func (d *boltDriverPool) giveBack(conn *boltConn) {
+	d.borrowLock.Lock()
+	defer d.borrowLock.Unlock()
+	if b, ok := d.borrowed[conn]; ok && b.leak != nil {
+		b.leak.Stop()
+	}
+	delete(d.borrowed, conn)
+}
+
+// SetLeakDetection logs the stack trace of whoever borrowed a connection,
+// once, if it isn't closed within the given time
+func (d *boltDriverPool) SetLeakDetection(after time.Duration) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.leakAfter = after
+}
@@ -1,11 +1,21 @@
 package golangNeo4jBoltDriver
 
 import (
+	"bytes"
+	"database/sql/driver"
 	"io"
+	"io/ioutil"
+	"math"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
 )
 
@@ -64,6 +74,51 @@ func TestBoltConn_parseURL(t *testing.T) {
 	if c.keyFile != "key" {
 		t.Fatal("Expected key file 'key'")
 	}
+	c = &boltConn{connStr: "bolt://foo:7687?graph_encoding=JSON"}
+	if _, err = c.parseURL(); err != nil || c.graphEncoding != "json" {
+		t.Fatalf("Expected graph encoding 'json'. Got: %s %v", c.graphEncoding, err)
+	}
+
+	c = &boltConn{connStr: "bolt://foo:7687?graph_encoding=xml"}
+	if _, err = c.parseURL(); err == nil {
+		t.Fatal("Expected error from unsupported graph encoding")
+	}
+
+	c = &boltConn{connStr: "bolt://foo:7687?chunk_size=1024&application_name=billing"}
+	if _, err = c.parseURL(); err != nil {
+		t.Fatalf("Should not error on valid url: %s", err)
+	}
+	if c.chunkSize != 1024 {
+		t.Fatalf("Expected chunk size 1024. Got: %d", c.chunkSize)
+	}
+	if c.userAgent() != ClientID+" (billing)" {
+		t.Fatalf("Expected application name in user agent. Got: %s", c.userAgent())
+	}
+
+	for _, param := range []string{"fetch_size=100", "database=neo4j"} {
+		c = &boltConn{connStr: "bolt://foo:7687?" + param}
+		if _, err = c.parseURL(); err == nil {
+			t.Fatalf("Expected error from unsupported %s", param)
+		}
+	}
+
+	c = &boltConn{connStr: "bolt://foo:7687?chunk_size=70000"}
+	if _, err = c.parseURL(); err == nil {
+		t.Fatal("Expected error from chunk size too large")
+	}
+
+	c = &boltConn{connStr: "bolt://foo:7687?max_execution_time=30"}
+	if _, err = c.parseURL(); err != nil || c.maxExecTime != 30*time.Second {
+		t.Fatalf("Expected max execution time of 30s. Got: %s %v", c.maxExecTime, err)
+	}
+
+	c = &boltConn{connStr: "bolt://foo:7687?chunk_size=auto"}
+	if _, err = c.parseURL(); err != nil {
+		t.Fatalf("Should not error on valid url: %s", err)
+	}
+	if !c.adaptiveChunk {
+		t.Fatal("Expected adaptive chunking from chunk_size=auto")
+	}
 }
 
 func TestBoltConn_Close(t *testing.T) {
@@ -285,6 +340,9 @@ func TestBoltConn_FailureMessageError(t *testing.T) {
 	if err.(*errors.Error).InnerMost().(messages.FailureMessage).Metadata["code"] != code {
 		t.Fatalf("Expected error message code %s, but got %v", code, err.(*errors.Error).InnerMost().(messages.FailureMessage).Metadata["code"])
 	}
+	if !strings.Contains(err.Error(), "THIS IS A BAD QUERY") {
+		t.Fatalf("Expected the query text in the error. Got: %s", err)
+	}
 }
 
 func TestBoltConn_CloseStatementOnError(t *testing.T) {
@@ -309,3 +367,677 @@ func TestBoltConn_CloseStatementOnError(t *testing.T) {
 		t.Fatalf("Got error when running next query after a failure: %#v", err)
 	}
 }
+
+func TestBoltConn_ProtocolVersion(t *testing.T) {
+	c := createBoltConn("")
+	copy(c.serverVersion, []byte{0x00, 0x00, 0x00, 0x01})
+	if c.protocolVersion() != 1 {
+		t.Fatalf("Unexpected protocol version: %d", c.protocolVersion())
+	}
+
+	copy(c.serverVersion, []byte{0x00, 0x00, 0x00, 0x03})
+	if c.protocolVersion() != 3 {
+		t.Fatalf("Unexpected protocol version: %d", c.protocolVersion())
+	}
+}
+
+func TestBoltConn_VersionPinning(t *testing.T) {
+	c := &boltConn{connStr: "bolt://localhost:7687?bolt_version=1&compat_mode=true"}
+	if _, err := c.parseURL(); err != nil {
+		t.Fatalf("Unexpected error parsing URL: %s", err)
+	}
+	if c.boltVersion != 1 || !c.compatMode {
+		t.Fatalf("Expected version pinning and compatibility mode. Got version %d compat %t", c.boltVersion, c.compatMode)
+	}
+
+	c.serverVersion = []byte{0x00, 0x00, 0x00, 0x03}
+	if c.featureVersion() != 1 {
+		t.Fatalf("Expected compatibility mode to disable newer features. Got feature version: %d", c.featureVersion())
+	}
+
+	c = &boltConn{connStr: "bolt://localhost:7687?bolt_version=9"}
+	if _, err := c.parseURL(); err == nil {
+		t.Fatal("Expected error pinning an unsupported version")
+	}
+}
+
+type closedConn struct {
+	net.Conn
+}
+
+func (c closedConn) Read(b []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (c closedConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func TestBoltConn_ServerClosed(t *testing.T) {
+	c := createBoltConn("")
+	c.conn = closedConn{}
+	c.serverMeta = map[string]interface{}{"server": "Neo4j/3.1.0"}
+
+	_, err := c.Read(make([]byte, 2))
+	closedErr, ok := err.(*ServerClosedError)
+	if !ok {
+		t.Fatalf("Expected server closed error. Got: %#v", err)
+	}
+	if closedErr.Metadata["server"] != "Neo4j/3.1.0" {
+		t.Fatalf("Expected server metadata on error. Got: %#v", closedErr.Metadata)
+	}
+	if c.connErr != err {
+		t.Fatalf("Expected connection error to be set. Got: %#v", c.connErr)
+	}
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+type timeoutConn struct {
+	net.Conn
+	timeouts int
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if c.timeouts > 0 {
+		c.timeouts--
+		return 0, timeoutErr{}
+	}
+	return len(b), nil
+}
+
+func (c *timeoutConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func TestBoltConn_ReadRetries(t *testing.T) {
+	c := createBoltConn("")
+	c.conn = &timeoutConn{timeouts: 1}
+	c.readRetries = 1
+	stream := &streamReader{conn: c, awaiting: true}
+
+	if _, err := stream.Read(make([]byte, 2)); err != nil {
+		t.Fatalf("Expected read to be retried after timeout. Got: %s", err)
+	}
+
+	c.conn = &timeoutConn{timeouts: 1}
+	if _, err := stream.Read(make([]byte, 2)); err == nil {
+		t.Fatal("Expected timeout in the middle of a message not to be retried")
+	}
+	if stream.defunct == nil {
+		t.Fatal("Expected timeout to break the connection")
+	}
+	if c.connErr != nil {
+		t.Fatal("Expected the stream not to set the connection error itself")
+	}
+}
+
+func TestBoltConn_Options(t *testing.T) {
+	c := createBoltConn("")
+	c.encoder = &encoding.Encoder{}
+
+	// Setting the options unchanged leaves the connection as it is
+	c.SetOptions(c.Options())
+	if c.encoder == nil {
+		t.Fatal("Expected unchanged options to keep the encoder")
+	}
+	if c.errQueryLen != defaultErrQueryLen || c.pipeWindow != defaultPipeWindow || c.maxIgnored != defaultMaxIgnored {
+		t.Fatalf("Expected the defaults to be kept. Got: %#v", c.Options())
+	}
+
+	conn := NewSafeConn(c)
+	opts := conn.Options()
+	opts.ReadOnly = true
+	opts.MaxSize = 1024
+	opts.StatementCacheSize = 10
+	conn.SetOptions(opts)
+	if !c.readOnly || c.maxSize != 1024 || c.stmtCache == nil || c.encoder != nil {
+		t.Fatalf("Expected the options to be applied. Got: %#v", c.Options())
+	}
+	if !reflect.DeepEqual(conn.Options(), opts) {
+		t.Fatalf("Unexpected options. Expected %#v. Got: %#v", opts, conn.Options())
+	}
+}
+
+func TestBoltConn_DefaultParams(t *testing.T) {
+	c := createBoltConn("")
+	if params := c.withDefaults(nil); params != nil {
+		t.Fatalf("Expected params to be unchanged without defaults. Got: %#v", params)
+	}
+
+	c.SetDefaultParams(map[string]interface{}{"tenantId": 1, "foo": "bar"})
+	params := c.withDefaults(map[string]interface{}{"foo": "baz"})
+	expected := map[string]interface{}{"tenantId": 1, "foo": "baz"}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("Unexpected merged params. Expected %#v. Got: %#v", expected, params)
+	}
+}
+
+func TestBoltConn_QueryNeoAllSummary(t *testing.T) {
+	driver := NewDriver()
+
+	// Runs the same session as TestBoltConn_SelectAll using the typed summary
+	driver.(*boltDriver).recorder = newRecorder("TestBoltConn_SelectAll", neo4jConnStr)
+
+	conn, err := driver.OpenNeo(neo4jConnStr)
+	if err != nil {
+		t.Fatalf("An error occurred opening conn: %s", err)
+	}
+
+	if _, err := conn.ExecNeo("CREATE (f:NODE {a: 1}), (b:NODE {a: 2})", nil); err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+
+	data, fields, summary, err := conn.QueryNeoAllSummary("MATCH (n:NODE) RETURN n.a ORDER BY n.a", nil)
+	if err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+	if len(data) != 2 || data[0][0] != int64(1) || data[1][0] != int64(2) {
+		t.Fatalf("Incorrect data returned: %#v", data)
+	}
+	if !reflect.DeepEqual(fields, []string{"n.a"}) {
+		t.Fatalf("Unexpected fields: %#v", fields)
+	}
+	if summary.Type != "r" {
+		t.Fatalf("Unexpected summary: %#v", summary)
+	}
+
+	if _, err := conn.ExecNeo("MATCH (n:NODE) DELETE n", nil); err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatalf("Error closing connection: %s", err)
+	}
+}
+
+func TestBoltConn_CloseGoodbye(t *testing.T) {
+	for _, version := range []byte{1, 3} {
+		client, server := net.Pipe()
+		c := createBoltConn("")
+		c.conn = client
+		c.serverVersion = []byte{0x00, 0x00, 0x00, version}
+
+		received := make(chan []byte, 1)
+		go func() {
+			b, _ := ioutil.ReadAll(server)
+			received <- b
+		}()
+
+		if err := c.Close(); err != nil {
+			t.Fatalf("Error closing connection: %s", err)
+		}
+
+		// GOODBYE is a struct marker with no fields and signature 0x02, in a single chunk
+		expected := []byte{}
+		if version >= 3 {
+			expected = []byte{0x00, 0x02, 0xB0, messages.GoodbyeMessageSignature, 0x00, 0x00}
+		}
+		if b := <-received; !bytes.Equal(b, expected) {
+			t.Fatalf("Unexpected bytes sent on close for version %d. Expected %#v. Got: %#v", version, expected, b)
+		}
+	}
+}
+
+func TestBoltConn_HandShakeErrors(t *testing.T) {
+	tests := []struct {
+		response []byte
+		expected string
+	}{
+		{[]byte("HTTP/1.1 400 Bad Request"), "Server responded with HTTP"},
+		{[]byte{0x00, 0x00, 0x00, 0x00}, "proposed Bolt versions: 3, 2, 1"},
+		{[]byte{0x00, 0x00, 0x04, 0x04}, "Bolt version 4.4, which wasn't proposed"},
+	}
+	for _, test := range tests {
+		client, server := net.Pipe()
+		c := createBoltConn("")
+		c.conn = client
+
+		go func(response []byte) {
+			server.Read(make([]byte, len(handShake)))
+			server.Write(response)
+			server.Close()
+		}(test.response)
+
+		err := c.handShake()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Fatalf("Expected handshake error containing %q. Got: %v", test.expected, err)
+		}
+		client.Close()
+	}
+}
+
+func TestBoltConn_IgnoredFlood(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.SetIgnoredLimit(5, 0)
+
+	go func() {
+		io.ReadFull(server, make([]byte, 6))
+		// The server responds to RESET with IGNORED forever
+		for {
+			if _, err := server.Write([]byte{0x00, 0x02, 0xB0, messages.IgnoredMessageSignature, 0x00, 0x00}); err != nil {
+				return
+			}
+		}
+	}()
+
+	err := c.reset()
+	flood, ok := err.(*IgnoredFloodError)
+	if !ok {
+		t.Fatalf("Expected IgnoredFloodError. Got: %#v", err)
+	}
+	if flood.Drained != 6 {
+		t.Fatalf("Unexpected number of drained messages: %d", flood.Drained)
+	}
+	if !c.closed {
+		t.Fatal("Expected the connection to be closed")
+	}
+}
+
+func TestBoltConn_QueryError(t *testing.T) {
+	c := createBoltConn("")
+	params := map[string]interface{}{"b": "secret", "a": 1}
+	inner := errors.New("failed")
+
+	err := c.queryError(inner, strings.Repeat("x", 300), params)
+	if msg := err.Error(); !strings.Contains(msg, strings.Repeat("x", 200)+"...") || strings.Contains(msg, strings.Repeat("x", 201)) {
+		t.Fatalf("Expected the query text to be truncated. Got: %s", msg)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "[a b]") || strings.Contains(msg, "secret") {
+		t.Fatalf("Expected only the parameter keys in the error. Got: %s", msg)
+	}
+	if err.(*errors.Error).InnerMost() != inner {
+		t.Fatalf("Expected the original error to be wrapped. Got: %#v", err)
+	}
+
+	c.SetErrorQueryLength(0)
+	if msg := c.queryError(inner, "MATCH (n) RETURN n", params).Error(); strings.Contains(msg, "MATCH") {
+		t.Fatalf("Expected the query text to be left out. Got: %s", msg)
+	}
+
+	if err := c.queryError(driver.ErrBadConn, "RETURN 1", params); err != driver.ErrBadConn {
+		t.Fatalf("Expected driver.ErrBadConn to be returned as is. Got: %#v", err)
+	}
+}
+
+func TestBoltConn_TagQuery(t *testing.T) {
+	c := createBoltConn("")
+	if query := c.tagQuery("RETURN 1"); query != "RETURN 1" {
+		t.Fatalf("Expected query to be untagged without an application name. Got: %s", query)
+	}
+
+	c.appName = "billing*/"
+	if query := c.tagQuery("RETURN 1"); query != "/* billing */ RETURN 1" {
+		t.Fatalf("Unexpected tagged query: %s", query)
+	}
+	if query := c.tagQuery("COMMIT"); query != "COMMIT" {
+		t.Fatalf("Expected transaction control to be untagged. Got: %s", query)
+	}
+}
+
+func TestBoltConn_ApplicationMetadata(t *testing.T) {
+	for _, version := range []byte{1, 3} {
+		client, server := net.Pipe()
+		c := createBoltConn("")
+		c.conn = client
+		c.serverVersion = []byte{0x00, 0x00, 0x00, version}
+		c.appName = "billing"
+		logs := &lockedBuffer{}
+		c.logger = log.New(logs, log.TextFormat)
+		c.logger.SetLevel("info")
+
+		received := make(chan interface{}, 1)
+		go func() {
+			decoder := encoding.NewDecoder(server)
+			decoder.SetRawStructures(true)
+			msg, _ := decoder.Decode()
+			received <- msg
+			server.Close()
+		}()
+
+		if err := c.sendRun("RETURN 1", nil); err != nil {
+			t.Fatalf("An error occurred sending RUN: %s", err)
+		}
+		run := (<-received).(structures.Raw)
+		if version < 3 {
+			if run.Fields[0] != "/* billing */ RETURN 1" {
+				t.Fatalf("Expected the query to be tagged before Bolt v3. Got: %#v", run.Fields)
+			}
+			// Only the query sent is tagged, so the logs show it as it was run
+			if !strings.Contains(logs.String(), "query RETURN 1 ") || strings.Contains(logs.String(), "billing") {
+				t.Fatalf("Expected the untagged query to be logged. Got: %s", logs.String())
+			}
+			continue
+		}
+
+		expected := map[string]interface{}{"tx_metadata": map[string]interface{}{"application_name": "billing"}}
+		if run.Fields[0] != "RETURN 1" || !reflect.DeepEqual(run.Fields[2], expected) {
+			t.Fatalf("Expected an untagged query with the application in its metadata. Got: %#v", run.Fields)
+		}
+	}
+}
+
+func TestBoltConn_ReceiveLoop(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	// Messages only come from the receive loop while a watchdog is set
+	c.maxExecTime = time.Minute
+	c.execStart = time.Now()
+	c.inFlight = make([]*QueryMetrics, 2)
+
+	record := []byte{0x00, 0x04, 0xB1, messages.RecordMessageSignature, 0x91, 0x01, 0x00, 0x00}
+	success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+	go func() {
+		for _, msg := range [][]byte{record, record, success, success} {
+			if _, err := server.Write(msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	records, summary, err := c.consumeAll()
+	if err != nil {
+		t.Fatalf("An error occurred consuming messages: %s", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records. Got: %#v", records)
+	}
+	if _, ok := summary.(messages.SuccessMessage); !ok {
+		t.Fatalf("Expected a success summary. Got: %#v", summary)
+	}
+
+	// Nothing is read ahead, so a new receive loop picks up where the last left off
+	c.stopReceiver()
+	if c.receiver != nil {
+		t.Fatal("Expected the receive loop to be stopped")
+	}
+	resp, err := c.receive()
+	if _, ok := resp.(messages.SuccessMessage); !ok || err != nil {
+		t.Fatalf("Expected the last success message. Got: %#v %v", resp, err)
+	}
+
+	server.Close()
+	c.execStart = time.Now()
+	if _, err := c.receive(); err == nil {
+		t.Fatal("Expected an error receiving from a closed stream")
+	}
+	if c.connErr == nil {
+		t.Fatal("Expected the closed stream to mark the connection defunct")
+	}
+	c.closeConn()
+	if c.receiver != nil {
+		t.Fatal("Expected closing the connection to stop the receive loop")
+	}
+
+	// A message requested but never taken, e.g. when an interrupt fails,
+	// doesn't leave the loop blocked, and stopping the loop waits for it, so
+	// the connection struct isn't copied while the loop is decoding
+	client, server = net.Pipe()
+	c = createBoltConn("")
+	c.conn = client
+	c.startReceiver()
+	r := c.receiver
+	r.requests <- struct{}{}
+	stopped := make(chan struct{})
+	go func() {
+		c.stopReceiver()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Expected stopping the receive loop to wait for the message it's decoding")
+	case <-time.After(20 * time.Millisecond):
+	}
+	go server.Write(success)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the receive loop to hand off the message and exit")
+	}
+	if len(r.received) != 1 {
+		t.Fatal("Expected the message to be handed off")
+	}
+	server.Close()
+}
+
+func TestBoltConn_MaxExecutionTime(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.SetMaxExecutionTime(50 * time.Millisecond)
+
+	reset := make(chan interface{}, 1)
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		// RUN and PULL_ALL, then the query runs until it's interrupted
+		decoder.Decode()
+		decoder.Decode()
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}))
+		msg, _ := decoder.Decode()
+		reset <- msg
+		encoder.Encode(messages.NewFailureMessage(map[string]interface{}{"code": "Neo.TransientError.Transaction.Terminated"}))
+		encoder.Encode(messages.NewSuccessMessage(nil))
+	}()
+
+	rows, err := c.QueryNeo("MATCH (n) RETURN n", nil)
+	if err != nil {
+		t.Fatalf("Error running query: %s", err)
+	}
+	_, _, err = rows.NextNeo()
+	if e, ok := err.(*errors.Error); ok {
+		err = e.InnerMost()
+	}
+	interrupted, ok := err.(*QueryInterruptedError)
+	if !ok {
+		t.Fatalf("Expected QueryInterruptedError. Got: %#v", err)
+	}
+	if interrupted.Fingerprint != queryFingerprint("MATCH (n)\n RETURN n") || interrupted.Elapsed < 50*time.Millisecond {
+		t.Fatalf("Unexpected interrupted query: %#v", interrupted)
+	}
+	if msg, ok := (<-reset).(messages.ResetMessage); !ok {
+		t.Fatalf("Expected the query to be interrupted with RESET. Got: %#v", msg)
+	}
+
+	if err := rows.Close(); err != nil {
+		t.Fatalf("Error closing interrupted rows: %s", err)
+	}
+	if c.state() != stateReady || len(c.inFlight) != 0 {
+		t.Fatalf("Expected the connection to be ready after the interrupt. Got: %s with %d in flight", c.state(), len(c.inFlight))
+	}
+}
+
+func TestBoltConn_ReceiveInline(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+	go server.Write(success)
+
+	resp, err := c.receive()
+	if _, ok := resp.(messages.SuccessMessage); !ok || err != nil {
+		t.Fatalf("Expected a success message. Got: %#v %v", resp, err)
+	}
+	if c.receiver != nil {
+		t.Fatal("Expected messages to be decoded inline without a watchdog")
+	}
+
+	server.Close()
+	if _, err := c.receive(); err == nil {
+		t.Fatal("Expected an error receiving from a closed stream")
+	}
+	if c.connErr == nil {
+		t.Fatal("Expected the closed stream to mark the connection defunct")
+	}
+}
+
+func TestBoltConn_MaxExecutionTimeMidStream(t *testing.T) {
+	for _, closeOnReset := range []bool{false, true} {
+		client, server := net.Pipe()
+		c := createBoltConn("")
+		c.conn = client
+		c.SetMaxExecutionTime(50 * time.Millisecond)
+
+		go func() {
+			defer server.Close()
+			decoder := encoding.NewDecoder(server)
+			decoder.SetRawStructures(true)
+			encoder := encoding.NewEncoder(server, math.MaxUint16)
+			// RUN and PULL_ALL, then records keep streaming until RESET
+			decoder.Decode()
+			decoder.Decode()
+			encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}))
+
+			reset := make(chan struct{})
+			go func() {
+				decoder.Decode()
+				close(reset)
+			}()
+			for {
+				select {
+				case <-reset:
+					if closeOnReset {
+						return
+					}
+					encoder.Encode(messages.NewFailureMessage(map[string]interface{}{"code": "Neo.TransientError.Transaction.Terminated"}))
+					encoder.Encode(messages.NewSuccessMessage(nil))
+					return
+				case <-time.After(5 * time.Millisecond):
+					if err := encoder.Encode(messages.NewRecordMessage([]interface{}{int64(1)})); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		rows, err := c.QueryNeo("MATCH (n) RETURN n", nil)
+		if err != nil {
+			t.Fatalf("Error running query: %s", err)
+		}
+		for err == nil {
+			_, _, err = rows.NextNeo()
+		}
+		if e, ok := err.(*errors.Error); ok {
+			err = e.InnerMost()
+		}
+
+		if closeOnReset {
+			if _, ok := c.connErr.(*ServerClosedError); !ok {
+				t.Fatalf("Expected the closed stream to mark the connection defunct. Got: %v", c.connErr)
+			}
+		} else {
+			if _, ok := err.(*QueryInterruptedError); !ok {
+				t.Fatalf("Expected QueryInterruptedError. Got: %v", err)
+			}
+			if err := rows.Close(); err != nil {
+				t.Fatalf("Error closing interrupted rows: %s", err)
+			}
+			if c.connErr != nil || c.state() != stateReady || len(c.inFlight) != 0 {
+				t.Fatalf("Expected the connection to be ready after the interrupt. Got: %s with %d in flight: %v", c.state(), len(c.inFlight), c.connErr)
+			}
+		}
+		c.closeConn()
+	}
+}
+
+func TestBoltConn_BoltV3Messages(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	copy(c.serverVersion, []byte{0x00, 0x00, 0x00, 0x03})
+
+	received := make(chan interface{}, 100)
+	go func() {
+		defer close(received)
+		replies := make(chan struct{}, 100)
+		defer close(replies)
+		go func() {
+			success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+			for range replies {
+				server.Write(success)
+			}
+		}()
+
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		for {
+			msg, err := decoder.Decode()
+			if err != nil {
+				return
+			}
+			received <- msg
+			replies <- struct{}{}
+		}
+	}()
+
+	if _, err := c.sendInit(); err != nil {
+		t.Fatalf("An error occurred sending HELLO: %s", err)
+	}
+	tx, err := c.BeginWithBookmarks("neo4j:bookmark:v1:tx1")
+	if err != nil {
+		t.Fatalf("An error occurred beginning transaction: %s", err)
+	}
+	if _, err := c.ExecNeo("CREATE (n)", nil); err != nil {
+		t.Fatalf("An error occurred running query: %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("An error occurred committing transaction: %s", err)
+	}
+	client.Close()
+
+	var sent []interface{}
+	for msg := range received {
+		sent = append(sent, msg)
+	}
+	if len(sent) != 5 {
+		t.Fatalf("Expected HELLO, BEGIN, RUN, PULL_ALL and COMMIT. Got: %#v", sent)
+	}
+
+	hello := sent[0].(structures.Raw)
+	if hello.Signature != messages.HelloMessageSignature || len(hello.Fields) != 1 || hello.Fields[0].(map[string]interface{})["user_agent"] != ClientID {
+		t.Fatalf("Unexpected HELLO message: %#v", hello)
+	}
+	begin := sent[1].(structures.Raw)
+	expectedBegin := map[string]interface{}{"bookmarks": []interface{}{"neo4j:bookmark:v1:tx1"}}
+	if begin.Signature != messages.BeginMessageSignature || !reflect.DeepEqual(begin.Fields[0], expectedBegin) {
+		t.Fatalf("Unexpected BEGIN message: %#v", begin)
+	}
+	run := sent[2].(structures.Raw)
+	if run.Signature != messages.RunMessageSignature || len(run.Fields) != 3 {
+		t.Fatalf("Expected RUN with a metadata field. Got: %#v", run)
+	}
+	if commit := sent[4].(structures.Raw); commit.Signature != messages.CommitMessageSignature {
+		t.Fatalf("Unexpected COMMIT message: %#v", commit)
+	}
+}
+
+func TestBoltConn_EncodingLimitError(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := createBoltConn("")
+	c.conn = client
+	c.SetEncodingLimits(1, 0)
+
+	// Nothing is sent for a message over the limits, so the server never reads
+	_, err := c.ExecNeo("RETURN $foo", map[string]interface{}{"foo": []interface{}{1}})
+	wrapped, ok := err.(*errors.Error)
+	if !ok {
+		t.Fatalf("Expected a wrapped error running a query over the limits. Got: %#v", err)
+	}
+	limitErr, ok := wrapped.InnerMost().(*encoding.LimitError)
+	if !ok {
+		t.Fatalf("Expected the innermost error to be a limit error. Got: %#v", wrapped.InnerMost())
+	}
+	if limitErr.Param != "foo" || limitErr.Limit != "depth" {
+		t.Fatalf("Unexpected limit error: %s", limitErr)
+	}
+}
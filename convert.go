@@ -0,0 +1,157 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+)
+
+// ValueConverter converts a query parameter passed through database/sql
+// into a value that can be sent to Neo4j
+type ValueConverter func(value interface{}) (interface{}, error)
+
+var (
+	convertersLock sync.RWMutex
+	converters     = map[reflect.Type]ValueConverter{
+		reflect.TypeOf(time.Time{}): convertTime,
+	}
+)
+
+// RegisterConverter registers a converter for parameters of the same type
+// as sample, e.g. uuid.UUID or a domain type, so they can be passed through
+// database/sql without marshalling them first.  The converter is applied
+// to query arguments, and to values inside parameter maps and lists.
+//
+// A converter that returns a map[string]interface{} lets a value be passed
+// as a query argument on its own, as its parameters.
+//
+// time.Time is converted to an RFC3339 string by default.
+func RegisterConverter(sample interface{}, converter ValueConverter) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	converters[reflect.TypeOf(sample)] = converter
+}
+
+func convertTime(value interface{}) (interface{}, error) {
+	return value.(time.Time).Format(time.RFC3339Nano), nil
+}
+
+// convertValue applies the registered converters to a value, and the values
+// inside it.  Typed slices and maps are converted to the []interface{} and
+// map[string]interface{} Neo4j expects, and structs to maps.  See structToMap.
+func convertValue(value interface{}) (interface{}, error) {
+	convertersLock.RLock()
+	converter, ok := converters[reflect.TypeOf(value)]
+	convertersLock.RUnlock()
+
+	if ok {
+		var err error
+		if value, err = converter(value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			item, err := convertValue(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[k] = item
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := convertValue(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = item
+		}
+		return converted, nil
+	case []byte, structures.Structure:
+		return value, nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		converted := make([]interface{}, v.Len())
+		for i := range converted {
+			converted[i] = v.Index(i).Interface()
+		}
+		return convertValue(converted)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return value, nil
+		}
+		converted := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			converted[k.String()] = v.MapIndex(k).Interface()
+		}
+		return convertValue(converted)
+	case reflect.Struct:
+		return convertValue(structToMap(v))
+	default:
+		return value, nil
+	}
+}
+
+// structToMap converts the exported fields of a struct to a map.  The key
+// for a field is set with a `bolt:"name"` tag, and defaults to the field
+// name.  Fields tagged `bolt:"-"` are left out.
+func structToMap(v reflect.Value) map[string]interface{} {
+	t := v.Type()
+	output := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("bolt"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		output[name] = v.Field(i).Interface()
+	}
+	return output
+}
+
+// CheckNamedValue converts query arguments passed through database/sql.
+// Parameter maps and structs can be passed directly, as can typed slices and
+// maps given as parameter values, with the registered converters applied to
+// them. See RegisterConverter and driverArgsToMap.
+// Implements driver.NamedValueChecker.
+func (c *boltConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.([]byte); ok {
+		return nil
+	}
+
+	value, err := convertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		nv.Value = value
+		return nil
+	}
+
+	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(value)
+	return err
+}
@@ -0,0 +1,93 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testUserID int
+
+type testUser struct {
+	name string
+}
+
+func TestBoltConn_CheckNamedValue(t *testing.T) {
+	RegisterConverter(testUserID(0), func(value interface{}) (interface{}, error) {
+		return int64(value.(testUserID)), nil
+	})
+	RegisterConverter(testUser{}, func(value interface{}) (interface{}, error) {
+		return map[string]interface{}{"name": value.(testUser).name}, nil
+	})
+
+	c := createBoltConn("")
+	at := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	nv := &driver.NamedValue{Value: map[string]interface{}{"id": testUserID(1), "at": []interface{}{at}}}
+	if err := c.CheckNamedValue(nv); err != nil {
+		t.Fatalf("Error checking parameter map: %s", err)
+	}
+	expected := map[string]interface{}{"id": int64(1), "at": []interface{}{"2017-01-02T03:04:05Z"}}
+	if !reflect.DeepEqual(nv.Value, expected) {
+		t.Fatalf("Unexpected converted parameters. Expected %#v. Got: %#v", expected, nv.Value)
+	}
+
+	nv = &driver.NamedValue{Value: testUser{name: "foo"}}
+	if err := c.CheckNamedValue(nv); err != nil {
+		t.Fatalf("Error checking converted parameter: %s", err)
+	}
+	params, err := driverArgsToMap([]driver.Value{nv.Value})
+	if err != nil || params["name"] != "foo" {
+		t.Fatalf("Expected a value converted to a map to be used as parameters. Got: %#v %v", params, err)
+	}
+
+	if err := c.CheckNamedValue(&driver.NamedValue{Value: make(chan int)}); err == nil {
+		t.Fatal("Expected an error checking an unsupported value")
+	}
+}
+
+type testPerson struct {
+	Name    string   `bolt:"name"`
+	Tags    []string `bolt:"tags"`
+	Age     int
+	Skipped string `bolt:"-"`
+	private string
+}
+
+func TestDriverArgsToMap_StructsAndPairs(t *testing.T) {
+	c := createBoltConn("")
+
+	args := []interface{}{
+		&testPerson{Name: "foo", Tags: []string{"a", "b"}, Age: 3, Skipped: "x", private: "y"},
+		"ids", []int{1, 2},
+		"props", map[string]string{"a": "b"},
+	}
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		nv := &driver.NamedValue{Ordinal: i + 1, Value: arg}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatalf("Error checking arg %#v: %s", arg, err)
+		}
+		values[i] = nv.Value
+	}
+
+	params, err := driverArgsToMap(values)
+	if err != nil {
+		t.Fatalf("Error converting args to parameters: %s", err)
+	}
+	expected := map[string]interface{}{
+		"name":  "foo",
+		"tags":  []interface{}{"a", "b"},
+		"Age":   3,
+		"ids":   []interface{}{1, 2},
+		"props": map[string]interface{}{"a": "b"},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("Unexpected parameters. Expected %#v. Got: %#v", expected, params)
+	}
+
+	if _, err := driverArgsToMap([]driver.Value{"name"}); err == nil {
+		t.Fatal("Expected an error for a parameter name without a value")
+	}
+}
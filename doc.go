@@ -25,10 +25,35 @@ internal APIs will also pipeline statements where it is able to
 reliably do so, but by manually using the pipelining feature
 you can maximize your throughput.
 
+Transactions have their own ExecPipeline and QueryPipeline, so the
+statements of a transaction can be sent in one round trip too.
+
+To load a lot of documents, IngestJSON and IngestDocuments write them in
+batches with an UNWIND query, each batch in its own transaction, e.g.
+`UNWIND {rows} AS row CREATE (n:Person) SET n = row`.
+
+Rows.StructScan and ScanAll read rows into structs, by column names and
+the properties of returned nodes, with the same `bolt:"name"` field tags
+as struct parameters, instead of type asserting each value.
+
+From Bolt v2 on, dates, times and durations can be sent and returned.  A
+time.Time parameter is sent as a DateTime, and DateTime values are returned
+as time.Time.  The other temporal types are in the structures/temporal
+package.  Through database/sql, they're returned as time.Time, and
+durations as ISO 8601 strings.
+
+Points, e.g. from `point({x: 1, y: 2})`, are sent and returned as
+graph.Point2D and graph.Point3D, also from Bolt v2 on.  Through
+database/sql, they're returned as extended WKT strings, e.g.
+`SRID=7203;POINT(1 2)`.
+
 The API provides connection pooling using the `NewDriverPool` method.
 This allows you to pass it the maximum number of open connections
 to be used in the pool.  Once this limit is hit, any new clients will
 have to wait for a connection to become available again.
+`NewDriverPoolWithConfig` also sets how many connections stay connected
+while idle, how many connect up front, how long connections are kept, and
+how long to wait for one.  See PoolConfig.
 
 The sql driver is registered as "neo4j-bolt". The sql.driver interface
 is much more limited than what bolt and neo4j supports.  In some cases,
@@ -41,10 +66,14 @@ makes for a less efficient and more 'clunky' implementation.  A glaring
 instance of this is passing parameters.  Neo4j expects named parameters
 but the driver interface can only really support positional parameters.
 To get around this, the user must create a map[string]interface{} of their
-parameters and marshal it to a driver.Value using the encoding.Marshal
-function. Similarly, the user must unmarshal data returned from the queries
-using the encoding.Unmarshal function, then use type assertions to retrieve
-the proper type.
+parameters and pass it as a single argument, or marshal it to a driver.Value
+using the encoding.Marshal function. A struct can be passed instead of a map,
+with the parameter names set by `bolt:"name"` field tags, as can parameter
+name and value pairs, e.g. `db.Exec(query, "ids", []int{1, 2})`.
+Types Neo4j can't store, like time.Time, are converted by the converters
+registered with RegisterConverter. Similarly, the user must unmarshal data
+returned from the queries using the encoding.Unmarshal function, then use
+type assertions to retrieve the proper type.
 
 In most cases the driver will return the data from neo as the proper
 go-specific types.  For integers they always come back
@@ -75,6 +104,18 @@ TLS is supported by using query parameters on the connection string, like so:
 The supported query params are:
 
 * timeout - the number of seconds to set the connection timeout to. Defaults to 60 seconds.
+* max_execution_time - the number of seconds a query may run before it's interrupted with RESET. Same as ConnOptions.MaxExecutionTime. Defaults to 0, no limit.
+* read_retries - the number of times to retry a read that times out while waiting for the next message from the server. Defaults to 0.
+* bolt_version - only propose this Bolt protocol version during the handshake, for debugging server quirks. Must be a version the driver supports: 3, 2 or 1. By default all three are proposed, newest first.
+* compat_mode - Set to 'true' or '1' to disable protocol features newer than Bolt v1, even when a newer version is negotiated
+* chunk_size - The maximum size in bytes of the chunks messages are sent in. Same as Conn.SetChunkSize. 'auto' sizes writes to the messages and the connection instead. See ConnOptions.AdaptiveChunking
+* fetch_size, database - Not supported.  Fetching results in batches and selecting a database need Bolt v4, so connection strings with them are rejected
+* application_name - A name for the application, included in the client name sent to the server and in the transaction metadata (a comment before every query before Bolt v3), so its load can be told apart. Overrides Driver.SetApplicationName
+* read_only - Set to 'true' or '1' to refuse queries that write, like CREATE or SET, before they're sent. Same as ConnOptions.ReadOnly
+* missing_fields - What rows do when Neo4j doesn't return the names of their columns, as some procedures don't. 'empty' (the default) returns no columns, 'columns' names them col0..colN from the first row, and 'error' fails the query with a *NoFieldsError. Same as ConnOptions.MissingFields
+* lazy_metadata - Set to 'true' or '1' to keep query plans, profiles and notifications encoded until they're read, instead of decoding them for every query. Same as ConnOptions.LazyMetadata
+* graph_encoding - How lists, maps, nodes, relationships and paths are returned through database/sql. 'bolt' (the default) returns bytes to decode with encoding.Unmarshal, 'json' returns JSON bytes generic sql tooling can read
+* idle_monitor - Set to 'true' or '1' to watch idle pooled connections in the background, so dead connections are detected before they are borrowed
 * tls - Set to 'true' or '1' if you want to use TLS encryption
 * tls_no_verify - Set to 'true' or '1' if you want to accept any server certificate (for testing, not secure)
 * tls_ca_cert_file - path to a custom ca cert for a self-signed TLS cert
@@ -91,5 +132,7 @@ from a wrapped error, you can do so by calling
 If there is an error with the database connection, you should get a sql/driver ErrBadConn
 as per the best practice recommendations of the Golang SQL Driver. However, this error
 may be wrapped, so you might have to call `InnerMost` to get it, as specified above.
+If the server closed the connection, e.g. because Neo4j restarted, the innermost
+error will be a *ServerClosedError instead.
 */
 package golangNeo4jBoltDriver
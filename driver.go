@@ -1,21 +1,25 @@
 package golangNeo4jBoltDriver
 
 import (
-	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
-	"time"
+	"context"
 	"database/sql"
 	"database/sql/driver"
-	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
 )
 
 var (
-	magicPreamble     = []byte{0x60, 0x60, 0xb0, 0x17}
+	magicPreamble = []byte{0x60, 0x60, 0xb0, 0x17}
+	// supportedVersions are proposed in order of preference
 	supportedVersions = []byte{
+		0x00, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x00, 0x02,
 		0x00, 0x00, 0x00, 0x01,
 		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00,
-		0x00, 0x00, 0x00, 0x00,
 	}
 	handShake          = append(magicPreamble, supportedVersions...)
 	noVersionSupported = []byte{0x00, 0x00, 0x00, 0x00}
@@ -43,10 +47,27 @@ type Driver interface {
 	// OpenNeo opens a Neo-specific connection. This should be used
 	// directly when not using the golang sql interface
 	OpenNeo(string) (Conn, error)
+	// SetLogger sets the logger for connections opened by the driver,
+	// to separate their logs from the package level loggers
+	SetLogger(*log.Logger)
+	// SetApplicationName names the application in the client name sent to the
+	// server, and in the transaction metadata, or a comment before every query
+	// before Bolt v3, so load can be attributed to it in the server's monitoring views. The application_name URL param overrides it.
+	SetApplicationName(string)
+	// SetMetricsHook sets a hook called with the metrics of each query run on
+	// connections opened by the driver.  See QueryMetrics.
+	SetMetricsHook(MetricsHook)
+	// SetQueryGuard sets a guard checking each query before it's sent on
+	// connections opened by the driver.  See QueryGuard.
+	SetQueryGuard(QueryGuard)
 }
 
 type boltDriver struct {
-	recorder *recorder
+	recorder    *recorder
+	logger      *log.Logger
+	appName     string
+	metricsHook MetricsHook
+	queryGuard  QueryGuard
 }
 
 // NewDriver creates a new Driver object
@@ -64,6 +85,26 @@ func (d *boltDriver) OpenNeo(connStr string) (Conn, error) {
 	return newBoltConn(connStr, d)
 }
 
+// SetLogger sets the logger for connections opened by the driver
+func (d *boltDriver) SetLogger(logger *log.Logger) {
+	d.logger = logger
+}
+
+// SetApplicationName names the application in connections opened by the driver
+func (d *boltDriver) SetApplicationName(name string) {
+	d.appName = name
+}
+
+// SetMetricsHook sets the hook called with the metrics of each query run on connections opened by the driver
+func (d *boltDriver) SetMetricsHook(hook MetricsHook) {
+	d.metricsHook = hook
+}
+
+// SetQueryGuard sets the guard checking each query before it's sent on connections opened by the driver
+func (d *boltDriver) SetQueryGuard(guard QueryGuard) {
+	d.queryGuard = guard
+}
+
 // DriverPool is a driver allowing connection to Neo4j with support for connection pooling
 // The driver allows you to open a new connection to Neo4j
 //
@@ -74,6 +115,83 @@ func (d *boltDriver) OpenNeo(connStr string) (Conn, error) {
 type DriverPool interface {
 	// OpenPool opens a Neo-specific connection.
 	OpenPool() (Conn, error)
+	// SetLogger sets the logger for connections opened by the pool,
+	// to separate their logs from the package level loggers
+	SetLogger(*log.Logger)
+	// SetCircuitBreaker makes the pool fail fast with a CircuitOpenError for the cooldown
+	// period after the given number of consecutive failures connecting to the server,
+	// instead of waiting on the dial timeout for every connection. 0 failures disables it.
+	SetCircuitBreaker(failures int, cooldown time.Duration)
+	// SetOperationBudget bounds the total time of an operation on a borrowed connection,
+	// from the call to OpenPool.  Time spent waiting for a connection reduces the read
+	// and write deadlines of the queries run on it. 0 means no budget.
+	SetOperationBudget(time.Duration)
+	// SetApplicationName names the application in the client name sent to the
+	// server, and in the transaction metadata, or a comment before every query
+	// before Bolt v3, so load can be attributed to it in the server's monitoring views. The application_name URL param overrides it.
+	SetApplicationName(string)
+	// SetMetricsHook sets a hook called with the metrics of each query run on
+	// connections from the pool.  See QueryMetrics.
+	SetMetricsHook(MetricsHook)
+	// SetQueryGuard sets a guard checking each query before it's sent on
+	// connections from the pool.  See QueryGuard.
+	SetQueryGuard(QueryGuard)
+	// Partition gets the named sub-pool of the pool, creating it with up to max
+	// connections on first use.  A partition connects to the same server, starting
+	// with the settings of the pool, but has its own connections, size limit and
+	// operation budget, so e.g. bulk jobs can't starve latency sensitive queries
+	// of connections.  Closing the pool closes its partitions.
+	Partition(name string, max int) (DriverPool, error)
+	// Borrowed lists the connections borrowed from the pool, and its partitions,
+	// that haven't been closed yet, with how long they've been out, to help
+	// track down connection leaks
+	Borrowed() []BorrowedConn
+	// SetLeakDetection makes the pool log, once, the stack trace captured when a
+	// connection was borrowed, if it isn't closed within the given time.  It's
+	// logged as an error.  0 turns leak detection off.
+	SetLeakDetection(time.Duration)
+	// QueryConcurrently runs the queries on up to concurrency connections from
+	// the pool at once, returning the result of each in the same order as the
+	// queries.  If any fail, the error is a *FanOutError and the results of the
+	// rest are still returned.  See QuerySpec and QueryResult.
+	QueryConcurrently(ctx context.Context, queries []QuerySpec, concurrency int) ([]QueryResult, error)
+	// ExecOnAll runs a maintenance statement, e.g. CALL db.clearQueryCaches(),
+	// once on every distinct server the pool and its partitions connect to,
+	// returning the result from each.  If any fail, the error is a *FanOutError
+	// indexing the failed results.  See MemberResult.
+	ExecOnAll(query string, params map[string]interface{}) ([]MemberResult, error)
+	// SetRetryPolicy sets how QueryNeoAll and QueryConcurrently retry queries
+	// that fail with a transient error, e.g. a deadlock, or a broken
+	// connection.  Each attempt borrows a fresh connection.  The zero
+	// policy, the default, doesn't retry.  Retried queries must be idempotent.
+	SetRetryPolicy(RetryPolicy)
+	// QueryNeoAll runs a query outside of a transaction on a connection from
+	// the pool, closing the connection after, and retries it as set by
+	// SetRetryPolicy
+	QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error)
+	// ExecStmt executes a statement bound with Statement.Bind on a connection
+	// from the pool, closing the connection after.  A bound statement can be
+	// run any number of times, from any number of goroutines.
+	ExecStmt(BoundStatement) (Result, error)
+	// ReadTransaction runs the work in a read transaction on a connection from
+	// the pool, committing it if the work succeeds and rolling it back if not,
+	// then returns the connection to the pool.  Transient failures are retried
+	// in a new transaction as set by SetRetryPolicy.  From Bolt v3 on, the
+	// transaction is marked as read only.  The pool connects to a single
+	// server, so there is no routing to a leader.
+	ReadTransaction(TransactionWork) error
+	// SetMaxConnLifetime makes the pool replace connections that connected
+	// longer ago than the given time when they're next borrowed, e.g. to
+	// spread connections over servers behind a load balancer.  0, the
+	// default, keeps connections for as long as they work.
+	SetMaxConnLifetime(time.Duration)
+	// SetIdleTimeout makes the pool replace connections that haven't been
+	// used for longer than the given time when they're next borrowed, instead
+	// of checking them, e.g. when a firewall drops idle connections.  0, the
+	// default, doesn't.  Other connections are checked with a RESET.
+	SetIdleTimeout(time.Duration)
+	// WriteTransaction runs the work in a write transaction, like ReadTransaction
+	WriteTransaction(TransactionWork) error
 	reclaim(*boltConn) error
 }
 
@@ -84,12 +202,31 @@ type ClosableDriverPool interface {
 }
 
 type boltDriverPool struct {
-	connStr  string
-	maxConns int
-	pool     chan *boltConn
-	connRefs []*boltConn
-	refLock  sync.Mutex
-	closed   bool
+	connStr     string
+	maxConns    int
+	pool        chan *boltConn
+	connRefs    []*boltConn
+	refLock     sync.Mutex
+	connsLock   sync.Mutex
+	closed      int32
+	confLock    sync.Mutex
+	logger      *log.Logger
+	breaker     *circuitBreaker
+	opBudget    time.Duration
+	appName     string
+	metricsHook MetricsHook
+	queryGuard  QueryGuard
+	partitions  map[string]*boltDriverPool
+	partLock    sync.Mutex
+	borrowed    map[*boltConn]*borrowing
+	borrowLock  sync.Mutex
+	leakAfter   time.Duration
+	retryPolicy RetryPolicy
+	maxLifetime time.Duration
+	idleTimeout time.Duration
+	borrowWait  time.Duration
+	maxIdle     int
+	idleCount   int32
 }
 
 // NewDriverPool creates a new Driver object with connection pooling
@@ -123,60 +260,279 @@ func createDriverPool(connStr string, max int) (*boltDriverPool, error) {
 
 // OpenPool opens a returns a Bolt connection from the pool to the Neo4J database.
 func (d *boltDriverPool) OpenPool() (Conn, error) {
+	conn, err := d.take()
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked without the lock, so other borrowers don't wait on the round trip
+	if err := conn.stopIdleMonitor(); err != nil {
+		conn.logger.Errorf("Idle monitor detected a bad connection: %s", err)
+		conn.closeConn()
+		conn.conn = nil
+	}
+	ok := healthy(conn)
+
 	// For each connection request we need to block in case the Close function is called. This gives us a guarantee
 	// when closing the pool no new connections are made.
 	d.refLock.Lock()
 	defer d.refLock.Unlock()
-	if !d.closed {
-		conn := <-d.pool
-		if connectionNilOrClosed(conn) {
-			if err := conn.initialize(); err != nil {
-				return nil, err
-			}
-			d.connRefs = append(d.connRefs, conn)
+	if d.isClosed() {
+		if conn.conn != nil {
+			conn.closeConn()
+			conn.conn = nil
+		}
+		// Put it back, so borrowers still waiting on the pool wake up and fail too
+		d.pool <- conn
+		return nil, errors.New("Driver pool has been closed")
+	}
+	if !ok {
+		conn = d.replace(conn)
+		d.confLock.Lock()
+		breaker, appName := d.breaker, d.appName
+		d.confLock.Unlock()
+		if err := breaker.allow(); err != nil {
+			// Return the unconnected connection, to be connected on a later borrow
+			d.pool <- conn
+			return nil, err
+		}
+		conn.appName = appName
+		if err := conn.initialize(); err != nil {
+			// initialize closes the connection, reclaiming it for the pool
+			breaker.failure()
+			return nil, err
+		}
+		breaker.success()
+		d.swapRef(nil, conn)
+	}
+	d.borrow(conn)
+	return conn, nil
+}
+
+// take takes a connection off the pool, waiting for one to be returned if
+// they're all borrowed, and sets it up with the pool's settings.  It doesn't
+// hold refLock while it waits, as the borrower it's waiting on needs it.
+func (d *boltDriverPool) take() (*boltConn, error) {
+	if d.isClosed() {
+		return nil, errors.New("Driver pool has been closed")
+	}
+
+	d.confLock.Lock()
+	wait, budget := d.borrowTimeout(), d.opBudget
+	d.confLock.Unlock()
+
+	var conn *boltConn
+	start := time.Now()
+	if wait > 0 {
+		select {
+		case conn = <-d.pool:
+		case <-time.After(wait):
+			return nil, errors.New("Timed out after %s waiting for a connection from the pool", wait)
 		}
-		return conn, nil
+	} else {
+		conn = <-d.pool
+	}
+	if budget > 0 {
+		conn.opDeadline = start.Add(budget)
+	}
+	if conn.conn != nil {
+		atomic.AddInt32(&d.idleCount, -1)
+	}
+	d.configure(conn)
+	return conn, nil
+}
+
+// configure applies the pool's settings to a connection being borrowed
+func (d *boltDriverPool) configure(conn *boltConn) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	conn.logger = d.logger
+	conn.metricsHook = d.metricsHook
+	conn.queryGuard = d.queryGuard
+	conn.maxLifetime = d.maxLifetime
+	conn.idleTimeout = d.idleTimeout
+}
+
+// replace swaps a connection that failed its checks for a fresh one, as
+// the old one may be defunct, which can't be undone.  Called with refLock held.
+func (d *boltDriverPool) replace(conn *boltConn) *boltConn {
+	if conn.conn == nil && conn.connErr == nil && !conn.closed && conn.created.IsZero() {
+		// Never connected, so there's nothing to clean up
+		return conn
+	}
+	conn.closed = true
+	d.swapRef(conn, nil)
+
+	fresh, _ := newPooledBoltConn(d.connStr, d)
+	fresh.opDeadline = conn.opDeadline
+	d.configure(fresh)
+	return fresh
+}
+
+// SetLogger sets the logger for connections opened by the pool
+func (d *boltDriverPool) SetLogger(logger *log.Logger) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.logger = logger
+}
+
+// SetApplicationName names the application in connections opened by the pool
+func (d *boltDriverPool) SetApplicationName(name string) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.appName = name
+}
+
+// SetMetricsHook sets the hook called with the metrics of each query run on connections from the pool
+func (d *boltDriverPool) SetMetricsHook(hook MetricsHook) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.metricsHook = hook
+}
+
+// SetQueryGuard sets the guard checking each query before it's sent on connections from the pool
+func (d *boltDriverPool) SetQueryGuard(guard QueryGuard) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.queryGuard = guard
+}
+
+// SetCircuitBreaker makes the pool fail fast after consecutive connection failures
+func (d *boltDriverPool) SetCircuitBreaker(failures int, cooldown time.Duration) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	if failures <= 0 {
+		d.breaker = nil
+		return
 	}
-	return nil, errors.New("Driver pool has been closed")
+	d.breaker = newCircuitBreaker(failures, cooldown)
 }
 
-func connectionNilOrClosed(conn *boltConn) (bool) {
-	if(conn.conn == nil) {//nil check before attempting read
-		return true
+// SetOperationBudget bounds the total time of an operation on a borrowed connection
+func (d *boltDriverPool) SetOperationBudget(budget time.Duration) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.opBudget = budget
+}
+
+// Partition gets the named sub-pool of the pool, creating it on first use
+func (d *boltDriverPool) Partition(name string, max int) (DriverPool, error) {
+	// Partitions have their own lock, so getting one doesn't wait on borrowers
+	// blocked on the pool
+	d.partLock.Lock()
+	defer d.partLock.Unlock()
+	if d.isClosed() {
+		return nil, errors.New("Driver pool has been closed")
+	}
+	if partition, ok := d.partitions[name]; ok {
+		return partition, nil
 	}
-	conn.conn.SetReadDeadline(time.Now())
-	zero := make ([]byte, 0)
-	_, err := conn.conn.Read(zero)//read zero bytes to validate connection is still alive
+
+	partition, err := createDriverPool(d.connStr, max)
 	if err != nil {
-		log.Error("Bad Connection state detected", err)//the error caught here could be a io.EOF or a timeout, either way we want to log the error & return true
-		return true
+		return nil, errors.Wrap(err, "An error occurred creating pool partition %s", name)
+	}
+
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	partition.logger = d.logger
+	if d.breaker != nil {
+		// Breakers aren't thread safe, and the partition guards its own with its own lock
+		partition.breaker = newCircuitBreaker(d.breaker.threshold, d.breaker.cooldown)
+	}
+	partition.opBudget = d.opBudget
+	partition.appName = d.appName
+	partition.metricsHook = d.metricsHook
+	partition.queryGuard = d.queryGuard
+	partition.leakAfter = d.leakAfter
+	partition.retryPolicy = d.retryPolicy
+	partition.maxLifetime = d.maxLifetime
+	partition.idleTimeout = d.idleTimeout
+	partition.borrowWait = d.borrowWait
+
+	if d.partitions == nil {
+		d.partitions = map[string]*boltDriverPool{}
 	}
-	return false
+	d.partitions[name] = partition
+	return partition, nil
 }
 
 // Close all connections in the pool
 func (d *boltDriverPool) Close() error {
+	// Mark the pool as closed to stop any new connections or partitions
+	atomic.StoreInt32(&d.closed, 1)
+
+	d.partLock.Lock()
+	for name, partition := range d.partitions {
+		if err := partition.Close(); err != nil {
+			d.partLock.Unlock()
+			return errors.Wrap(err, "An error occurred closing pool partition %s", name)
+		}
+	}
+	d.partLock.Unlock()
+
 	// Lock the connection ref so no new connections can be added
 	d.refLock.Lock()
 	defer d.refLock.Unlock()
+	d.connsLock.Lock()
+	defer d.connsLock.Unlock()
 	for _, conn := range d.connRefs {
 		// Remove the reference to the pool, to allow a clean up of the connection
 		conn.poolDriver = nil
 		err := conn.Close()
 		if err != nil {
-			d.closed = true
 			return err
 		}
 	}
-	// Mark the pool as closed to stop any new connections
-	d.closed = true
 	return nil
 }
 
+// swapRef swaps a connection closed with the pool for the one taking its
+// place.  A nil old connection adds one, and a nil new connection removes one.
+func (d *boltDriverPool) swapRef(old, conn *boltConn) {
+	d.connsLock.Lock()
+	defer d.connsLock.Unlock()
+	for i, ref := range d.connRefs {
+		if old == nil || ref != old {
+			continue
+		}
+		if conn == nil {
+			d.connRefs = append(d.connRefs[:i], d.connRefs[i+1:]...)
+		} else {
+			d.connRefs[i] = conn
+		}
+		return
+	}
+	if conn != nil {
+		d.connRefs = append(d.connRefs, conn)
+	}
+}
+
+// isClosed checks whether the pool has been closed
+func (d *boltDriverPool) isClosed() bool {
+	return atomic.LoadInt32(&d.closed) != 0
+}
+
 func (d *boltDriverPool) reclaim(conn *boltConn) error {
 	var newConn *boltConn
 	var err error
+	d.giveBack(conn)
+	// The receive loop is bound to the old struct
+	conn.stopReceiver()
 	if conn.connErr != nil || conn.closed {
+		d.swapRef(conn, nil)
+		newConn, err = newPooledBoltConn(d.connStr, d)
+		if err != nil {
+			return err
+		}
+	} else if conn.conn != nil && !d.reserveIdle() {
+		// Enough connections are idle, so this one gives up its place
+		// to a connection that connects when it's borrowed
+		if err := conn.closeConn(); err != nil {
+			conn.logger.Errorf("An error occurred disconnecting connection past the maximum idle: %s", err)
+		}
+		conn.closed = true
+		d.swapRef(conn, nil)
 		newConn, err = newPooledBoltConn(d.connStr, d)
 		if err != nil {
 			return err
@@ -186,8 +542,19 @@ func (d *boltDriverPool) reclaim(conn *boltConn) error {
 		// it isn't held on to
 		newConn = &boltConn{}
 		*newConn = *conn
+		// The encoder and decoder are bound to the old struct
+		newConn.encoder = nil
+		newConn.decoder = nil
+		newConn.stream = nil
+		// Settings, bookmark chains and operation deadlines belong to the borrower, not the connection
+		newConn.restoreDefaults()
+		newConn.bookmarks = nil
+		newConn.bookmarkChain = ""
+		newConn.opDeadline = time.Time{}
+		d.swapRef(conn, newConn)
 	}
 
+	newConn.startIdleMonitor()
 	d.pool <- newConn
 	conn = nil
 
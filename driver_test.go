@@ -1,7 +1,9 @@
 package golangNeo4jBoltDriver
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
 
 	"time"
@@ -366,3 +368,178 @@ func TestBoltDriverPool_ClosePool(t *testing.T) {
 		t.Fatalf("An error occurred creating trying to close the driver pool: %s", err)
 	}
 }
+
+func TestBoltDriverPool_OperationBudget(t *testing.T) {
+	pool, err := createDriverPool("bolt://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	pool.SetOperationBudget(10 * time.Millisecond)
+
+	// Hold the only connection, so borrowing waits out the budget
+	conn := <-pool.pool
+	if _, err := pool.OpenPool(); err == nil {
+		t.Fatal("Expected a timeout waiting for a connection")
+	}
+
+	// A connection with less budget left than its timeout uses the budget as its deadline
+	conn.opDeadline = time.Now().Add(time.Second)
+	if deadline := conn.ioDeadline(); !deadline.Equal(conn.opDeadline) {
+		t.Fatalf("Expected the operation deadline to bound reads and writes. Got: %s", deadline)
+	}
+}
+
+func TestBoltDriverPool_Partition(t *testing.T) {
+	pool, err := createDriverPool("bolt://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	pool.SetApplicationName("importer")
+	pool.SetOperationBudget(10 * time.Millisecond)
+
+	batch, err := pool.Partition("batch", 2)
+	if err != nil {
+		t.Fatalf("An error occurred creating partition: %s", err)
+	}
+	if again, _ := pool.Partition("batch", 5); again != batch {
+		t.Fatal("Expected the existing partition to be returned")
+	}
+	partition := batch.(*boltDriverPool)
+	if cap(partition.pool) != 2 || partition.appName != "importer" || partition.opBudget != pool.opBudget {
+		t.Fatalf("Expected the partition to have its own size and the pool's settings. Got: %#v", partition)
+	}
+
+	// With every connection of the pool held, the partition still has its own
+	<-pool.pool
+	if _, err := pool.OpenPool(); err == nil || !strings.Contains(err.Error(), "Timed out") {
+		t.Fatalf("Expected a timeout waiting on the pool. Got: %v", err)
+	}
+	if _, err := batch.OpenPool(); err == nil || strings.Contains(err.Error(), "Timed out") {
+		t.Fatalf("Expected the partition to try to connect. Got: %v", err)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("An error occurred closing pool: %s", err)
+	}
+	if !partition.isClosed() {
+		t.Fatal("Expected closing the pool to close its partitions")
+	}
+}
+
+func TestBoltDriverPool_PartitionWhileExhausted(t *testing.T) {
+	pool, err := createDriverPool("bolt://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+
+	// A borrower waits on the pool with every connection held
+	held := <-pool.pool
+	go pool.OpenPool()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		pool.SetApplicationName("importer")
+		_, err := pool.Partition("batch", 1)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("An error occurred creating partition: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected getting a partition not to wait on the exhausted pool")
+	}
+
+	// Let the waiting borrower through, so the pool can be closed
+	pool.pool <- held
+	if err := pool.Close(); err != nil {
+		t.Fatalf("An error occurred closing pool: %s", err)
+	}
+}
+
+func TestBoltDriverPool_Borrowed(t *testing.T) {
+	pool, err := createDriverPool("bolt://127.0.0.1:1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	batch, err := pool.Partition("batch", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating partition: %s", err)
+	}
+
+	conn := <-pool.pool
+	pool.borrow(conn)
+	conn.trackUse()
+	conn.trackUse()
+	batchConn := <-batch.(*boltDriverPool).pool
+	batch.(*boltDriverPool).borrow(batchConn)
+
+	borrowed := pool.Borrowed()
+	if len(borrowed) != 2 {
+		t.Fatalf("Expected 2 borrowed connections. Got: %#v", borrowed)
+	}
+	if borrowed[0].Partition != "" || borrowed[0].Stats.Queries != 2 || borrowed[0].Stats.LastUsed.IsZero() {
+		t.Fatalf("Expected the pool's connection, borrowed first, with its usage. Got: %#v", borrowed[0])
+	}
+	if borrowed[1].Partition != "batch" || borrowed[1].Out > borrowed[0].Out {
+		t.Fatalf("Expected the partition's connection, borrowed last. Got: %#v", borrowed[1])
+	}
+
+	conn.closed = true
+	if err := pool.reclaim(conn); err != nil {
+		t.Fatalf("An error occurred reclaiming connection: %s", err)
+	}
+	if borrowed := pool.Borrowed(); len(borrowed) != 1 || borrowed[0].Partition != "batch" {
+		t.Fatalf("Expected the returned connection to be removed. Got: %#v", borrowed)
+	}
+}
+
+func TestBoltDriverPool_LeakDetection(t *testing.T) {
+	pool, err := createDriverPool("bolt://127.0.0.1:1", 2)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	output := &lockedBuffer{}
+	logger := log.New(output, log.TextFormat)
+	logger.SetLevel("error")
+	pool.SetLogger(logger)
+	pool.SetLeakDetection(10 * time.Millisecond)
+
+	leaked := <-pool.pool
+	pool.borrow(leaked)
+	returned := <-pool.pool
+	pool.borrow(returned)
+	returned.closed = true
+	if err := pool.reclaim(returned); err != nil {
+		t.Fatalf("An error occurred reclaiming connection: %s", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	logged := output.String()
+	if strings.Count(logged, "may have leaked") != 1 || !strings.Contains(logged, "TestBoltDriverPool_LeakDetection") {
+		t.Fatalf("Expected the leaked connection's borrower to be logged once. Got: %s", logged)
+	}
+	if borrowed := pool.Borrowed(); len(borrowed) != 1 || len(borrowed[0].Stack) == 0 {
+		t.Fatalf("Expected the borrower's stack in the report. Got: %#v", borrowed)
+	}
+}
+
+// lockedBuffer is a buffer that can be logged to from other go routines
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
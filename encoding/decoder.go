@@ -6,8 +6,10 @@ import (
 	"io"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
 )
 
 // Decoder decodes a message from the bolt protocol stream
@@ -18,27 +20,46 @@ import (
 // Maps and Slices are a special case, where only
 // map[string]interface{} and []interface{} are supported.
 // The interface for maps and slices may be more permissive in the future.
+//
+// A Decoder can be reused for many messages, and pointed at a new stream
+// with Reset.  Decoder objects ARE NOT THREAD SAFE.
 type Decoder struct {
-	r   io.Reader
-	buf *bytes.Buffer
+	r        io.Reader
+	buf      *bytes.Buffer
+	raw      bool
+	lazyKeys map[string]bool
 }
 
 // NewDecoder Creates a new Decoder object
-func NewDecoder(r io.Reader) Decoder {
-	return Decoder{
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
 		r:   r,
 		buf: &bytes.Buffer{},
 	}
 }
 
+// Reset points the decoder at a new stream
+func (d *Decoder) Reset(r io.Reader) {
+	d.r = r
+	d.buf.Reset()
+}
+
+// SetRawStructures makes the decoder decode structures with unrecognized
+// signatures as structures.Raw, instead of returning an error
+func (d *Decoder) SetRawStructures(raw bool) {
+	d.raw = raw
+}
+
 // Unmarshal is used to marshal an object to the bolt interface encoded bytes
 func Unmarshal(b []byte) (interface{}, error) {
 	return NewDecoder(bytes.NewBuffer(b)).Decode()
 }
 
 // Read out the object bytes to decode
-func (d Decoder) read() (*bytes.Buffer, error) {
-	output := &bytes.Buffer{}
+// The buffer is reused by the next read.
+func (d *Decoder) read() (*bytes.Buffer, error) {
+	output := d.buf
+	output.Reset()
 	for {
 		lengthBytes := make([]byte, 2)
 		if numRead, err := io.ReadFull(d.r, lengthBytes); numRead != 2 {
@@ -48,6 +69,11 @@ func (d Decoder) read() (*bytes.Buffer, error) {
 		// Chunk header contains length of current message
 		messageLen := binary.BigEndian.Uint16(lengthBytes)
 		if messageLen == 0 {
+			if output.Len() == 0 {
+				// A 0 length chunk before any data is a NOOP, which newer
+				// servers send as a keepalive between messages
+				continue
+			}
 			// If the length is 0, the chunk is done.
 			return output, nil
 		}
@@ -66,7 +92,7 @@ func (d Decoder) read() (*bytes.Buffer, error) {
 	}
 }
 
-func (d Decoder) readData(messageLen uint16) ([]byte, error) {
+func (d *Decoder) readData(messageLen uint16) ([]byte, error) {
 	output := make([]byte, messageLen)
 	var totalRead uint16
 	for totalRead < messageLen {
@@ -89,7 +115,7 @@ func (d Decoder) readData(messageLen uint16) ([]byte, error) {
 }
 
 // Decode decodes the stream to an object
-func (d Decoder) Decode() (interface{}, error) {
+func (d *Decoder) Decode() (interface{}, error) {
 	data, err := d.read()
 	if err != nil {
 		return nil, err
@@ -98,7 +124,7 @@ func (d Decoder) Decode() (interface{}, error) {
 	return d.decode(data)
 }
 
-func (d Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
+func (d *Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
 
 	marker, err := buffer.ReadByte()
 	if err != nil {
@@ -159,19 +185,19 @@ func (d Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
 		}
 		return string(buffer.Next(size)), nil
 	case marker == String8Marker:
-		var size int8
+		var size uint8
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading string size")
 		}
 		return string(buffer.Next(int(size))), nil
 	case marker == String16Marker:
-		var size int16
+		var size uint16
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading string size")
 		}
 		return string(buffer.Next(int(size))), nil
 	case marker == String32Marker:
-		var size int32
+		var size uint32
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading string size")
 		}
@@ -182,19 +208,19 @@ func (d Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
 		size := int(marker) - int(TinySliceMarker)
 		return d.decodeSlice(buffer, size)
 	case marker == Slice8Marker:
-		var size int8
+		var size uint8
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading slice size")
 		}
 		return d.decodeSlice(buffer, int(size))
 	case marker == Slice16Marker:
-		var size int16
+		var size uint16
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading slice size")
 		}
 		return d.decodeSlice(buffer, int(size))
 	case marker == Slice32Marker:
-		var size int32
+		var size uint32
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading slice size")
 		}
@@ -205,19 +231,19 @@ func (d Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
 		size := int(marker) - int(TinyMapMarker)
 		return d.decodeMap(buffer, size)
 	case marker == Map8Marker:
-		var size int8
+		var size uint8
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading map size")
 		}
 		return d.decodeMap(buffer, int(size))
 	case marker == Map16Marker:
-		var size int16
+		var size uint16
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading map size")
 		}
 		return d.decodeMap(buffer, int(size))
 	case marker == Map32Marker:
-		var size int32
+		var size uint32
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading map size")
 		}
@@ -228,13 +254,13 @@ func (d Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
 		size := int(marker) - int(TinyStructMarker)
 		return d.decodeStruct(buffer, size)
 	case marker == Struct8Marker:
-		var size int8
+		var size uint8
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading struct size")
 		}
 		return d.decodeStruct(buffer, int(size))
 	case marker == Struct16Marker:
-		var size int16
+		var size uint16
 		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading struct size")
 		}
@@ -246,7 +272,20 @@ func (d Decoder) decode(buffer *bytes.Buffer) (interface{}, error) {
 
 }
 
-func (d Decoder) decodeSlice(buffer *bytes.Buffer, size int) ([]interface{}, error) {
+// checkSize rejects a declared size larger than the rest of the message, since
+// each element takes at least a byte, so a corrupt size can't allocate more
+// than the message holds
+func checkSize(buffer *bytes.Buffer, name string, size int) error {
+	if size > buffer.Len() {
+		return errors.New("Invalid %s size %d, with %d bytes left in the message", name, size, buffer.Len())
+	}
+	return nil
+}
+
+func (d *Decoder) decodeSlice(buffer *bytes.Buffer, size int) ([]interface{}, error) {
+	if err := checkSize(buffer, "slice", size); err != nil {
+		return nil, err
+	}
 	slice := make([]interface{}, size)
 	for i := 0; i < size; i++ {
 		item, err := d.decode(buffer)
@@ -259,7 +298,10 @@ func (d Decoder) decodeSlice(buffer *bytes.Buffer, size int) ([]interface{}, err
 	return slice, nil
 }
 
-func (d Decoder) decodeMap(buffer *bytes.Buffer, size int) (map[string]interface{}, error) {
+func (d *Decoder) decodeMap(buffer *bytes.Buffer, size int) (map[string]interface{}, error) {
+	if err := checkSize(buffer, "map", size); err != nil {
+		return nil, err
+	}
 	mapp := make(map[string]interface{}, size)
 	for i := 0; i < size; i++ {
 		keyInt, err := d.decode(buffer)
@@ -281,7 +323,7 @@ func (d Decoder) decodeMap(buffer *bytes.Buffer, size int) (map[string]interface
 	return mapp, nil
 }
 
-func (d Decoder) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, error) {
+func (d *Decoder) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, error) {
 
 	signature, err := buffer.ReadByte()
 	if err != nil {
@@ -297,6 +339,24 @@ func (d Decoder) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, erro
 		return d.decodePath(buffer)
 	case graph.UnboundRelationshipSignature:
 		return d.decodeUnboundRelationship(buffer)
+	case graph.Point2DSignature:
+		return d.decodePoint2D(buffer)
+	case graph.Point3DSignature:
+		return d.decodePoint3D(buffer)
+	case temporal.DateSignature:
+		return d.decodeDate(buffer)
+	case temporal.TimeSignature:
+		return d.decodeTime(buffer)
+	case temporal.LocalTimeSignature:
+		return d.decodeLocalTime(buffer)
+	case temporal.DateTimeSignature:
+		return d.decodeDateTime(buffer)
+	case temporal.DateTimeZoneIDSignature:
+		return d.decodeDateTimeZoneID(buffer)
+	case temporal.LocalDateTimeSignature:
+		return d.decodeLocalDateTime(buffer)
+	case temporal.DurationSignature:
+		return d.decodeDuration(buffer)
 	case messages.RecordMessageSignature:
 		return d.decodeRecordMessage(buffer)
 	case messages.FailureMessageSignature:
@@ -304,6 +364,9 @@ func (d Decoder) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, erro
 	case messages.IgnoredMessageSignature:
 		return d.decodeIgnoredMessage(buffer)
 	case messages.SuccessMessageSignature:
+		if d.lazyKeys != nil {
+			return d.decodeLazySuccessMessage(buffer)
+		}
 		return d.decodeSuccessMessage(buffer)
 	case messages.AckFailureMessageSignature:
 		return d.decodeAckFailureMessage(buffer)
@@ -314,65 +377,173 @@ func (d Decoder) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, erro
 	case messages.ResetMessageSignature:
 		return d.decodeResetMessage(buffer)
 	default:
+		if d.raw {
+			return d.decodeRaw(buffer, signature, size)
+		}
 		return nil, errors.New("Unrecognized type decoding struct with signature %x", signature)
 	}
 }
 
-func (d Decoder) decodeNode(buffer *bytes.Buffer) (graph.Node, error) {
-	node := graph.Node{}
+func (d *Decoder) decodeRaw(buffer *bytes.Buffer, signature byte, size int) (structures.Raw, error) {
+	raw := structures.Raw{Signature: signature, Fields: make([]interface{}, size)}
+	for i := range raw.Fields {
+		field, err := d.decode(buffer)
+		if err != nil {
+			return raw, errors.Wrap(err, "An error occurred decoding field %d of struct with signature %x", i, signature)
+		}
+		raw.Fields[i] = field
+	}
+	return raw, nil
+}
 
-	nodeIdentityInt, err := d.decode(buffer)
+// next reads the next n bytes, erroring if the message is too short
+func next(buffer *bytes.Buffer, n int) ([]byte, error) {
+	data := buffer.Next(n)
+	if len(data) < n {
+		return nil, errors.New("Unexpected end of message reading %d bytes", n)
+	}
+	return data, nil
+}
+
+// decodeSize reads the size of a collection given its markers. Unlike
+// decode, the size is read unsigned, as the spec declares it.
+func decodeSize(buffer *bytes.Buffer, name string, tiny, marker8, marker16, marker32 byte) (int, error) {
+	marker, err := buffer.ReadByte()
 	if err != nil {
-		return node, err
+		return 0, errors.Wrap(err, "Error reading marker")
+	}
+
+	var data []byte
+	switch marker {
+	case marker8:
+		data, err = next(buffer, 1)
+		if err == nil {
+			return int(data[0]), nil
+		}
+	case marker16:
+		data, err = next(buffer, 2)
+		if err == nil {
+			return int(binary.BigEndian.Uint16(data)), nil
+		}
+	case marker32:
+		data, err = next(buffer, 4)
+		if err == nil {
+			return int(binary.BigEndian.Uint32(data)), nil
+		}
+	default:
+		if marker >= tiny && marker <= tiny+0x0F {
+			return int(marker - tiny), nil
+		}
+		return 0, errors.New("Expected: %s, but got marker %x", name, marker)
 	}
-	node.NodeIdentity = nodeIdentityInt.(int64)
+	return 0, errors.Wrap(err, "An error occurred reading %s size", name)
+}
 
-	labelInt, err := d.decode(buffer)
+// decodeInt decodes an integer directly, without going through an interface
+func (d *Decoder) decodeInt(buffer *bytes.Buffer) (int64, error) {
+	marker, err := buffer.ReadByte()
 	if err != nil {
-		return node, err
+		return 0, errors.Wrap(err, "Error reading marker")
 	}
-	labelIntSlice, ok := labelInt.([]interface{})
-	if !ok {
-		return node, errors.New("Expected: Labels []string, but got %T %+v", labelInt, labelInt)
+
+	if int8(marker) >= -16 {
+		return int64(int8(marker)), nil
+	}
+
+	var data []byte
+	switch marker {
+	case Int8Marker:
+		if data, err = next(buffer, 1); err == nil {
+			return int64(int8(data[0])), nil
+		}
+	case Int16Marker:
+		if data, err = next(buffer, 2); err == nil {
+			return int64(int16(binary.BigEndian.Uint16(data))), nil
+		}
+	case Int32Marker:
+		if data, err = next(buffer, 4); err == nil {
+			return int64(int32(binary.BigEndian.Uint32(data))), nil
+		}
+	case Int64Marker:
+		if data, err = next(buffer, 8); err == nil {
+			return int64(binary.BigEndian.Uint64(data)), nil
+		}
+	default:
+		return 0, errors.New("Expected: Identity int64, but got marker %x", marker)
 	}
-	node.Labels, err = sliceInterfaceToString(labelIntSlice)
+	return 0, err
+}
+
+// decodeLabels decodes node labels straight into a string slice of the declared length
+func (d *Decoder) decodeLabels(buffer *bytes.Buffer) ([]string, error) {
+	size, err := decodeSize(buffer, "Labels []string", TinySliceMarker, Slice8Marker, Slice16Marker, Slice32Marker)
 	if err != nil {
-		return node, err
+		return nil, err
 	}
 
-	propertiesInt, err := d.decode(buffer)
+	if err := checkSize(buffer, "labels", size); err != nil {
+		return nil, err
+	}
+	labels := make([]string, size)
+	for i := range labels {
+		labelInt, err := d.decode(buffer)
+		if err != nil {
+			return nil, err
+		}
+		label, ok := labelInt.(string)
+		if !ok {
+			return nil, errors.New("Expected string value. Got %T %+v", labelInt, labelInt)
+		}
+		labels[i] = label
+	}
+	return labels, nil
+}
+
+// decodeProperties decodes a property map sized to its declared length
+func (d *Decoder) decodeProperties(buffer *bytes.Buffer) (map[string]interface{}, error) {
+	size, err := decodeSize(buffer, "Properties map[string]interface{}", TinyMapMarker, Map8Marker, Map16Marker, Map32Marker)
 	if err != nil {
-		return node, err
+		return nil, err
 	}
-	node.Properties, ok = propertiesInt.(map[string]interface{})
-	if !ok {
-		return node, errors.New("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
+	return d.decodeMap(buffer, size)
+}
+
+func (d *Decoder) decodeNode(buffer *bytes.Buffer) (graph.Node, error) {
+	node := graph.Node{}
+
+	var err error
+	node.NodeIdentity, err = d.decodeInt(buffer)
+	if err != nil {
+		return node, err
 	}
 
-	return node, nil
+	node.Labels, err = d.decodeLabels(buffer)
+	if err != nil {
+		return node, err
+	}
 
+	node.Properties, err = d.decodeProperties(buffer)
+	return node, err
 }
 
-func (d Decoder) decodeRelationship(buffer *bytes.Buffer) (graph.Relationship, error) {
+func (d *Decoder) decodeRelationship(buffer *bytes.Buffer) (graph.Relationship, error) {
 	rel := graph.Relationship{}
 
-	relIdentityInt, err := d.decode(buffer)
+	var err error
+	rel.RelIdentity, err = d.decodeInt(buffer)
 	if err != nil {
 		return rel, err
 	}
-	rel.RelIdentity = relIdentityInt.(int64)
 
-	startNodeIdentityInt, err := d.decode(buffer)
+	rel.StartNodeIdentity, err = d.decodeInt(buffer)
 	if err != nil {
 		return rel, err
 	}
-	rel.StartNodeIdentity = startNodeIdentityInt.(int64)
 
-	endNodeIdentityInt, err := d.decode(buffer)
+	rel.EndNodeIdentity, err = d.decodeInt(buffer)
 	if err != nil {
 		return rel, err
 	}
-	rel.EndNodeIdentity = endNodeIdentityInt.(int64)
 
 	var ok bool
 	typeInt, err := d.decode(buffer)
@@ -384,19 +555,11 @@ func (d Decoder) decodeRelationship(buffer *bytes.Buffer) (graph.Relationship, e
 		return rel, errors.New("Expected: Type string, but got %T %+v", typeInt, typeInt)
 	}
 
-	propertiesInt, err := d.decode(buffer)
-	if err != nil {
-		return rel, err
-	}
-	rel.Properties, ok = propertiesInt.(map[string]interface{})
-	if !ok {
-		return rel, errors.New("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
-	}
-
-	return rel, nil
+	rel.Properties, err = d.decodeProperties(buffer)
+	return rel, err
 }
 
-func (d Decoder) decodePath(buffer *bytes.Buffer) (graph.Path, error) {
+func (d *Decoder) decodePath(buffer *bytes.Buffer) (graph.Path, error) {
 	path := graph.Path{}
 
 	nodesInt, err := d.decode(buffer)
@@ -438,14 +601,14 @@ func (d Decoder) decodePath(buffer *bytes.Buffer) (graph.Path, error) {
 	return path, err
 }
 
-func (d Decoder) decodeUnboundRelationship(buffer *bytes.Buffer) (graph.UnboundRelationship, error) {
+func (d *Decoder) decodeUnboundRelationship(buffer *bytes.Buffer) (graph.UnboundRelationship, error) {
 	rel := graph.UnboundRelationship{}
 
-	relIdentityInt, err := d.decode(buffer)
+	var err error
+	rel.RelIdentity, err = d.decodeInt(buffer)
 	if err != nil {
 		return rel, err
 	}
-	rel.RelIdentity = relIdentityInt.(int64)
 
 	var ok bool
 	typeInt, err := d.decode(buffer)
@@ -457,19 +620,11 @@ func (d Decoder) decodeUnboundRelationship(buffer *bytes.Buffer) (graph.UnboundR
 		return rel, errors.New("Expected: Type string, but got %T %+v", typeInt, typeInt)
 	}
 
-	propertiesInt, err := d.decode(buffer)
-	if err != nil {
-		return rel, err
-	}
-	rel.Properties, ok = propertiesInt.(map[string]interface{})
-	if !ok {
-		return rel, errors.New("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
-	}
-
-	return rel, nil
+	rel.Properties, err = d.decodeProperties(buffer)
+	return rel, err
 }
 
-func (d Decoder) decodeRecordMessage(buffer *bytes.Buffer) (messages.RecordMessage, error) {
+func (d *Decoder) decodeRecordMessage(buffer *bytes.Buffer) (messages.RecordMessage, error) {
 	fieldsInt, err := d.decode(buffer)
 	if err != nil {
 		return messages.RecordMessage{}, err
@@ -482,7 +637,7 @@ func (d Decoder) decodeRecordMessage(buffer *bytes.Buffer) (messages.RecordMessa
 	return messages.NewRecordMessage(fields), nil
 }
 
-func (d Decoder) decodeFailureMessage(buffer *bytes.Buffer) (messages.FailureMessage, error) {
+func (d *Decoder) decodeFailureMessage(buffer *bytes.Buffer) (messages.FailureMessage, error) {
 	metadataInt, err := d.decode(buffer)
 	if err != nil {
 		return messages.FailureMessage{}, err
@@ -495,11 +650,11 @@ func (d Decoder) decodeFailureMessage(buffer *bytes.Buffer) (messages.FailureMes
 	return messages.NewFailureMessage(metadata), nil
 }
 
-func (d Decoder) decodeIgnoredMessage(buffer *bytes.Buffer) (messages.IgnoredMessage, error) {
+func (d *Decoder) decodeIgnoredMessage(buffer *bytes.Buffer) (messages.IgnoredMessage, error) {
 	return messages.NewIgnoredMessage(), nil
 }
 
-func (d Decoder) decodeSuccessMessage(buffer *bytes.Buffer) (messages.SuccessMessage, error) {
+func (d *Decoder) decodeSuccessMessage(buffer *bytes.Buffer) (messages.SuccessMessage, error) {
 	metadataInt, err := d.decode(buffer)
 	if err != nil {
 		return messages.SuccessMessage{}, err
@@ -512,18 +667,18 @@ func (d Decoder) decodeSuccessMessage(buffer *bytes.Buffer) (messages.SuccessMes
 	return messages.NewSuccessMessage(metadata), nil
 }
 
-func (d Decoder) decodeAckFailureMessage(buffer *bytes.Buffer) (messages.AckFailureMessage, error) {
+func (d *Decoder) decodeAckFailureMessage(buffer *bytes.Buffer) (messages.AckFailureMessage, error) {
 	return messages.NewAckFailureMessage(), nil
 }
 
-func (d Decoder) decodeDiscardAllMessage(buffer *bytes.Buffer) (messages.DiscardAllMessage, error) {
+func (d *Decoder) decodeDiscardAllMessage(buffer *bytes.Buffer) (messages.DiscardAllMessage, error) {
 	return messages.NewDiscardAllMessage(), nil
 }
 
-func (d Decoder) decodePullAllMessage(buffer *bytes.Buffer) (messages.PullAllMessage, error) {
+func (d *Decoder) decodePullAllMessage(buffer *bytes.Buffer) (messages.PullAllMessage, error) {
 	return messages.NewPullAllMessage(), nil
 }
 
-func (d Decoder) decodeResetMessage(buffer *bytes.Buffer) (messages.ResetMessage, error) {
+func (d *Decoder) decodeResetMessage(buffer *bytes.Buffer) (messages.ResetMessage, error) {
 	return messages.NewResetMessage(), nil
 }
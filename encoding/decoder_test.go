@@ -0,0 +1,281 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+func TestDecodeNoop(t *testing.T) {
+	// Two NOOP chunks, then a message containing `true`
+	output, err := Unmarshal([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x01, TrueMarker, 0x00, 0x00})
+	if err != nil {
+		t.Fatalf("Error decoding message after NOOP chunks: %s", err)
+	}
+	if output != true {
+		t.Fatalf("Unexpected output decoding message after NOOP chunks: %#v", output)
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	decoder := NewDecoder(bytes.NewBuffer([]byte{0x00, 0x01, TrueMarker, 0x00, 0x00}))
+	if output, err := decoder.Decode(); err != nil || output != true {
+		t.Fatalf("Unexpected decode output: %#v %v", output, err)
+	}
+
+	decoder.Reset(bytes.NewBuffer([]byte{0x00, 0x01, FalseMarker, 0x00, 0x00}))
+	if output, err := decoder.Decode(); err != nil || output != false {
+		t.Fatalf("Unexpected decode output after reset: %#v %v", output, err)
+	}
+}
+
+func TestDecodeUnsignedSizes(t *testing.T) {
+	// Sizes with the high bit set, which are read wrongly as signed
+	for _, size := range []int{200, 40000} {
+		str := strings.Repeat("a", size)
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = int64(1)
+		}
+
+		for _, input := range []interface{}{str, list} {
+			encoded, err := Marshal(input)
+			if err != nil {
+				t.Fatalf("Error encoding value of size %d: %s", size, err)
+			}
+			output, err := Unmarshal(encoded)
+			if err != nil {
+				t.Fatalf("Error decoding value of size %d: %s", size, err)
+			}
+			if !reflect.DeepEqual(output, input) {
+				t.Fatalf("Unexpected output decoding value of size %d", size)
+			}
+		}
+	}
+}
+
+func TestDecodeCorruptSizes(t *testing.T) {
+	// Sizes of about 4e9 elements, in messages holding a single byte after them
+	for _, message := range [][]byte{
+		{0x00, 0x06, Slice32Marker, 0xFF, 0xFF, 0xFF, 0xF0, 0x01, 0x00, 0x00},
+		{0x00, 0x06, Map32Marker, 0xFF, 0xFF, 0xFF, 0xF0, 0x01, 0x00, 0x00},
+		// A node with corrupt labels
+		{0x00, 0x09, 0xB3, 0x4E, 0x01, Slice32Marker, 0xFF, 0xFF, 0xFF, 0xF0, 0xA0, 0x00, 0x00},
+	} {
+		if _, err := NewDecoder(bytes.NewBuffer(message)).Decode(); err == nil || !strings.Contains(err.Error(), "Invalid") {
+			t.Fatalf("Expected an error decoding a size past the end of the message %x. Got: %v", message, err)
+		}
+	}
+}
+
+func TestDecodeRawStructure(t *testing.T) {
+	// A struct with unknown signature 0x7A and fields 1, "a"
+	message := []byte{0x00, 0x05, 0xB2, 0x7A, 0x01, 0x81, 'a', 0x00, 0x00}
+
+	if _, err := NewDecoder(bytes.NewBuffer(message)).Decode(); err == nil {
+		t.Fatal("Expected error decoding unknown structure")
+	}
+
+	decoder := NewDecoder(bytes.NewBuffer(message))
+	decoder.SetRawStructures(true)
+	output, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Error decoding unknown structure: %s", err)
+	}
+	expected := structures.Raw{Signature: 0x7A, Fields: []interface{}{int64(1), "a"}}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("Unexpected output decoding unknown structure: %#v", output)
+	}
+}
+
+func TestDecodeGraphStructures(t *testing.T) {
+	// Over 127 properties, so the map size has the high bit set
+	props := map[string]interface{}{}
+	for i := 0; i < 200; i++ {
+		props[fmt.Sprintf("prop%d", i)] = int64(i)
+	}
+	for _, expected := range []interface{}{
+		graph.Node{NodeIdentity: math.MaxInt64, Labels: []string{"A", "B"}, Properties: props},
+		graph.Node{NodeIdentity: -100, Labels: []string{}, Properties: map[string]interface{}{}},
+		graph.Relationship{RelIdentity: 70000, StartNodeIdentity: 1, EndNodeIdentity: 300, Type: "KNOWS", Properties: props},
+	} {
+		buf := &bytes.Buffer{}
+		if err := NewEncoder(buf, math.MaxUint16).Encode(expected); err != nil {
+			t.Fatalf("Error encoding %T: %s", expected, err)
+		}
+		output, err := NewDecoder(buf).Decode()
+		if err != nil {
+			t.Fatalf("Error decoding %T: %s", expected, err)
+		}
+		if !reflect.DeepEqual(output, expected) {
+			t.Fatalf("Unexpected output decoding %T: %#v", expected, output)
+		}
+	}
+
+	// A node with a string identity
+	message := []byte{0x00, 0x06, 0xB3, 0x4E, 0x81, 'a', 0x90, 0xA0, 0x00, 0x00}
+	if _, err := NewDecoder(bytes.NewBuffer(message)).Decode(); err == nil {
+		t.Fatal("Expected error decoding node with a string identity")
+	}
+}
+
+func TestDecodeLazyMetadata(t *testing.T) {
+	plan := map[string]interface{}{
+		"operatorType": "ProduceResults",
+		"args":         map[string]interface{}{"EstimatedRows": 1.5, "Rows": int64(100000), "Small": int64(-3)},
+		"children":     []interface{}{map[string]interface{}{"operatorType": "AllNodesScan", "identifiers": []interface{}{"n"}}},
+		"long":         string(make([]byte, 300)),
+		"flags":        []interface{}{true, false, nil, int64(1000), int64(math.MaxInt64)},
+		"node":         graph.Node{NodeIdentity: 1, Labels: []string{"A"}, Properties: map[string]interface{}{}},
+	}
+	metadata := map[string]interface{}{"type": "r", "plan": plan}
+
+	encode := func(v interface{}) []byte {
+		buf := &bytes.Buffer{}
+		encoder := NewEncoder(buf, math.MaxUint16)
+		encoder.SetSortedMaps(true)
+		if err := encoder.Encode(v); err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+		return buf.Bytes()
+	}
+	encoded := encode(messages.NewSuccessMessage(metadata))
+
+	decoder := NewDecoder(bytes.NewBuffer(encoded))
+	decoder.SetLazyMetadata(DefaultLazyMetadata...)
+	output, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Error decoding lazy metadata: %s", err)
+	}
+	success := output.(messages.SuccessMessage)
+	if success.Metadata["type"] != "r" {
+		t.Fatalf("Expected other metadata to be decoded. Got: %#v", success.Metadata)
+	}
+	lazy, ok := success.Metadata["plan"].(*LazyValue)
+	if !ok {
+		t.Fatalf("Expected the plan to be lazy. Got: %T", success.Metadata["plan"])
+	}
+
+	if reencoded := encode(success); !bytes.Equal(reencoded, encoded) {
+		t.Fatalf("Expected a lazy value to encode to its original bytes.\nExpected: %x\nGot: %x", encoded, reencoded)
+	}
+
+	value, err := lazy.Value()
+	if err != nil {
+		t.Fatalf("Error decoding lazy value: %s", err)
+	}
+	if !reflect.DeepEqual(value, plan) {
+		t.Fatalf("Unexpected lazy value.\nExpected: %#v\nGot: %#v", plan, value)
+	}
+	if Resolve(success.Metadata["plan"]) == nil || Resolve("r") != "r" {
+		t.Fatal("Expected Resolve to decode lazy values and pass others through")
+	}
+}
+
+func TestDecodeTemporalTypes(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone database not available: %s", err)
+	}
+
+	offset := time.Date(2018, time.March, 4, 10, 30, 15, 500, time.FixedZone("", 3600))
+	zoned := time.Date(2018, time.July, 4, 10, 30, 15, 0, berlin)
+	tests := []interface{}{
+		temporal.NewDate(2018, time.March, 4),
+		temporal.NewDate(1960, time.December, 31),
+		temporal.NewTime(10, 30, 15, 500, -7200),
+		temporal.NewLocalTime(23, 59, 59, 999999999),
+		temporal.NewLocalDateTime(offset),
+		temporal.Duration{Months: 14, Days: 2, Seconds: 3, Nanos: 500},
+	}
+	for _, test := range tests {
+		encoded, err := Marshal(test)
+		if err != nil {
+			t.Fatalf("Error encoding %T: %s", test, err)
+		}
+		decoded, err := Unmarshal(encoded)
+		if err != nil {
+			t.Fatalf("Error decoding %T: %s", test, err)
+		}
+		if !reflect.DeepEqual(decoded, test) {
+			t.Fatalf("Unexpected decoded %T.\nExpected: %#v\nGot: %#v", test, test, decoded)
+		}
+	}
+
+	for _, test := range []time.Time{offset, zoned} {
+		encoded, err := Marshal(test)
+		if err != nil {
+			t.Fatalf("Error encoding time.Time: %s", err)
+		}
+		decoded, err := Unmarshal(encoded)
+		if err != nil {
+			t.Fatalf("Error decoding DateTime: %s", err)
+		}
+		decodedTime, ok := decoded.(time.Time)
+		if !ok || !decodedTime.Equal(test) || decodedTime.Format(time.RFC3339Nano) != test.Format(time.RFC3339Nano) {
+			t.Fatalf("Expected a time.Time with the same time and offset. Expected: %s Got: %#v", test, decoded)
+		}
+	}
+	if zonedEncoded, _ := Marshal(zoned); zonedEncoded[3] != temporal.DateTimeZoneIDSignature {
+		t.Fatalf("Expected a time in a named location to be sent with its zone id. Got: %x", zonedEncoded)
+	}
+
+	// A DateTime of 2018-03-04T10:30:15+01:00 sent with local seconds
+	message := []byte{0x00, 0x0B, 0xB3, temporal.DateTimeSignature, Int32Marker, 0x5A, 0x9B, 0xCA, 0xB7, 0x00, Int16Marker, 0x0E, 0x10, 0x00, 0x00}
+	decoded, err := Unmarshal(message)
+	if err != nil {
+		t.Fatalf("Error decoding DateTime: %s", err)
+	}
+	if expected := time.Date(2018, time.March, 4, 10, 30, 15, 0, time.FixedZone("", 3600)); !decoded.(time.Time).Equal(expected) {
+		t.Fatalf("Unexpected DateTime. Expected: %s Got: %s", expected, decoded)
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	tests := map[string]temporal.Duration{
+		"P14M2DT3.0000005S": {Months: 14, Days: 2, Seconds: 3, Nanos: 500},
+		"PT0S":              {},
+		"P1D":               {Days: 1},
+		"PT-0.5S":           {Seconds: -1, Nanos: 500000000},
+	}
+	for expected, duration := range tests {
+		if duration.String() != expected {
+			t.Fatalf("Unexpected duration string. Expected: %s Got: %s", expected, duration.String())
+		}
+	}
+}
+
+func TestDecodeSpatialTypes(t *testing.T) {
+	tests := []interface{}{
+		graph.Point2D{SRID: graph.SRIDCartesian, X: 1, Y: 2.5},
+		graph.Point3D{SRID: graph.SRIDWGS843D, X: 12.99, Y: 55.61, Z: -4},
+	}
+	for _, test := range tests {
+		encoded, err := Marshal(test)
+		if err != nil {
+			t.Fatalf("Error encoding %T: %s", test, err)
+		}
+		decoded, err := Unmarshal(encoded)
+		if err != nil {
+			t.Fatalf("Error decoding %T: %s", test, err)
+		}
+		if !reflect.DeepEqual(decoded, test) {
+			t.Fatalf("Unexpected decoded %T.\nExpected: %#v\nGot: %#v", test, test, decoded)
+		}
+	}
+
+	// A Point2D with an integer coordinate
+	message := []byte{0x00, 0x05, 0xB3, graph.Point2DSignature, 0x01, 0x02, 0x03, 0x00, 0x00}
+	if _, err := Unmarshal(message); err == nil {
+		t.Fatal("Expected an error decoding a point with an integer coordinate")
+	}
+}
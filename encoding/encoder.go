@@ -1,15 +1,22 @@
 package encoding
 
 import (
+	"database/sql"
 	"encoding/binary"
 	"io"
+	"io/ioutil"
 	"math"
 	"reflect"
+	"sort"
+	"sync"
+	"time"
 
 	"bytes"
+	"fmt"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
 )
 
 const (
@@ -71,8 +78,16 @@ const (
 var (
 	// EndMessage is the data to send to end a message
 	EndMessage = []byte{byte(0x00), byte(0x00)}
+
+	// smallMessages holds the buffers for writing small messages
+	smallMessages = sync.Pool{New: func() interface{} { return &bytes.Buffer{} }}
 )
 
+// maxSmallMessage is the largest message written in a single write without
+// batching. Bigger messages go in their own chunks, so they aren't copied
+// again just to save a write.
+const maxSmallMessage = 4096
+
 // Encoder encodes objects of different types to the given stream.
 // Attempts to support all builtin golang types, when it can be confidently
 // mapped to a data type from: http://alpha.neohq.net/docs/server-manual/bolt-serialization.html#bolt-packstream-structures
@@ -81,21 +96,166 @@ var (
 // Maps and Slices are a special case, where only
 // map[string]interface{} and []interface{} are supported.
 // The interface for maps and slices may be more permissive in the future.
+//
+// An Encoder can be reused for many messages, and pointed at a new stream
+// with Reset.  Encoder objects ARE NOT THREAD SAFE.
 type Encoder struct {
 	w         io.Writer
 	buf       *bytes.Buffer
 	chunkSize uint16
+	maxDepth  int
+	maxSize   int
+	// out batches the chunks written to w, when batch is set
+	out   *bytes.Buffer
+	batch int
+	// depth, size and path track the position in the message being encoded
+	depth int
+	size  int
+	path  []pathSegment
+	// scratch holds a marker and a fixed width value while it's written
+	scratch [9]byte
+	// sortMaps encodes map keys in sorted order
+	sortMaps bool
+	// chunked is set once a chunk of the current message has been written
+	chunked bool
+}
+
+// LimitError is returned when a value exceeds the limits configured on the encoder.
+// Param is the key path of the value being encoded when the limit was hit.
+// For a RUN message, the first element of the path is the parameter name.
+//
+// Encode returns it as it is, however deep in the message the limit was hit.
+// Queries run on a connection wrap it with the query, so get it from their
+// error with errors.InnerMost.
+type LimitError struct {
+	Param string
+	Limit string
+	Max   int
+}
+
+// Error implements the error interface
+func (e *LimitError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("Value exceeds the maximum encoding %s of %d", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("Parameter %s exceeds the maximum encoding %s of %d", e.Param, e.Limit, e.Max)
 }
 
 // NewEncoder Creates a new Encoder object
-func NewEncoder(w io.Writer, chunkSize uint16) Encoder {
-	return Encoder{
+func NewEncoder(w io.Writer, chunkSize uint16) *Encoder {
+	return &Encoder{
 		w:         w,
 		buf:       &bytes.Buffer{},
 		chunkSize: chunkSize,
 	}
 }
 
+// Reset points the encoder at a new stream, discarding anything buffered
+// for the previous one.  The chunk size and limits are kept.
+func (e *Encoder) Reset(w io.Writer) {
+	e.w = w
+	e.buf.Reset()
+	if e.out != nil {
+		e.out.Reset()
+	}
+	e.depth = 0
+	e.size = 0
+	e.path = e.path[:0]
+}
+
+// SetWriteBatch makes the encoder batch the chunks it writes, writing to
+// the stream once at least batch bytes are ready, and at the end of each
+// message.  A message smaller than batch is written in a single write.
+// 0 writes each chunk as it's ready.
+func (e *Encoder) SetWriteBatch(batch int) {
+	e.batch = batch
+	if e.out == nil {
+		e.out = &bytes.Buffer{}
+	}
+}
+
+// emit writes encoded bytes to the stream, batching them if a write batch is set
+func (e *Encoder) emit(p []byte) error {
+	if e.batch <= 0 {
+		_, err := e.w.Write(p)
+		return err
+	}
+
+	e.out.Write(p)
+	if e.out.Len() >= e.batch {
+		return e.emitBatch()
+	}
+	return nil
+}
+
+// emitBatch writes the batched bytes to the stream
+func (e *Encoder) emitBatch() error {
+	if e.out == nil || e.out.Len() == 0 {
+		return nil
+	}
+	_, err := e.out.WriteTo(e.w)
+	e.out.Reset()
+	return err
+}
+
+// SetSortedMaps makes the encoder write map entries in sorted key order,
+// so the same message always encodes to the same bytes.  By default maps
+// are written in Go's map iteration order, which is random.
+func (e *Encoder) SetSortedMaps(sorted bool) {
+	e.sortMaps = sorted
+}
+
+// SetMaxDepth sets the maximum nesting depth of maps and slices the encoder
+// will encode. The parameters of a message are at depth 1. 0 means no limit.
+// With a limit, each message is buffered whole before it's written.
+func (e *Encoder) SetMaxDepth(maxDepth int) {
+	e.maxDepth = maxDepth
+}
+
+// SetMaxSize sets the maximum number of bytes the encoder will encode for a single
+// message, not including chunk headers. 0 means no limit.
+// With a limit, each message is buffered whole before it's written.
+func (e *Encoder) SetMaxSize(maxSize int) {
+	e.maxSize = maxSize
+}
+
+// Underlying gets the value that v is encoded as, for pointers and the
+// sql.Null types ORMs commonly hold.  A pointer gets the value it points to,
+// and a sql.Null type gets its value when valid.  Both get nil when null.
+// Any other value is returned as it is.
+func Underlying(v interface{}) interface{} {
+	switch val := v.(type) {
+	case sql.NullString:
+		if val.Valid {
+			return val.String
+		}
+		return nil
+	case sql.NullInt64:
+		if val.Valid {
+			return val.Int64
+		}
+		return nil
+	case sql.NullFloat64:
+		if val.Valid {
+			return val.Float64
+		}
+		return nil
+	case sql.NullBool:
+		if val.Valid {
+			return val.Bool
+		}
+		return nil
+	}
+
+	if value := reflect.ValueOf(v); value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		return Underlying(value.Elem().Interface())
+	}
+	return v
+}
+
 // Marshal is used to marshal an object to the bolt interface encoded bytes
 func Marshal(v interface{}) ([]byte, error) {
 	x := &bytes.Buffer{}
@@ -103,8 +263,24 @@ func Marshal(v interface{}) ([]byte, error) {
 	return x.Bytes(), err
 }
 
+// EstimateSize returns the number of bytes v encodes to, not including
+// chunk headers. Useful for splitting up batches of parameters before
+// they are sent, so they stay below the server's limits.
+func EstimateSize(v interface{}) (int, error) {
+	e := NewEncoder(ioutil.Discard, math.MaxUint16)
+	if err := e.Encode(v); err != nil {
+		return 0, err
+	}
+	return e.size, nil
+}
+
 // write writes to the writer.  Buffers the writes using chunkSize.
-func (e Encoder) Write(p []byte) (n int, err error) {
+func (e *Encoder) Write(p []byte) (n int, err error) {
+
+	e.size += len(p)
+	if e.maxSize > 0 && e.size > e.maxSize {
+		return 0, e.limitError("size", e.maxSize)
+	}
 
 	n, err = e.buf.Write(p)
 	if err != nil {
@@ -112,47 +288,119 @@ func (e Encoder) Write(p []byte) (n int, err error) {
 		return n, err
 	}
 
-	length := e.buf.Len()
-	for length >= int(e.chunkSize) {
-		if err := binary.Write(e.w, binary.BigEndian, e.chunkSize); err != nil {
-			return 0, errors.Wrap(err, "An error occured writing chunksize")
-		}
+	// With limits, the message is held until it's all been checked,
+	// so a message over the limits isn't partly sent
+	if e.limited() {
+		return n, nil
+	}
+	if err := e.emitChunks(); err != nil {
+		return 0, err
+	}
 
-		numWritten, err := e.w.Write(e.buf.Next(int(e.chunkSize)))
-		if err != nil {
-			err = errors.Wrap(err, "An error occured writing a chunk")
+	return n, nil
+}
+
+// emitChunks writes out each full chunk in the buffer
+func (e *Encoder) emitChunks() error {
+	for e.buf.Len() >= int(e.chunkSize) {
+		e.chunked = true
+		if err := e.emit(chunkHeader(e.chunkSize)); err != nil {
+			return errors.Wrap(err, "An error occured writing chunksize")
 		}
 
-		return numWritten, err
+		if err := e.emit(e.buf.Next(int(e.chunkSize))); err != nil {
+			return errors.Wrap(err, "An error occured writing a chunk")
+		}
 	}
+	return nil
+}
 
-	return n, nil
+// limited checks whether the encoder has a depth or size limit set
+func (e *Encoder) limited() bool {
+	return e.maxDepth > 0 || e.maxSize > 0
+}
+
+// Partial returns whether part of the last message that failed to encode
+// may have been written to the stream, leaving it in a bad state.  It's
+// never the case for a message over the limits.
+func (e *Encoder) Partial() bool {
+	return e.chunked
+}
+
+// chunkHeader gets the header for a chunk of the given length
+func chunkHeader(length uint16) []byte {
+	header := make([]byte, 2)
+	binary.BigEndian.PutUint16(header, length)
+	return header
 }
 
 // flush finishes the encoding stream by flushing it to the writer
-func (e Encoder) flush() error {
+func (e *Encoder) flush() error {
+	if !e.chunked && e.batch <= 0 && e.buf.Len() <= maxSmallMessage && e.buf.Len() <= int(e.chunkSize) {
+		return e.flushSmall()
+	}
+
+	// Full chunks are held back when the encoder has limits
+	if err := e.emitChunks(); err != nil {
+		return err
+	}
+
 	length := e.buf.Len()
 	if length > 0 {
-		if err := binary.Write(e.w, binary.BigEndian, uint16(length)); err != nil {
+		if err := e.emit(chunkHeader(uint16(length))); err != nil {
 			return errors.Wrap(err, "An error occured writing length bytes during flush")
 		}
 
-		if _, err := e.buf.WriteTo(e.w); err != nil {
+		if err := e.emit(e.buf.Bytes()); err != nil {
 			return errors.Wrap(err, "An error occured writing message bytes during flush")
 		}
 	}
+	e.buf.Reset()
 
-	_, err := e.w.Write(EndMessage)
-	if err != nil {
+	if err := e.emit(EndMessage); err != nil {
 		return errors.Wrap(err, "An error occurred ending encoding message")
 	}
+
+	if err := e.emitBatch(); err != nil {
+		return errors.Wrap(err, "An error occurred writing message")
+	}
+
+	return nil
+}
+
+// flushSmall writes a message that fits in a single chunk, with its
+// header and end marker, in one write
+func (e *Encoder) flushSmall() error {
+	length := e.buf.Len()
+	message := smallMessages.Get().(*bytes.Buffer)
+	defer smallMessages.Put(message)
+
+	message.Reset()
+	if length > 0 {
+		message.Write([]byte{byte(length >> 8), byte(length)})
+		message.Write(e.buf.Bytes())
+	}
+	message.Write(EndMessage)
 	e.buf.Reset()
 
+	if _, err := e.w.Write(message.Bytes()); err != nil {
+		return errors.Wrap(err, "An error occurred writing message")
+	}
 	return nil
 }
 
 // Encode encodes an object to the stream
-func (e Encoder) Encode(iVal interface{}) error {
+func (e *Encoder) Encode(iVal interface{}) error {
+
+	// Drop anything left over from a message that failed to encode
+	e.buf.Reset()
+	if e.out != nil {
+		e.out.Reset()
+	}
+	e.depth = 0
+	e.size = 0
+	e.path = e.path[:0]
+	e.chunked = false
 
 	err := e.encode(iVal)
 	if err != nil {
@@ -164,7 +412,7 @@ func (e Encoder) Encode(iVal interface{}) error {
 }
 
 // Encode encodes an object to the stream
-func (e Encoder) encode(iVal interface{}) error {
+func (e *Encoder) encode(iVal interface{}) error {
 
 	var err error
 	switch val := iVal.(type) {
@@ -207,7 +455,29 @@ func (e Encoder) encode(iVal interface{}) error {
 		err = e.encodeMap(val)
 	case structures.Structure:
 		err = e.encodeStructure(val)
+	case structures.Raw:
+		err = e.encodeStructure(rawStructure{val})
+	case *structures.Raw:
+		if val == nil {
+			return e.encodeNil()
+		}
+		err = e.encodeStructure(rawStructure{*val})
+	case time.Time:
+		// Sent as a DateTime, which needs Bolt v2
+		err = e.encodeStructure(temporal.DateTime{Time: val})
+	case *LazyValue:
+		if val == nil {
+			return e.encodeNil()
+		}
+		_, err = e.Write(val.data)
+	case sql.NullString, sql.NullInt64, sql.NullFloat64, sql.NullBool:
+		err = e.encode(Underlying(val))
 	default:
+		// pointers are encoded as the value they point to, or null
+		if reflect.TypeOf(iVal).Kind() == reflect.Ptr {
+			return e.encode(Underlying(iVal))
+		}
+
 		// arbitrary slice types
 		if reflect.TypeOf(iVal).Kind() == reflect.Slice {
 			s := reflect.ValueOf(iVal)
@@ -224,158 +494,200 @@ func (e Encoder) encode(iVal interface{}) error {
 	return err
 }
 
-func (e Encoder) encodeNil() error {
-	_, err := e.Write([]byte{NilMarker})
-	return err
+func (e *Encoder) encodeNil() error {
+	return e.writeByte(NilMarker)
 }
 
-func (e Encoder) encodeBool(val bool) error {
-	var err error
+func (e *Encoder) encodeBool(val bool) error {
 	if val {
-		_, err = e.Write([]byte{TrueMarker})
-	} else {
-		_, err = e.Write([]byte{FalseMarker})
+		return e.writeByte(TrueMarker)
 	}
-	return err
+	return e.writeByte(FalseMarker)
 }
 
-func (e Encoder) encodeInt(val int64) error {
+func (e *Encoder) encodeInt(val int64) error {
 	var err error
 	switch {
 	case val >= math.MinInt64 && val < math.MinInt32:
 		// Write as INT_64
-		if _, err = e.Write([]byte{Int64Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, val)
+		err = e.writeMarked(Int64Marker, uint64(val), 8)
 	case val >= math.MinInt32 && val < math.MinInt16:
 		// Write as INT_32
-		if _, err = e.Write([]byte{Int32Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, int32(val))
+		err = e.writeMarked(Int32Marker, uint64(val), 4)
 	case val >= math.MinInt16 && val < math.MinInt8:
 		// Write as INT_16
-		if _, err = e.Write([]byte{Int16Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, int16(val))
+		err = e.writeMarked(Int16Marker, uint64(val), 2)
 	case val >= math.MinInt8 && val < -16:
 		// Write as INT_8
-		if _, err = e.Write([]byte{Int8Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, int8(val))
+		err = e.writeMarked(Int8Marker, uint64(val), 1)
 	case val >= -16 && val <= math.MaxInt8:
 		// Write as TINY_INT
-		err = binary.Write(e, binary.BigEndian, int8(val))
+		err = e.writeByte(byte(val))
 	case val > math.MaxInt8 && val <= math.MaxInt16:
 		// Write as INT_16
-		if _, err = e.Write([]byte{Int16Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, int16(val))
+		err = e.writeMarked(Int16Marker, uint64(val), 2)
 	case val > math.MaxInt16 && val <= math.MaxInt32:
 		// Write as INT_32
-		if _, err = e.Write([]byte{Int32Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, int32(val))
+		err = e.writeMarked(Int32Marker, uint64(val), 4)
 	case val > math.MaxInt32 && val <= math.MaxInt64:
 		// Write as INT_64
-		if _, err = e.Write([]byte{Int64Marker}); err != nil {
-			return err
-		}
-		err = binary.Write(e, binary.BigEndian, val)
+		err = e.writeMarked(Int64Marker, uint64(val), 8)
 	default:
 		return errors.New("Int too long to write: %d", val)
 	}
 	if err != nil {
-		return errors.Wrap(err, "An error occured writing an int to bolt")
+		return wrapNested(err, "An error occured writing an int to bolt")
 	}
 	return err
 }
 
-func (e Encoder) encodeFloat(val float64) error {
-	if _, err := e.Write([]byte{FloatMarker}); err != nil {
-		return err
-	}
-
-	err := binary.Write(e, binary.BigEndian, val)
+func (e *Encoder) encodeFloat(val float64) error {
+	err := e.writeMarked(FloatMarker, math.Float64bits(val), 8)
 	if err != nil {
-		return errors.Wrap(err, "An error occured writing a float to bolt")
+		return wrapNested(err, "An error occured writing a float to bolt")
 	}
 
 	return err
 }
 
-func (e Encoder) encodeString(val string) error {
+func (e *Encoder) encodeString(val string) error {
 	var err error
-	bytes := []byte(val)
-
-	length := len(bytes)
+	length := len(val)
 	switch {
 	case length <= 15:
-		if _, err = e.Write([]byte{byte(TinyStringMarker + length)}); err != nil {
-			return err
-		}
-		_, err = e.Write(bytes)
+		err = e.writeByte(byte(TinyStringMarker + length))
 	case length > 15 && length <= math.MaxUint8:
-		if _, err = e.Write([]byte{String8Marker}); err != nil {
-			return err
-		}
-		if err = binary.Write(e, binary.BigEndian, int8(length)); err != nil {
-			return err
-		}
-		_, err = e.Write(bytes)
+		err = e.writeMarked(String8Marker, uint64(length), 1)
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err = e.Write([]byte{String16Marker}); err != nil {
-			return err
-		}
-		if err = binary.Write(e, binary.BigEndian, int16(length)); err != nil {
-			return err
-		}
-		_, err = e.Write(bytes)
+		err = e.writeMarked(String16Marker, uint64(length), 2)
 	case length > math.MaxUint16 && int64(length) <= math.MaxUint32:
-		if _, err = e.Write([]byte{String32Marker}); err != nil {
-			return err
-		}
-		if err = binary.Write(e, binary.BigEndian, int32(length)); err != nil {
-			return err
-		}
-		_, err = e.Write(bytes)
+		err = e.writeMarked(String32Marker, uint64(length), 4)
 	default:
 		return errors.New("String too long to write: %s", val)
 	}
+	if err != nil {
+		return err
+	}
+	return e.writeString(val)
+}
+
+// writeString writes a string like Write, without copying it to a byte slice first
+func (e *Encoder) writeString(val string) error {
+	e.size += len(val)
+	if e.maxSize > 0 && e.size > e.maxSize {
+		return e.limitError("size", e.maxSize)
+	}
+
+	e.buf.WriteString(val)
+	if e.limited() {
+		return nil
+	}
+	return e.emitChunks()
+}
+
+// writeByte writes a single byte without allocating
+func (e *Encoder) writeByte(b byte) error {
+	e.scratch[0] = b
+	_, err := e.Write(e.scratch[:1])
+	return err
+}
+
+// writeMarked writes a marker followed by the low width bytes of val in
+// big endian order, without the allocations of binary.Write
+func (e *Encoder) writeMarked(marker byte, val uint64, width int) error {
+	e.scratch[0] = marker
+	switch width {
+	case 1:
+		e.scratch[1] = byte(val)
+	case 2:
+		binary.BigEndian.PutUint16(e.scratch[1:], uint16(val))
+	case 4:
+		binary.BigEndian.PutUint32(e.scratch[1:], uint32(val))
+	default:
+		binary.BigEndian.PutUint64(e.scratch[1:], val)
+	}
+	_, err := e.Write(e.scratch[:1+width])
 	return err
 }
 
-func (e Encoder) encodeSlice(val []interface{}) error {
+// pathSegment is a map key, or a slice index when isIndex is set, in the
+// path to the value being encoded.  Paths are only formatted for errors,
+// so tracking them doesn't allocate.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// limitError builds a LimitError for the value currently being encoded
+func (e *Encoder) limitError(limit string, max int) *LimitError {
+	param := ""
+	for i, segment := range e.path {
+		switch {
+		case segment.isIndex:
+			param += fmt.Sprintf("[%d]", segment.index)
+		case i > 0:
+			param += "." + segment.key
+		default:
+			param += segment.key
+		}
+	}
+	return &LimitError{Param: param, Limit: limit, Max: max}
+}
+
+// wrapNested wraps an error encoding part of a value.  A *LimitError is
+// returned as it is, even from deep inside a message, so it can be checked
+// for with a type assertion.
+func wrapNested(err error, msg string, args ...interface{}) error {
+	if limitErr, ok := err.(*LimitError); ok {
+		return limitErr
+	}
+	return errors.Wrap(err, msg, args...)
+}
+
+// enter descends into a nested map or slice, checking the depth limit
+func (e *Encoder) enter() error {
+	e.depth++
+	if e.maxDepth > 0 && e.depth > e.maxDepth {
+		return e.limitError("depth", e.maxDepth)
+	}
+	return nil
+}
+
+func (e *Encoder) leave() {
+	e.depth--
+}
+
+func (e *Encoder) pushPath(segment pathSegment) {
+	e.path = append(e.path, segment)
+}
+
+func (e *Encoder) popPath() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+func (e *Encoder) encodeSlice(val []interface{}) error {
+	if err := e.enter(); err != nil {
+		return err
+	}
+	defer e.leave()
+
 	length := len(val)
 	switch {
 	case length <= 15:
-		if _, err := e.Write([]byte{byte(TinySliceMarker + length)}); err != nil {
+		if err := e.writeByte(byte(TinySliceMarker + length)); err != nil {
 			return err
 		}
 	case length > 15 && length <= math.MaxUint8:
-		if _, err := e.Write([]byte{Slice8Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err := e.writeMarked(Slice8Marker, uint64(length), 1); err != nil {
 			return err
 		}
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err := e.Write([]byte{Slice16Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err := e.writeMarked(Slice16Marker, uint64(length), 2); err != nil {
 			return err
 		}
 	case length >= math.MaxUint16 && int64(length) <= math.MaxUint32:
-		if _, err := e.Write([]byte{Slice32Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int32(length)); err != nil {
+		if err := e.writeMarked(Slice32Marker, uint64(length), 4); err != nil {
 			return err
 		}
 	default:
@@ -383,41 +695,39 @@ func (e Encoder) encodeSlice(val []interface{}) error {
 	}
 
 	// Encode Slice values
-	for _, item := range val {
+	for i, item := range val {
+		e.pushPath(pathSegment{index: i, isIndex: true})
 		if err := e.encode(item); err != nil {
 			return err
 		}
+		e.popPath()
 	}
 
 	return nil
 }
 
-func (e Encoder) encodeMap(val map[string]interface{}) error {
+func (e *Encoder) encodeMap(val map[string]interface{}) error {
+	if err := e.enter(); err != nil {
+		return err
+	}
+	defer e.leave()
+
 	length := len(val)
 	switch {
 	case length <= 15:
-		if _, err := e.Write([]byte{byte(TinyMapMarker + length)}); err != nil {
+		if err := e.writeByte(byte(TinyMapMarker + length)); err != nil {
 			return err
 		}
 	case length > 15 && length <= math.MaxUint8:
-		if _, err := e.Write([]byte{Map8Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err := e.writeMarked(Map8Marker, uint64(length), 1); err != nil {
 			return err
 		}
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err := e.Write([]byte{Map16Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err := e.writeMarked(Map16Marker, uint64(length), 2); err != nil {
 			return err
 		}
 	case length >= math.MaxUint16 && int64(length) <= math.MaxUint32:
-		if _, err := e.Write([]byte{Map32Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int32(length)); err != nil {
+		if err := e.writeMarked(Map32Marker, uint64(length), 4); err != nil {
 			return err
 		}
 	default:
@@ -425,11 +735,22 @@ func (e Encoder) encodeMap(val map[string]interface{}) error {
 	}
 
 	// Encode Map values
-	for k, v := range val {
-		if err := e.encode(k); err != nil {
-			return err
+	if !e.sortMaps {
+		for k, v := range val {
+			if err := e.encodeEntry(k, v); err != nil {
+				return err
+			}
 		}
-		if err := e.encode(v); err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, length)
+	for k := range val {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := e.encodeEntry(k, val[k]); err != nil {
 			return err
 		}
 	}
@@ -437,41 +758,62 @@ func (e Encoder) encodeMap(val map[string]interface{}) error {
 	return nil
 }
 
-func (e Encoder) encodeStructure(val structures.Structure) error {
+// encodeEntry encodes a key and value of a map
+func (e *Encoder) encodeEntry(k string, v interface{}) error {
+	e.pushPath(pathSegment{key: k})
+	if err := e.encodeString(k); err != nil {
+		return err
+	}
+	if err := e.encode(v); err != nil {
+		return err
+	}
+	e.popPath()
+	return nil
+}
+
+// rawStructure adapts a structures.Raw so it is encoded as-is
+type rawStructure struct {
+	raw structures.Raw
+}
+
+// Signature gets the signature of the raw structure
+func (r rawStructure) Signature() int {
+	return int(r.raw.Signature)
+}
+
+// AllFields gets the fields of the raw structure
+func (r rawStructure) AllFields() []interface{} {
+	return r.raw.Fields
+}
+
+func (e *Encoder) encodeStructure(val structures.Structure) error {
 
 	fields := val.AllFields()
 	length := len(fields)
 	switch {
 	case length <= 15:
-		if _, err := e.Write([]byte{byte(TinyStructMarker + length)}); err != nil {
+		if err := e.writeByte(byte(TinyStructMarker + length)); err != nil {
 			return err
 		}
 	case length > 15 && length <= math.MaxUint8:
-		if _, err := e.Write([]byte{Struct8Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err := e.writeMarked(Struct8Marker, uint64(length), 1); err != nil {
 			return err
 		}
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err := e.Write([]byte{Struct16Marker}); err != nil {
-			return err
-		}
-		if err := binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err := e.writeMarked(Struct16Marker, uint64(length), 2); err != nil {
 			return err
 		}
 	default:
 		return errors.New("Structure too long to write: %+v", val)
 	}
 
-	_, err := e.Write([]byte{byte(val.Signature())})
-	if err != nil {
-		return errors.Wrap(err, "An error occurred writing to encoder a struct field")
+	if err := e.writeByte(byte(val.Signature())); err != nil {
+		return wrapNested(err, "An error occurred writing to encoder a struct field")
 	}
 
 	for _, field := range fields {
 		if err := e.encode(field); err != nil {
-			return errors.Wrap(err, "An error occurred encoding a struct field")
+			return wrapNested(err, "An error occurred encoding a struct field")
 		}
 	}
 
@@ -0,0 +1,203 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// DefaultLazyMetadata are the SUCCESS metadata keys whose values can be large,
+// like query plans, and are rarely read
+var DefaultLazyMetadata = []string{"plan", "profile", "notifications"}
+
+// LazyValue is a value in SUCCESS metadata that's kept encoded until it's
+// read, see Decoder.SetLazyMetadata.  Encoding a LazyValue writes out the
+// bytes it was decoded from.
+type LazyValue struct {
+	data    []byte
+	value   interface{}
+	err     error
+	decoded bool
+}
+
+// NewLazyValue gets a LazyValue for the encoded bytes of a value
+func NewLazyValue(data []byte) *LazyValue {
+	return &LazyValue{data: data}
+}
+
+// Value decodes the value on first use, and returns it
+func (l *LazyValue) Value() (interface{}, error) {
+	if !l.decoded {
+		l.decoded = true
+		decoder := &Decoder{}
+		l.value, l.err = decoder.decode(bytes.NewBuffer(l.data))
+		if l.err != nil {
+			l.err = errors.Wrap(l.err, "An error occurred decoding lazy metadata value")
+		}
+	}
+	return l.value, l.err
+}
+
+// MarshalJSON marshals the decoded value
+func (l *LazyValue) MarshalJSON() ([]byte, error) {
+	value, err := l.Value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// Resolve gets a value from metadata, decoding it if it's a LazyValue.
+// Values that fail to decode are nil.
+func Resolve(value interface{}) interface{} {
+	if lazy, ok := value.(*LazyValue); ok {
+		value, _ = lazy.Value()
+	}
+	return value
+}
+
+// SetLazyMetadata makes the decoder keep the values of the given keys in
+// SUCCESS metadata encoded, as a *LazyValue, until they're read.  Decoding
+// large query plans and notifications for every query is wasted when
+// they're never read.  No keys turns it off.
+func (d *Decoder) SetLazyMetadata(keys ...string) {
+	d.lazyKeys = nil
+	if len(keys) > 0 {
+		d.lazyKeys = make(map[string]bool, len(keys))
+		for _, key := range keys {
+			d.lazyKeys[key] = true
+		}
+	}
+}
+
+// decodeLazySuccessMessage decodes SUCCESS metadata, keeping the values
+// of lazy keys encoded
+func (d *Decoder) decodeLazySuccessMessage(buffer *bytes.Buffer) (messages.SuccessMessage, error) {
+	marker, err := buffer.ReadByte()
+	if err != nil {
+		return messages.SuccessMessage{}, errors.Wrap(err, "Error reading marker")
+	}
+
+	var size int
+	switch {
+	case marker >= TinyMapMarker && marker <= TinyMapMarker+0x0F:
+		size = int(marker) - int(TinyMapMarker)
+	case marker == Map8Marker || marker == Map16Marker || marker == Map32Marker:
+		if size, err = readSize(buffer, marker-Map8Marker); err != nil {
+			return messages.SuccessMessage{}, errors.Wrap(err, "An error occurred reading map size")
+		}
+	default:
+		return messages.SuccessMessage{}, errors.New("Expected: Metadata map[string]interface{}, but got marker %x", marker)
+	}
+
+	metadata := make(map[string]interface{}, size)
+	for i := 0; i < size; i++ {
+		keyInt, err := d.decode(buffer)
+		if err != nil {
+			return messages.SuccessMessage{}, err
+		}
+		key, ok := keyInt.(string)
+		if !ok {
+			return messages.SuccessMessage{}, errors.New("Unexpected key type: %T with value %+v", keyInt, keyInt)
+		}
+
+		if !d.lazyKeys[key] {
+			if metadata[key], err = d.decode(buffer); err != nil {
+				return messages.SuccessMessage{}, err
+			}
+			continue
+		}
+
+		// The buffer is reused for the next message, so the value is copied
+		start := buffer.Bytes()
+		if err := skip(buffer); err != nil {
+			return messages.SuccessMessage{}, errors.Wrap(err, "An error occurred skipping lazy metadata value %s", key)
+		}
+		data := make([]byte, len(start)-buffer.Len())
+		copy(data, start)
+		metadata[key] = NewLazyValue(data)
+	}
+
+	return messages.NewSuccessMessage(metadata), nil
+}
+
+// readSize reads a size of 1, 2 or 4 bytes, for width 0, 1 or 2
+func readSize(buffer *bytes.Buffer, width byte) (int, error) {
+	switch width {
+	case 0:
+		size, err := buffer.ReadByte()
+		return int(size), err
+	case 1:
+		size := buffer.Next(2)
+		if len(size) < 2 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return int(binary.BigEndian.Uint16(size)), nil
+	default:
+		size := buffer.Next(4)
+		if len(size) < 4 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return int(binary.BigEndian.Uint32(size)), nil
+	}
+}
+
+// skip moves the buffer past the next value without decoding it
+func skip(buffer *bytes.Buffer) error {
+	marker, err := buffer.ReadByte()
+	if err != nil {
+		return errors.Wrap(err, "Error reading marker")
+	}
+
+	var size, items int
+	switch {
+	case marker == NilMarker || marker == TrueMarker || marker == FalseMarker || int8(marker) >= -16:
+		return nil
+	case marker == Int8Marker:
+		size = 1
+	case marker == Int16Marker:
+		size = 2
+	case marker == Int32Marker:
+		size = 4
+	case marker == Int64Marker || marker == FloatMarker:
+		size = 8
+	case marker >= TinyStringMarker && marker <= TinyStringMarker+0x0F:
+		size = int(marker) - int(TinyStringMarker)
+	case marker >= String8Marker && marker <= String32Marker:
+		size, err = readSize(buffer, marker-String8Marker)
+	case marker >= TinySliceMarker && marker <= TinySliceMarker+0x0F:
+		items = int(marker) - int(TinySliceMarker)
+	case marker >= Slice8Marker && marker <= Slice32Marker:
+		items, err = readSize(buffer, marker-Slice8Marker)
+	case marker >= TinyMapMarker && marker <= TinyMapMarker+0x0F:
+		items = 2 * (int(marker) - int(TinyMapMarker))
+	case marker >= Map8Marker && marker <= Map32Marker:
+		items, err = readSize(buffer, marker-Map8Marker)
+		items *= 2
+	case marker >= TinyStructMarker && marker <= TinyStructMarker+0x0F:
+		// The signature byte comes before the fields
+		size, items = 1, int(marker)-int(TinyStructMarker)
+	case marker == Struct8Marker || marker == Struct16Marker:
+		items, err = readSize(buffer, marker-Struct8Marker)
+		size = 1
+	default:
+		return errors.New("Unrecognized marker byte!: %x", marker)
+	}
+	if err != nil {
+		return errors.Wrap(err, "An error occurred reading size")
+	}
+
+	if len(buffer.Next(size)) < size {
+		return errors.New("Expected %d more bytes for value with marker %x", size, marker)
+	}
+	for i := 0; i < items; i++ {
+		if err := skip(buffer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package encoding
+
+import (
+	"bytes"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+)
+
+// decodeCoordinates decodes the SRID and the float coordinates of a point
+func (d *Decoder) decodeCoordinates(buffer *bytes.Buffer, n int) (int64, []float64, error) {
+	srid, err := d.decodeInt(buffer)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	coordinates := make([]float64, n)
+	for i := range coordinates {
+		coordinateInt, err := d.decode(buffer)
+		if err != nil {
+			return 0, nil, err
+		}
+		coordinate, ok := coordinateInt.(float64)
+		if !ok {
+			return 0, nil, errors.New("Expected: coordinate float64, but got %T %+v", coordinateInt, coordinateInt)
+		}
+		coordinates[i] = coordinate
+	}
+	return srid, coordinates, nil
+}
+
+func (d *Decoder) decodePoint2D(buffer *bytes.Buffer) (graph.Point2D, error) {
+	srid, coordinates, err := d.decodeCoordinates(buffer, 2)
+	if err != nil {
+		return graph.Point2D{}, err
+	}
+	return graph.Point2D{SRID: srid, X: coordinates[0], Y: coordinates[1]}, nil
+}
+
+func (d *Decoder) decodePoint3D(buffer *bytes.Buffer) (graph.Point3D, error) {
+	srid, coordinates, err := d.decodeCoordinates(buffer, 3)
+	if err != nil {
+		return graph.Point3D{}, err
+	}
+	return graph.Point3D{SRID: srid, X: coordinates[0], Y: coordinates[1], Z: coordinates[2]}, nil
+}
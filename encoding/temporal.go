@@ -0,0 +1,91 @@
+package encoding
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+// decodeInts decodes the integer fields of a temporal structure
+func (d *Decoder) decodeInts(buffer *bytes.Buffer, n int) ([]int64, error) {
+	ints := make([]int64, n)
+	for i := range ints {
+		var err error
+		if ints[i], err = d.decodeInt(buffer); err != nil {
+			return nil, err
+		}
+	}
+	return ints, nil
+}
+
+func (d *Decoder) decodeDate(buffer *bytes.Buffer) (temporal.Date, error) {
+	fields, err := d.decodeInts(buffer, 1)
+	if err != nil {
+		return temporal.Date{}, err
+	}
+	return temporal.DateFromDays(fields[0]), nil
+}
+
+func (d *Decoder) decodeTime(buffer *bytes.Buffer) (temporal.Time, error) {
+	fields, err := d.decodeInts(buffer, 2)
+	if err != nil {
+		return temporal.Time{}, err
+	}
+	return temporal.TimeFromNanos(fields[0], fields[1]), nil
+}
+
+func (d *Decoder) decodeLocalTime(buffer *bytes.Buffer) (temporal.LocalTime, error) {
+	fields, err := d.decodeInts(buffer, 1)
+	if err != nil {
+		return temporal.LocalTime{}, err
+	}
+	return temporal.LocalTimeFromNanos(fields[0]), nil
+}
+
+func (d *Decoder) decodeDateTime(buffer *bytes.Buffer) (time.Time, error) {
+	fields, err := d.decodeInts(buffer, 3)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return temporal.DateTimeFromOffset(fields[0], fields[1], fields[2]), nil
+}
+
+func (d *Decoder) decodeDateTimeZoneID(buffer *bytes.Buffer) (time.Time, error) {
+	fields, err := d.decodeInts(buffer, 2)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	zoneInt, err := d.decode(buffer)
+	if err != nil {
+		return time.Time{}, err
+	}
+	zone, ok := zoneInt.(string)
+	if !ok {
+		return time.Time{}, errors.New("Expected: ZoneID string, but got %T %+v", zoneInt, zoneInt)
+	}
+
+	t, err := temporal.DateTimeFromZoneID(fields[0], fields[1], zone)
+	if err != nil {
+		return t, errors.Wrap(err, "An error occurred loading the time zone of a DateTime")
+	}
+	return t, nil
+}
+
+func (d *Decoder) decodeLocalDateTime(buffer *bytes.Buffer) (temporal.LocalDateTime, error) {
+	fields, err := d.decodeInts(buffer, 2)
+	if err != nil {
+		return temporal.LocalDateTime{}, err
+	}
+	return temporal.LocalDateTimeFromEpoch(fields[0], fields[1]), nil
+}
+
+func (d *Decoder) decodeDuration(buffer *bytes.Buffer) (temporal.Duration, error) {
+	fields, err := d.decodeInts(buffer, 4)
+	if err != nil {
+		return temporal.Duration{}, err
+	}
+	return temporal.Duration{Months: fields[0], Days: fields[1], Seconds: fields[2], Nanos: fields[3]}, nil
+}
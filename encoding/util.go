@@ -5,18 +5,6 @@ import (
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
 )
 
-func sliceInterfaceToString(from []interface{}) ([]string, error) {
-	to := make([]string, len(from))
-	for idx, item := range from {
-		toItem, ok := item.(string)
-		if !ok {
-			return nil, errors.New("Expected string value. Got %T %+v", item, item)
-		}
-		to[idx] = toItem
-	}
-	return to, nil
-}
-
 func sliceInterfaceToInt(from []interface{}) ([]int, error) {
 	to := make([]int, len(from))
 	for idx, item := range from {
@@ -0,0 +1,103 @@
+package golangNeo4jBoltDriver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+// QuerySpec is a query and its parameters, run by QueryConcurrently
+type QuerySpec struct {
+	Query  string
+	Params map[string]interface{}
+}
+
+// QueryResult is the outcome of a query run by QueryConcurrently.  If the
+// query failed, or wasn't run, Err is set and the rest is empty.
+type QueryResult struct {
+	Rows    [][]interface{}
+	Columns []string
+	Summary Summary
+	Err     error
+}
+
+// FanOutError is returned by QueryConcurrently when any of the queries
+// failed.  Failed holds the index of each failed query, in order, and the
+// result of every query, failed or not, is still returned.
+type FanOutError struct {
+	Failed []int
+	First  error
+}
+
+// Error implements the error interface
+func (e *FanOutError) Error() string {
+	return fmt.Sprintf("%d queries failed, the first (query %d) with: %s", len(e.Failed), e.Failed[0], e.First)
+}
+
+// QueryConcurrently runs the queries on up to concurrency connections from
+// the pool at once, returning their results in the same order as the
+// queries.  Each query borrows a connection and closes it when it's done,
+// so a connection broken by one query is replaced for the next.  Failed
+// queries are retried as set by SetRetryPolicy.
+//
+// Once the context is done, queries that haven't started fail with the
+// context's error.  Queries already running run to completion, so use
+// ConnOptions.MaxExecutionTime to bound them.
+func (d *boltDriverPool) QueryConcurrently(ctx context.Context, queries []QuerySpec, concurrency int) ([]QueryResult, error) {
+	return queryConcurrently(ctx, d.OpenPool, d.poolLogger(), queries, concurrency, d.retry())
+}
+
+func queryConcurrently(ctx context.Context, open func() (Conn, error), logger *log.Logger, queries []QuerySpec, concurrency int, policy RetryPolicy) ([]QueryResult, error) {
+	if concurrency <= 0 || concurrency > len(queries) {
+		concurrency = len(queries)
+	}
+
+	results := make([]QueryResult, len(queries))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				result := &results[i]
+				result.Err = safely("running a query concurrently", func() (err error) {
+					result.Rows, result.Columns, result.Summary, err = runWithRetry(open, logger, queries[i].Query, queries[i].Params, policy, time.Sleep)
+					return err
+				})
+			}
+		}()
+	}
+
+	for i := range queries {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var fanOutErr *FanOutError
+	for i, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if fanOutErr == nil {
+			fanOutErr = &FanOutError{First: result.Err}
+		}
+		fanOutErr.Failed = append(fanOutErr.Failed, i)
+	}
+	if fanOutErr != nil {
+		return results, fanOutErr
+	}
+	return results, nil
+}
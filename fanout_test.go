@@ -0,0 +1,75 @@
+package golangNeo4jBoltDriver
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// fanOutConn returns the query as its only row, or fails queries named FAIL
+type fanOutConn struct {
+	Conn
+}
+
+func (c fanOutConn) QueryNeoAllSummary(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	if query == "FAIL" {
+		return nil, nil, Summary{}, errors.New("failed")
+	}
+	return [][]interface{}{{query}}, []string{"q"}, Summary{}, nil
+}
+
+func TestQueryConcurrently(t *testing.T) {
+	var lock sync.Mutex
+	out, maxOut := 0, 0
+	open := func() (Conn, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		out++
+		if out > maxOut {
+			maxOut = out
+		}
+		return &fanOutCloser{fanOutConn{}, &lock, &out}, nil
+	}
+
+	queries := []QuerySpec{{Query: "A"}, {Query: "FAIL"}, {Query: "C"}, {Query: "D"}, {Query: "FAIL"}}
+	results, err := queryConcurrently(context.Background(), open, nil, queries, 2, RetryPolicy{})
+	fanOutErr, ok := err.(*FanOutError)
+	if !ok || len(fanOutErr.Failed) != 2 || fanOutErr.Failed[0] != 1 || fanOutErr.Failed[1] != 4 {
+		t.Fatalf("Expected the failed queries to be reported in order. Got: %#v", err)
+	}
+	for i, query := range []string{"A", "", "C", "D", ""} {
+		if query == "" {
+			if results[i].Err == nil {
+				t.Fatalf("Expected query %d to fail. Got: %#v", i, results[i])
+			}
+		} else if results[i].Err != nil || results[i].Rows[0][0] != query {
+			t.Fatalf("Expected query %d to return its result in order. Got: %#v", i, results[i])
+		}
+	}
+	if maxOut > 2 || out != 0 {
+		t.Fatalf("Expected at most 2 connections out, all closed. Got: %d max, %d still out", maxOut, out)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = queryConcurrently(ctx, open, nil, queries, 2, RetryPolicy{})
+	if fanOutErr, ok := err.(*FanOutError); !ok || len(fanOutErr.Failed) != len(queries) || fanOutErr.First != context.Canceled {
+		t.Fatalf("Expected every query to fail once the context is done. Got: %#v", err)
+	}
+}
+
+// fanOutCloser counts the connection back in when it's closed
+type fanOutCloser struct {
+	fanOutConn
+	lock *sync.Mutex
+	out  *int
+}
+
+func (c *fanOutCloser) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	*c.out--
+	return nil
+}
@@ -0,0 +1,82 @@
+package golangNeo4jBoltDriver
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Feature is a protocol or server feature a connection may support,
+// checked with Conn.Supports
+type Feature string
+
+const (
+	// FeatureBookmarks is causal consistency with bookmarks, from Neo4j 3.1 on
+	FeatureBookmarks Feature = "bookmarks"
+	// FeatureTemporalTypes is sending and returning dates, times and
+	// durations, from Bolt v2 on
+	FeatureTemporalTypes Feature = "temporal types"
+	// FeatureSpatialTypes is sending and returning points, from Bolt v2 on
+	FeatureSpatialTypes Feature = "spatial types"
+	// FeatureTransactionMessages is BEGIN, COMMIT and ROLLBACK messages,
+	// with transaction metadata, from Bolt v3 on
+	FeatureTransactionMessages Feature = "transaction messages"
+	// FeatureMultiDatabase is running queries against a named database,
+	// from Bolt v4 on.  The driver doesn't support Bolt v4 yet.
+	FeatureMultiDatabase Feature = "multi-database"
+	// FeaturePullN is pulling records in batches with PULL n, from Bolt v4
+	// on.  The driver doesn't support Bolt v4 yet.
+	FeaturePullN Feature = "PULL n"
+)
+
+// featureVersions are the Bolt versions features were added in
+var featureVersions = map[Feature]uint32{
+	FeatureTemporalTypes:       2,
+	FeatureSpatialTypes:        2,
+	FeatureTransactionMessages: 3,
+	FeatureMultiDatabase:       4,
+	FeaturePullN:               4,
+}
+
+// Supports checks whether the connection supports the feature, from the
+// negotiated Bolt version and the server version
+func (c *boltConn) Supports(feature Feature) bool {
+	if feature == FeatureTransactionMessages {
+		// The message format of the negotiated version is used even in
+		// compatibility mode
+		return c.protocolVersion() >= featureVersions[feature]
+	}
+	if version, ok := featureVersions[feature]; ok {
+		return c.featureVersion() >= version
+	}
+
+	switch feature {
+	case FeatureBookmarks:
+		// Every server that speaks Bolt v3 has bookmarks
+		return c.featureVersion() >= 3 || c.serverAtLeast(3, 1)
+	}
+	return false
+}
+
+// serverAtLeast checks the server version, e.g. Neo4j/3.5.1, from the
+// response to INIT.  Returns false if the version is unknown.
+func (c *boltConn) serverAtLeast(major, minor int) bool {
+	server, _ := c.serverMeta["server"].(string)
+	slash := strings.Index(server, "/")
+	if slash < 0 {
+		return false
+	}
+
+	parts := strings.SplitN(server[slash+1:], ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	serverMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	serverMinor, err := strconv.Atoi(strings.TrimFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return false
+	}
+	return serverMajor > major || (serverMajor == major && serverMinor >= minor)
+}
@@ -0,0 +1,37 @@
+package golangNeo4jBoltDriver
+
+import "testing"
+
+func TestBoltConn_Supports(t *testing.T) {
+	tests := []struct {
+		version   byte
+		server    string
+		compat    bool
+		supported []Feature
+	}{
+		{1, "Neo4j/3.0.7", false, nil},
+		{1, "Neo4j/3.1.0", false, []Feature{FeatureBookmarks}},
+		{2, "Neo4j/3.4.0-rc1", false, []Feature{FeatureBookmarks, FeatureTemporalTypes, FeatureSpatialTypes}},
+		{3, "Neo4j/3.5.1", false, []Feature{FeatureBookmarks, FeatureTemporalTypes, FeatureSpatialTypes, FeatureTransactionMessages}},
+		{3, "Neo4j/3.5.1", true, []Feature{FeatureBookmarks, FeatureTransactionMessages}},
+		{2, "", false, []Feature{FeatureTemporalTypes, FeatureSpatialTypes}},
+	}
+	all := []Feature{FeatureBookmarks, FeatureTemporalTypes, FeatureSpatialTypes, FeatureTransactionMessages, FeatureMultiDatabase, FeaturePullN}
+
+	for _, test := range tests {
+		c := createBoltConn("")
+		c.serverVersion[3] = test.version
+		c.serverMeta = map[string]interface{}{"server": test.server}
+		c.compatMode = test.compat
+
+		for _, feature := range all {
+			expected := false
+			for _, supported := range test.supported {
+				expected = expected || supported == feature
+			}
+			if c.Supports(feature) != expected {
+				t.Fatalf("Expected Bolt v%d, %q (compat mode %t) to support %s: %t", test.version, test.server, test.compat, feature, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package golangNeo4jBoltDriver
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	// writeClauses matches the Cypher clauses that write to the database,
+	// clauses, and not properties or parameters with the same name
+	writeClauses = regexp.MustCompile(`(?i)(?:^|[^.$\w])(CREATE|MERGE|DELETE|SET|REMOVE|DROP|FOREACH|LOAD\s+CSV)\b`)
+	// literals matches string literals, quoted names and comments, which
+	// are removed before looking for write clauses
+	literals = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|` + "`[^`]*`" + `|//[^\n]*|/\*(?s:.*?)\*/`)
+)
+
+// QueryGuard checks each query before it's sent, returning an error to
+// block it, so production services can refuse queries like
+// `MATCH (n) DETACH DELETE n` or `CALL dbms.*`.  It sees every query run
+// on the connection, including BEGIN, COMMIT and ROLLBACK.
+type QueryGuard func(query string, params map[string]interface{}) error
+
+// QueryBlockedError is returned for a query blocked by the query guard.
+// Reason is the error the guard returned.
+type QueryBlockedError struct {
+	Query  string
+	Reason string
+}
+
+// Error implements the error interface
+func (e *QueryBlockedError) Error() string {
+	return fmt.Sprintf("Query blocked by query guard: %s\n\n%s", e.Reason, e.Query)
+}
+
+// BlockQueries gets a query guard that blocks queries matching any of the patterns
+func BlockQueries(patterns ...*regexp.Regexp) QueryGuard {
+	return func(query string, params map[string]interface{}) error {
+		for _, pattern := range patterns {
+			if pattern.MatchString(query) {
+				return fmt.Errorf("Query matches blocked pattern %s", pattern)
+			}
+		}
+		return nil
+	}
+}
+
+// SetQueryGuard sets the guard checking each query before it's sent
+func (c *boltConn) SetQueryGuard(guard QueryGuard) {
+	c.queryGuard = guard
+}
+
+// SetReadOnly makes the connection refuse queries that write
+func (c *boltConn) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+// IsWriteQuery checks if the query has any clauses that write, like CREATE
+// or SET.  It's a check of the query text, so a procedure that writes, in
+// a CALL, isn't seen as a write.
+func IsWriteQuery(query string) bool {
+	return writeClauses.MatchString(literals.ReplaceAllString(query, " "))
+}
+
+// checkQuery checks the query with the query guard, if there is one, and
+// that it doesn't write on a read only connection
+func (c *boltConn) checkQuery(prepared *preparedQuery, params map[string]interface{}) error {
+	if c.readOnly && prepared.isWrite() {
+		return &QueryBlockedError{Query: prepared.query, Reason: "Can't write on a read only connection"}
+	}
+	if c.queryGuard == nil {
+		return nil
+	}
+	if err := c.queryGuard(prepared.query, params); err != nil {
+		if blocked, ok := err.(*QueryBlockedError); ok {
+			return blocked
+		}
+		return &QueryBlockedError{Query: prepared.query, Reason: err.Error()}
+	}
+	return nil
+}
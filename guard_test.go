@@ -0,0 +1,97 @@
+package golangNeo4jBoltDriver
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestBoltConn_QueryGuard(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.SetQueryGuard(BlockQueries(regexp.MustCompile(`(?i)DETACH\s+DELETE`), regexp.MustCompile(`(?i)CALL\s+dbms\.`)))
+
+	written := make(chan int, 1)
+	go func() {
+		n, _ := server.Read(make([]byte, 1024))
+		written <- n
+	}()
+
+	_, err := c.ExecNeo("MATCH (n) DETACH DELETE n", nil)
+	blocked, ok := err.(*QueryBlockedError)
+	if !ok {
+		t.Fatalf("Expected QueryBlockedError. Got: %#v", err)
+	}
+	if blocked.Query != "MATCH (n) DETACH DELETE n" {
+		t.Fatalf("Expected the blocked query in the error. Got: %#v", blocked)
+	}
+
+	// A pipeline is checked before any of it is sent
+	_, err = c.ExecPipeline([]string{"CREATE (n)", "call dbms.killQueries([])"}, nil, nil)
+	if _, ok := err.(*QueryBlockedError); !ok {
+		t.Fatalf("Expected QueryBlockedError for pipeline. Got: %#v", err)
+	}
+
+	c.SetQueryGuard(func(query string, params map[string]interface{}) error {
+		if _, ok := params["unsafe"]; ok {
+			return errors.New("unsafe parameter")
+		}
+		return nil
+	})
+	_, err = c.QueryNeo("RETURN $unsafe", map[string]interface{}{"unsafe": true})
+	if blocked, ok := err.(*QueryBlockedError); !ok || blocked.Reason != "unsafe parameter" {
+		t.Fatalf("Expected QueryBlockedError from the guard's error. Got: %#v", err)
+	}
+
+	select {
+	case n := <-written:
+		t.Fatalf("Expected nothing sent for blocked queries. Got %d bytes", n)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestIsWriteQuery(t *testing.T) {
+	writes := []string{
+		"CREATE (n)",
+		"MATCH (n) DETACH DELETE n",
+		"MATCH (n) SET n.a = 1",
+		"merge (n:Foo {id: $id})",
+		"MATCH (n) WITH n\nREMOVE n:Foo",
+		"LOAD CSV FROM 'file:///a.csv' AS row RETURN row",
+	}
+	for _, query := range writes {
+		if !IsWriteQuery(query) {
+			t.Errorf("Expected a write query: %s", query)
+		}
+	}
+
+	reads := []string{
+		"MATCH (n) RETURN n",
+		"MATCH (n) WHERE n.name = 'CREATE' RETURN n.set, $delete",
+		"RETURN \"SET\" // DELETE everything",
+		"MATCH (n) /* MERGE */ RETURN `create`",
+	}
+	for _, query := range reads {
+		if IsWriteQuery(query) {
+			t.Errorf("Expected a read query: %s", query)
+		}
+	}
+}
+
+func TestBoltConn_ReadOnly(t *testing.T) {
+	c := createBoltConn("bolt://localhost:7687?read_only=true")
+	if _, err := c.parseURL(); err != nil {
+		t.Fatalf("An error occurred parsing url: %s", err)
+	}
+	if !c.readOnly {
+		t.Fatalf("Expected read_only to make the connection read only")
+	}
+
+	_, err := c.ExecNeo("CREATE (n)", nil)
+	if _, ok := err.(*QueryBlockedError); !ok {
+		t.Fatalf("Expected QueryBlockedError writing on a read only connection. Got: %#v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package golangNeo4jBoltDriver
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// SetMaxConnLifetime sets how long a connection is kept from when it connected
+func (d *boltDriverPool) SetMaxConnLifetime(lifetime time.Duration) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.maxLifetime = lifetime
+}
+
+// SetIdleTimeout sets how long a connection can go unused before it's replaced
+func (d *boltDriverPool) SetIdleTimeout(timeout time.Duration) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.idleTimeout = timeout
+}
+
+// healthy checks a connection taken from the pool before it's handed out.
+// A connection that's too old or has been idle too long is closed, and one
+// that doesn't answer a RESET with SUCCESS is closed as broken.  Returns
+// false if the connection needs to connect again.  It's run without the pool
+// locked, so the limits are the ones copied onto the connection when taken.
+func healthy(conn *boltConn) bool {
+	if conn.conn == nil {
+		return false
+	}
+
+	reason := ""
+	// Uses from before the connection last connected don't count
+	lastUsed := conn.created
+	if used := time.Unix(0, atomic.LoadInt64(&conn.lastUsed)); used.After(lastUsed) {
+		lastUsed = used
+	}
+	if conn.maxLifetime > 0 && time.Since(conn.created) > conn.maxLifetime {
+		reason = "it's older than the maximum lifetime of " + conn.maxLifetime.String()
+	} else if conn.idleTimeout > 0 && time.Since(lastUsed) > conn.idleTimeout {
+		reason = "it's been idle longer than the timeout of " + conn.idleTimeout.String()
+	} else if err := conn.ping(); err != nil {
+		conn.logger.Errorf("Health check detected a bad connection: %s", err)
+		reason = "it failed the health check"
+	}
+	if reason == "" {
+		return true
+	}
+
+	conn.logger.Infof("Replacing pooled connection, as %s", reason)
+	conn.closeConn()
+	conn.conn = nil
+	return false
+}
+
+// ping checks the connection is alive and ready for a query by sending a
+// RESET, which an idle connection answers with a single SUCCESS
+func (c *boltConn) ping() error {
+	if err := c.encode(messages.NewResetMessage()); err != nil {
+		return errors.Wrap(err, "An error occurred sending RESET")
+	}
+
+	respInt, err := c.receive()
+	if err != nil {
+		return errors.Wrap(err, "An error occurred reading the response to RESET")
+	}
+	if _, ok := respInt.(messages.SuccessMessage); !ok {
+		return errors.New("Unexpected response to RESET: %#v", respInt)
+	}
+	return nil
+}
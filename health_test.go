@@ -0,0 +1,278 @@
+package golangNeo4jBoltDriver
+
+import (
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+func TestBoltDriverPool_Healthy(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.created = time.Now()
+
+	received := make(chan interface{}, 1)
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		msg, _ := decoder.Decode()
+		received <- msg
+		encoding.NewEncoder(server, math.MaxUint16).Encode(messages.NewSuccessMessage(nil))
+		// The second check gets no answer
+		decoder.Decode()
+		server.Close()
+	}()
+
+	if !healthy(c) {
+		t.Fatal("Expected a connection answering RESET to be healthy")
+	}
+	if msg, ok := (<-received).(messages.ResetMessage); !ok {
+		t.Fatalf("Expected the health check to send RESET. Got: %#v", msg)
+	}
+	if healthy(c) || c.conn != nil {
+		t.Fatal("Expected a connection closed by the server to be replaced")
+	}
+
+	var pool *boltDriverPool
+	for _, setting := range []func(){
+		func() { pool.SetMaxConnLifetime(time.Minute) },
+		func() { pool.SetIdleTimeout(time.Minute) },
+	} {
+		pool = &boltDriverPool{}
+		setting()
+		pool.configure(c)
+		client, _ := net.Pipe()
+		c.conn = client
+		c.created = time.Now().Add(-2 * time.Minute)
+		// Sending a RESET would block, as nothing reads from the pipe
+		if healthy(c) || c.conn != nil {
+			t.Fatal("Expected an old or idle connection to be replaced without a health check")
+		}
+	}
+}
+
+func TestBoltDriverPool_ReconnectsAfterServerClose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("An error occurred listening: %s", err)
+	}
+	defer listener.Close()
+
+	// The server closes the first connection once it's connected, as if it restarted
+	go func() {
+		for first := true; ; first = false {
+			server, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveBolt(server, first, 0)
+		}
+	}()
+
+	pool, err := NewClosableDriverPool("bolt://"+listener.Addr().String(), 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	defer pool.Close()
+
+	conn, err := pool.OpenPool()
+	if err != nil {
+		t.Fatalf("An error occurred opening the first connection: %s", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("An error occurred returning the first connection: %s", err)
+	}
+	// Give the server time to close the idle connection
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err = pool.OpenPool()
+	if err != nil {
+		t.Fatalf("Expected a new connection after the server closed the pooled one. Got: %s", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("An error occurred returning the second connection: %s", err)
+	}
+}
+
+func TestBoltDriverPool_MoreBorrowersThanConns(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("An error occurred listening: %s", err)
+	}
+	defer listener.Close()
+
+	// Slow replies keep the health check running while the other borrowers wait
+	go func() {
+		for {
+			server, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveBolt(server, false, 20*time.Millisecond)
+		}
+	}()
+
+	pool, err := NewClosableDriverPool("bolt://"+listener.Addr().String(), 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	defer pool.Close()
+
+	// Connect the only connection, so the next borrows check it with a RESET
+	conn, err := pool.OpenPool()
+	if err != nil {
+		t.Fatalf("An error occurred opening the first connection: %s", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("An error occurred returning the first connection: %s", err)
+	}
+
+	const borrowers = 4
+	errs := make(chan error, borrowers)
+	for i := 0; i < borrowers; i++ {
+		go func() {
+			conn, err := pool.OpenPool()
+			if err == nil {
+				err = conn.Close()
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < borrowers; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Fatalf("An error occurred borrowing a connection: %s", err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Expected borrowers waiting on the pool not to block the borrower returning the connection")
+		}
+	}
+}
+
+// listenBolt serves fake Bolt connections with serveBolt until the listener is closed
+func listenBolt(t *testing.T, closeAfterInit bool) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("An error occurred listening: %s", err)
+	}
+	go func() {
+		for {
+			server, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveBolt(server, closeAfterInit, 0)
+		}
+	}()
+	return listener
+}
+
+func TestBoltDriverPool_ReclaimRestoresSettings(t *testing.T) {
+	listener := listenBolt(t, false)
+	defer listener.Close()
+
+	pool, err := NewClosableDriverPool("bolt://"+listener.Addr().String()+"?read_only=1", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	defer pool.Close()
+
+	conn, err := pool.OpenPool()
+	if err != nil {
+		t.Fatalf("An error occurred opening connection: %s", err)
+	}
+	defaults := conn.Options()
+	if !defaults.ReadOnly {
+		t.Fatal("Expected the connection param to set the default")
+	}
+
+	opts := conn.Options()
+	opts.ReadOnly = false
+	opts.AsyncClose = true
+	opts.MaxExecutionTime = time.Second
+	opts.MaxSize = 1024
+	opts.StatementCacheSize = 10
+	opts.DefaultParams = map[string]interface{}{"tenantId": 1}
+	conn.SetOptions(opts)
+	conn.SetTimeout(time.Second)
+	if err := conn.Close(); err != nil {
+		t.Fatalf("An error occurred returning connection: %s", err)
+	}
+
+	conn, err = pool.OpenPool()
+	if err != nil {
+		t.Fatalf("An error occurred opening connection: %s", err)
+	}
+	defer conn.Close()
+	if !reflect.DeepEqual(conn.Options(), defaults) {
+		t.Fatalf("Expected the next borrower to get the defaults. Expected %#v. Got: %#v", defaults, conn.Options())
+	}
+	if conn.(*boltConn).timeout != 60*time.Second {
+		t.Fatalf("Expected the next borrower to get the default timeout. Got: %s", conn.(*boltConn).timeout)
+	}
+}
+
+func TestBoltDriverPool_ReplaceRemovesRefs(t *testing.T) {
+	// Every connection is closed after INIT, so each borrow replaces it
+	listener := listenBolt(t, true)
+	defer listener.Close()
+
+	pool, err := createDriverPool("bolt://"+listener.Addr().String(), 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating pool: %s", err)
+	}
+	defer pool.Close()
+
+	for i := 0; i < 5; i++ {
+		conn, err := pool.OpenPool()
+		if err != nil {
+			t.Fatalf("An error occurred opening connection: %s", err)
+		}
+		if err := conn.Close(); err != nil {
+			t.Fatalf("An error occurred returning connection: %s", err)
+		}
+	}
+
+	pool.connsLock.Lock()
+	defer pool.connsLock.Unlock()
+	if len(pool.connRefs) != 1 {
+		t.Fatalf("Expected only the connection in the pool to be referenced. Got: %d", len(pool.connRefs))
+	}
+}
+
+// serveBolt answers the handshake and every message with SUCCESS after the
+// delay, closing the connection after answering INIT if closeAfterInit is set
+func serveBolt(server net.Conn, closeAfterInit bool, delay time.Duration) {
+	defer server.Close()
+	preamble := make([]byte, 20)
+	if _, err := io.ReadFull(server, preamble); err != nil {
+		return
+	}
+	if _, err := server.Write([]byte{0x00, 0x00, 0x00, 0x01}); err != nil {
+		return
+	}
+
+	decoder := encoding.NewDecoder(server)
+	decoder.SetRawStructures(true)
+	encoder := encoding.NewEncoder(server, math.MaxUint16)
+	for {
+		if _, err := decoder.Decode(); err != nil {
+			return
+		}
+		time.Sleep(delay)
+		if err := encoder.Encode(messages.NewSuccessMessage(nil)); err != nil {
+			return
+		}
+		if closeAfterInit {
+			return
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package golangNeo4jBoltDriver
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+// DefaultIngestBatchSize is the number of documents written in each
+// transaction when IngestOptions doesn't set one
+const DefaultIngestBatchSize = 1000
+
+// IngestOptions configures how documents are written by IngestJSON and
+// IngestDocuments
+type IngestOptions struct {
+	// BatchSize is the number of documents written in each transaction.
+	// Defaults to DefaultIngestBatchSize.
+	BatchSize int
+	// Param is the name of the parameter the batch of documents is passed
+	// in, e.g. `UNWIND {rows} AS row CREATE (n:Person) SET n = row`.
+	// Defaults to "rows".
+	Param string
+	// Retry is how a batch that fails on a deadlock is run again, in a new
+	// transaction.  The zero value doesn't retry.
+	Retry RetryPolicy
+	// Progress is called after each batch is committed
+	Progress func(IngestProgress)
+}
+
+// IngestProgress is how far an ingest has got
+type IngestProgress struct {
+	// Batches is the number of batches committed
+	Batches int
+	// Documents is the number of documents committed
+	Documents int
+}
+
+// IngestJSON reads JSON documents, one object per line, and writes them in
+// batches with the query, each batch in its own transaction.  Numbers that
+// are whole are passed as integers, and the rest as floats.  Returns how
+// much was committed, which on an error is everything before the failed
+// batch.
+func IngestJSON(conn Conn, query string, r io.Reader, opts IngestOptions) (IngestProgress, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	read := 0
+	next := func() (map[string]interface{}, error) {
+		var doc interface{}
+		if err := decoder.Decode(&doc); err == io.EOF {
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, errors.Wrap(err, "An error occurred reading JSON document %d", read)
+		}
+
+		mapp, ok := jsonParam(doc).(map[string]interface{})
+		if !ok {
+			return nil, errors.New("JSON document %d is a %T, not an object", read, doc)
+		}
+		read++
+		return mapp, nil
+	}
+
+	return ingest(conn, query, next, opts, time.Sleep)
+}
+
+// IngestDocuments writes the documents received from the channel in
+// batches with the query, each batch in its own transaction, until the
+// channel is closed.  On an error, it stops receiving from the channel,
+// so a producer should be able to stop sending without blocking.
+func IngestDocuments(conn Conn, query string, docs <-chan map[string]interface{}, opts IngestOptions) (IngestProgress, error) {
+	next := func() (map[string]interface{}, error) {
+		doc, ok := <-docs
+		if !ok {
+			return nil, io.EOF
+		}
+		return doc, nil
+	}
+
+	return ingest(conn, query, next, opts, time.Sleep)
+}
+
+// jsonParam converts a value decoded from JSON with UseNumber into a
+// parameter, parsing numbers as integers where they're whole
+func jsonParam(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = jsonParam(v)
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = jsonParam(v)
+		}
+	}
+	return value
+}
+
+func ingest(conn Conn, query string, next func() (map[string]interface{}, error), opts IngestOptions, sleep func(time.Duration)) (IngestProgress, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultIngestBatchSize
+	}
+	if opts.Param == "" {
+		opts.Param = "rows"
+	}
+
+	logger := conn.Options().Logger
+	var progress IngestProgress
+	for {
+		batch := make([]interface{}, 0, opts.BatchSize)
+		var readErr error
+		for len(batch) < opts.BatchSize {
+			doc, err := next()
+			if err != nil {
+				readErr = err
+				break
+			}
+			batch = append(batch, doc)
+		}
+
+		if len(batch) > 0 {
+			if err := ingestBatch(conn, logger, query, map[string]interface{}{opts.Param: batch}, opts.Retry, sleep); err != nil {
+				return progress, errors.Wrap(err, "An error occurred writing batch %d", progress.Batches)
+			}
+			progress.Batches++
+			progress.Documents += len(batch)
+			if opts.Progress != nil {
+				opts.Progress(progress)
+			}
+		}
+
+		if readErr == io.EOF {
+			return progress, nil
+		} else if readErr != nil {
+			return progress, readErr
+		}
+	}
+}
+
+// ingestBatch writes a batch in a transaction, running it again in a new
+// transaction when it fails on a deadlock, as the retry policy allows
+func ingestBatch(conn Conn, logger *log.Logger, query string, params map[string]interface{}, policy RetryPolicy, sleep func(time.Duration)) error {
+	return retryWork(logger, policy, IsDeadlock, sleep, func() error {
+		return ingestBatchOnce(conn, logger, query, params)
+	})
+}
+
+func ingestBatchOnce(conn Conn, logger *log.Logger, query string, params map[string]interface{}) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecNeo(query, params); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.Errorf("An error occurred rolling back failed batch: %s", rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,172 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// ingestServer answers every message with a success, and sends the
+// query and parameters of each RUN it receives
+func ingestServer(server net.Conn, runs chan<- []interface{}) {
+	defer close(runs)
+	decoder := encoding.NewDecoder(server)
+	decoder.SetRawStructures(true)
+
+	// Replies are written separately, so the client isn't blocked sending
+	// a PULL_ALL while a reply to its RUN waits to be read
+	replies := make(chan struct{}, 100)
+	defer close(replies)
+	go func() {
+		success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+		for range replies {
+			server.Write(success)
+		}
+	}()
+
+	for {
+		msg, err := decoder.Decode()
+		if err != nil {
+			return
+		}
+		if raw, ok := msg.(structures.Raw); ok && raw.Signature == messages.RunMessageSignature {
+			runs <- raw.Fields
+		}
+		replies <- struct{}{}
+	}
+}
+
+func TestIngestJSON(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	runs := make(chan []interface{}, 100)
+	go ingestServer(server, runs)
+
+	input := strings.NewReader(`{"name": "a", "age": 1}
+{"name": "b", "score": 1.5}
+{"name": "c", "tags": ["x", "y"]}
+`)
+	var reported []IngestProgress
+	progress, err := IngestJSON(c, "UNWIND {docs} AS doc CREATE (n:Person) SET n = doc", input, IngestOptions{
+		BatchSize: 2,
+		Param:     "docs",
+		Progress:  func(p IngestProgress) { reported = append(reported, p) },
+	})
+	if err != nil {
+		t.Fatalf("An error occurred ingesting JSON: %s", err)
+	}
+	if progress != (IngestProgress{Batches: 2, Documents: 3}) {
+		t.Fatalf("Unexpected progress: %#v", progress)
+	}
+	expected := []IngestProgress{{Batches: 1, Documents: 2}, {Batches: 2, Documents: 3}}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Fatalf("Expected progress after each batch. Expected: %#v. Got: %#v", expected, reported)
+	}
+
+	client.Close()
+	var queries []string
+	var batches [][]interface{}
+	for run := range runs {
+		query := run[0].(string)
+		queries = append(queries, query)
+		if strings.HasPrefix(query, "UNWIND") {
+			batches = append(batches, run[1].(map[string]interface{})["docs"].([]interface{}))
+		}
+	}
+
+	expectedQueries := []string{"BEGIN", "UNWIND {docs} AS doc CREATE (n:Person) SET n = doc", "COMMIT"}
+	expectedQueries = append(expectedQueries, expectedQueries...)
+	if !reflect.DeepEqual(queries, expectedQueries) {
+		t.Fatalf("Expected each batch in its own transaction. Got: %#v", queries)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("Unexpected batches: %#v", batches)
+	}
+	if age := batches[0][0].(map[string]interface{})["age"]; age != int64(1) {
+		t.Fatalf("Expected whole numbers to be sent as integers. Got: %#v", age)
+	}
+	if score := batches[0][1].(map[string]interface{})["score"]; score != 1.5 {
+		t.Fatalf("Expected other numbers to be sent as floats. Got: %#v", score)
+	}
+}
+
+func TestIngestJSON_InvalidDocument(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	runs := make(chan []interface{}, 100)
+	go ingestServer(server, runs)
+	defer client.Close()
+
+	progress, err := IngestJSON(c, "UNWIND {rows} AS row CREATE (n) SET n = row", strings.NewReader("{\"a\": 1}\n[1, 2]\n"), IngestOptions{})
+	if err == nil || !strings.Contains(err.Error(), "not an object") {
+		t.Fatalf("Expected an error for a document that isn't an object. Got: %v", err)
+	}
+	if progress != (IngestProgress{Batches: 1, Documents: 1}) {
+		t.Fatalf("Expected the documents before the invalid one to be written. Got: %#v", progress)
+	}
+}
+
+func TestIngestDocuments_Retry(t *testing.T) {
+	var attempts int
+	var sleeps []time.Duration
+	conn := &ingestFailConn{fail: 2, attempts: &attempts}
+	docs := make(chan map[string]interface{}, 1)
+	docs <- map[string]interface{}{"a": int64(1)}
+	close(docs)
+
+	next := func() (map[string]interface{}, error) {
+		doc, ok := <-docs
+		if !ok {
+			return nil, io.EOF
+		}
+		return doc, nil
+	}
+	policy := RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Second}
+	progress, err := ingest(conn, "UNWIND {rows} AS row CREATE (n) SET n = row", next, IngestOptions{Retry: policy}, func(d time.Duration) { sleeps = append(sleeps, d) })
+	if err != nil {
+		t.Fatalf("Expected the deadlocked batch to be retried. Got: %s", err)
+	}
+	if attempts != 3 || len(sleeps) != 2 || progress.Documents != 1 {
+		t.Fatalf("Unexpected retries. Attempts: %d, Sleeps: %v, Progress: %#v", attempts, sleeps, progress)
+	}
+}
+
+// ingestFailConn deadlocks the first fail batches it runs
+type ingestFailConn struct {
+	Conn
+	fail     int
+	attempts *int
+}
+
+func (c *ingestFailConn) Options() ConnOptions {
+	return ConnOptions{}
+}
+
+func (c *ingestFailConn) Begin() (driver.Tx, error) {
+	return ingestTx{}, nil
+}
+
+func (c *ingestFailConn) ExecNeo(query string, params map[string]interface{}) (Result, error) {
+	*c.attempts++
+	if *c.attempts <= c.fail {
+		return nil, messages.NewFailureMessage(map[string]interface{}{"code": DeadlockCode})
+	}
+	return nil, nil
+}
+
+type ingestTx struct{}
+
+func (ingestTx) Commit() error   { return nil }
+func (ingestTx) Rollback() error { return nil }
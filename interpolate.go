@@ -0,0 +1,254 @@
+package golangNeo4jBoltDriver
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+// interpolatedHeader marks interpolated queries, so they aren't mistaken for
+// what was sent to the server
+const interpolatedHeader = "// DEBUG ONLY: parameters interpolated by SafeInterpolate, not how the query was sent\n"
+
+// SafeInterpolate renders the query with its parameters substituted as
+// Cypher literals, e.g. to copy a statement from the logs into Neo4j
+// Browser.  Both $name and the older {name} parameters are replaced, but
+// not inside string literals, quoted names or comments.  Strings are quoted
+// and escaped, and temporal values and points are written as calls to the
+// functions that build them.  Parameters that aren't given are left as is.
+//
+// The output starts with a comment marking it as debug output.  It's for
+// reading and reproducing problems only: never run interpolated queries
+// instead of sending parameters, as that defeats the server's query cache.
+func SafeInterpolate(query string, params map[string]interface{}) string {
+	var out bytes.Buffer
+	out.WriteString(interpolatedHeader)
+
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end := quotedEnd(query, i)
+			out.WriteString(query[i:end])
+			i = end
+		case strings.HasPrefix(query[i:], "//"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			out.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			out.WriteString(query[i : i+end])
+			i += end
+		case c == '$' || c == '{':
+			name, end := parameterAt(query, i)
+			value, ok := params[name]
+			if name == "" || !ok {
+				out.WriteByte(c)
+				i++
+				continue
+			}
+			out.WriteString(cypherLiteral(value))
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// quotedEnd finds the end of the string literal or quoted name starting at i
+func quotedEnd(query string, i int) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// parameterAt reads the name of a $name, $`name` or {name} parameter at i,
+// returning an empty name if there isn't one
+func parameterAt(query string, i int) (string, int) {
+	if query[i] == '{' {
+		start := i + 1
+		for start < len(query) && query[start] == ' ' {
+			start++
+		}
+		end := identifierEnd(query, start)
+		close := end
+		for close < len(query) && query[close] == ' ' {
+			close++
+		}
+		if end == start || close >= len(query) || query[close] != '}' {
+			return "", i
+		}
+		return query[start:end], close + 1
+	}
+
+	if i+1 < len(query) && query[i+1] == '`' {
+		end := quotedEnd(query, i+1)
+		if end-i < 3 || query[end-1] != '`' {
+			return "", i
+		}
+		return query[i+2 : end-1], end
+	}
+	end := identifierEnd(query, i+1)
+	return query[i+1 : end], end
+}
+
+// identifierEnd finds the end of the name or number starting at i
+func identifierEnd(query string, i int) int {
+	for i < len(query) {
+		c := rune(query[i])
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// cypherLiteral writes the value as a Cypher literal
+func cypherLiteral(value interface{}) string {
+	switch val := encoding.Underlying(value).(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(val)
+	case string:
+		return cypherString(val)
+	case float32:
+		return cypherFloat(float64(val))
+	case float64:
+		return cypherFloat(val)
+	case []byte:
+		items := make([]string, len(val))
+		for i, b := range val {
+			items[i] = strconv.Itoa(int(b))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case time.Time:
+		literal := val.Format("2006-01-02T15:04:05.999999999Z07:00")
+		if name := val.Location().String(); name != "" && name != "UTC" && name != "Local" {
+			literal += "[" + name + "]"
+		}
+		return "datetime(" + cypherString(literal) + ")"
+	case temporal.Date:
+		return "date(" + cypherString(val.Format("2006-01-02")) + ")"
+	case temporal.Time:
+		return "time(" + cypherString(val.Format("15:04:05.999999999Z07:00")) + ")"
+	case temporal.LocalTime:
+		return "localtime(" + cypherString(val.Format("15:04:05.999999999")) + ")"
+	case temporal.LocalDateTime:
+		return "localdatetime(" + cypherString(val.Format("2006-01-02T15:04:05.999999999")) + ")"
+	case temporal.Duration:
+		return "duration(" + cypherString(val.String()) + ")"
+	case graph.Point2D:
+		return fmt.Sprintf("point({srid: %d, x: %s, y: %s})", val.SRID, cypherFloat(val.X), cypherFloat(val.Y))
+	case graph.Point3D:
+		return fmt.Sprintf("point({srid: %d, x: %s, y: %s, z: %s})", val.SRID, cypherFloat(val.X), cypherFloat(val.Y), cypherFloat(val.Z))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = cypherName(key) + ": " + cypherLiteral(val[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(reflected.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(reflected.Uint(), 10)
+	case reflect.Slice, reflect.Array:
+		items := make([]string, reflected.Len())
+		for i := range items {
+			items[i] = cypherLiteral(reflected.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprintf("null /* %T can't be written as a literal */", value)
+}
+
+// cypherFloat writes the float so Cypher reads it as a float, not an integer
+func cypherFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "(0.0 / 0.0)"
+	case math.IsInf(f, 1):
+		return "(1.0 / 0.0)"
+	case math.IsInf(f, -1):
+		return "(-1.0 / 0.0)"
+	}
+	literal := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(literal, ".eE") {
+		literal += ".0"
+	}
+	return literal
+}
+
+// cypherString quotes and escapes the string as a Cypher string literal
+func cypherString(s string) string {
+	var out bytes.Buffer
+	out.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			out.WriteString(`\\`)
+		case '\'':
+			out.WriteString(`\'`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&out, `\u%04X`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('\'')
+	return out.String()
+}
+
+// cypherName quotes map keys that aren't plain identifiers
+func cypherName(name string) string {
+	if name != "" && identifierEnd(name, 0) == len(name) && !unicode.IsDigit(rune(name[0])) {
+		return name
+	}
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
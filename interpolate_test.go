@@ -0,0 +1,46 @@
+package golangNeo4jBoltDriver
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+func TestSafeInterpolate(t *testing.T) {
+	params := map[string]interface{}{
+		"name":  "O'Brien \\ \"Bob\"\n",
+		"age":   int32(42),
+		"score": 3.0,
+		"nan":   math.NaN(),
+		"tags":  []string{"a", "b"},
+		"props": map[string]interface{}{"x": nil, "has space": true},
+		"born":  temporal.NewDate(1980, time.May, 6),
+		"at":    time.Date(2018, time.March, 4, 10, 30, 15, 0, time.FixedZone("", 3600)),
+		"where": graph.Point2D{SRID: graph.SRIDCartesian, X: 1, Y: 2.5},
+		"0":     "first",
+	}
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"CREATE (n:Person {name: $name, age: {age}})", `CREATE (n:Person {name: 'O\'Brien \\ "Bob"\n', age: 42})`},
+		{"RETURN $score, $nan, $tags, $props", "RETURN 3.0, (0.0 / 0.0), ['a', 'b'], {`has space`: true, x: null}"},
+		{"RETURN $born, $at, $where, $0", "RETURN date('1980-05-06'), datetime('2018-03-04T10:30:15+01:00'), point({srid: 7203, x: 1.0, y: 2.5}), 'first'"},
+		{"RETURN '$name', \"{age}\", `$name` // $name\n/* {age} */ $missing, $`name`", "RETURN '$name', \"{age}\", `$name` // $name\n/* {age} */ $missing, 'O\\'Brien \\\\ \"Bob\"\\n'"},
+		{"MATCH (n) RETURN n {.name, age: 1}, {}, 'it\\'s $name'", "MATCH (n) RETURN n {.name, age: 1}, {}, 'it\\'s $name'"},
+	}
+
+	for _, test := range tests {
+		interpolated := SafeInterpolate(test.query, params)
+		if !strings.HasPrefix(interpolated, interpolatedHeader) {
+			t.Fatalf("Expected the output to be marked as debug only. Got: %s", interpolated)
+		}
+		if query := strings.TrimPrefix(interpolated, interpolatedHeader); query != test.expected {
+			t.Fatalf("Unexpected interpolated query.\nExpected: %s\nGot: %s", test.expected, query)
+		}
+	}
+}
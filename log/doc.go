@@ -2,5 +2,15 @@
 
 There are 3 logging levels - trace, info and error.  Setting trace would also set info and error logs.
 You can use the SetLevel("trace") to set trace logging, for example.
+
+Logs go to stderr by default.  Use SetOutput to send them elsewhere, or as lines of JSON
+with JSONFormat.  To separate the logs of a driver or connection from the rest, give it
+its own Logger from New, e.g. with ConnOptions.Logger.  A Logger can also have its own level,
+e.g. to trace a single misbehaving pool.
+
+During an outage, every connection can log the same error.  SetErrorRateLimit logs each
+distinct error once per window, followed by a count of how many times it repeated.  Errors
+are told apart by the format they're logged with, since the messages differ in details like
+local ports.
 */
 package log
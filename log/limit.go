@@ -0,0 +1,88 @@
+package log
+
+import (
+	"fmt"
+	l "log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorLimiter drops repeated error logs, so an outage doesn't flood the logs
+// with the same line from every connection
+var errorLimiter = &limiter{repeats: map[repeatKey]int{}}
+
+// SetErrorRateLimit logs each distinct error at most once per window.  Errors
+// are told apart by their format string, not the message, since the errors
+// formatted into it differ from one connection to the next, e.g. in their
+// local ports and stack traces.  Repeats within the window are counted, and
+// logged as a single "repeated N times" line when the window ends.  0
+// disables the limit.
+func SetErrorRateLimit(window time.Duration) {
+	errorLimiter.lock.Lock()
+	defer errorLimiter.lock.Unlock()
+	errorLimiter.window = window
+}
+
+type repeatKey struct {
+	logger *l.Logger
+	format string
+}
+
+// argsFormat gets a format for Println style arguments to key repeats on:
+// strings are kept, and other values are replaced by their type
+func argsFormat(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			parts[i] = s
+		} else {
+			parts[i] = fmt.Sprintf("%T", arg)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type limiter struct {
+	window  time.Duration
+	repeats map[repeatKey]int
+	lock    sync.Mutex
+}
+
+// output writes the message to the logger, unless a message with the same
+// format was already written within the window
+func (lim *limiter) output(logger *l.Logger, format string, msg string) {
+	lim.lock.Lock()
+	if lim.window <= 0 {
+		lim.lock.Unlock()
+		logger.Print(msg)
+		return
+	}
+
+	key := repeatKey{logger: logger, format: format}
+	if _, ok := lim.repeats[key]; ok {
+		lim.repeats[key]++
+		lim.lock.Unlock()
+		return
+	}
+
+	lim.repeats[key] = 0
+	window := lim.window
+	lim.lock.Unlock()
+
+	logger.Print(msg)
+	time.AfterFunc(window, func() { lim.flush(key, msg, window) })
+}
+
+// flush ends the window for a format, summarizing any repeats with the first
+// line of the message first written
+func (lim *limiter) flush(key repeatKey, msg string, window time.Duration) {
+	lim.lock.Lock()
+	count := lim.repeats[key]
+	delete(lim.repeats, key)
+	lim.lock.Unlock()
+
+	if count > 0 {
+		key.logger.Printf("%s (repeated %d times in the last %s)", strings.SplitN(msg, "\n", 2)[0], count, window)
+	}
+}
@@ -1,9 +1,13 @@
 package log
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	l "log"
 	"os"
 	"strings"
+	"time"
 )
 
 // Level is the logging level
@@ -30,17 +34,170 @@ var (
 	TraceLog = l.New(os.Stderr, "[BOLT][TRACE]", l.LstdFlags)
 )
 
+// Format is the output format of the logs
+type Format int
+
+const (
+	// TextFormat writes logs as prefixed lines of text
+	TextFormat Format = iota
+	// JSONFormat writes logs as lines of JSON, with time, level and msg keys
+	JSONFormat Format = iota
+)
+
+// Logger is a set of loggers that can be given to a driver or connection,
+// to separate its logs from the package level loggers.  It logs at the
+// package level set with SetLevel, unless given its own with Logger.SetLevel.
+//
+// A nil *Logger logs to the package level loggers.
+type Logger struct {
+	// ErrorLog is the logger for error logging
+	ErrorLog *l.Logger
+	// InfoLog is the logger for info logging
+	InfoLog *l.Logger
+	// TraceLog is the logger for trace logging
+	TraceLog *l.Logger
+
+	level    Level
+	hasLevel bool
+}
+
+// SetLevel sets the logging level of this logger, overriding the package level.
+// levelStr should be one of "trace", "info", or "error"
+func (lg *Logger) SetLevel(levelStr string) {
+	lg.level = parseLevel(levelStr)
+	lg.hasLevel = true
+}
+
+// GetLevel gets the logging level of this logger
+func (lg *Logger) GetLevel() Level {
+	if lg == nil || !lg.hasLevel {
+		return level
+	}
+	return lg.level
+}
+
+// New creates a Logger writing all levels to w in the given format
+func New(w io.Writer, format Format) *Logger {
+	return &Logger{
+		ErrorLog: newLogger(w, format, "ERROR"),
+		InfoLog:  newLogger(w, format, "INFO"),
+		TraceLog: newLogger(w, format, "TRACE"),
+	}
+}
+
+// SetOutput points the package level loggers at w in the given format
+func SetOutput(w io.Writer, format Format) {
+	ErrorLog = newLogger(w, format, "ERROR")
+	InfoLog = newLogger(w, format, "INFO")
+	TraceLog = newLogger(w, format, "TRACE")
+}
+
+func newLogger(w io.Writer, format Format, levelName string) *l.Logger {
+	if format == JSONFormat {
+		return l.New(&jsonWriter{w: w, level: strings.ToLower(levelName)}, "", 0)
+	}
+	return l.New(w, "[BOLT]["+levelName+"]", l.LstdFlags)
+}
+
+// jsonWriter writes each log line as a line of JSON
+type jsonWriter struct {
+	w     io.Writer
+	level string
+}
+
+func (j *jsonWriter) Write(b []byte) (int, error) {
+	line, err := json.Marshal(map[string]string{
+		"time":  time.Now().Format(time.RFC3339Nano),
+		"level": j.level,
+		"msg":   strings.TrimSuffix(string(b), "\n"),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := j.w.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (lg *Logger) errorLog() *l.Logger {
+	if lg == nil || lg.ErrorLog == nil {
+		return ErrorLog
+	}
+	return lg.ErrorLog
+}
+
+func (lg *Logger) infoLog() *l.Logger {
+	if lg == nil || lg.InfoLog == nil {
+		return InfoLog
+	}
+	return lg.InfoLog
+}
+
+func (lg *Logger) traceLog() *l.Logger {
+	if lg == nil || lg.TraceLog == nil {
+		return TraceLog
+	}
+	return lg.TraceLog
+}
+
+// Trace writes a trace log in the format of Println
+func (lg *Logger) Trace(args ...interface{}) {
+	if lg.GetLevel() >= TraceLevel {
+		lg.traceLog().Println(args...)
+	}
+}
+
+// Tracef writes a trace log in the format of Printf
+func (lg *Logger) Tracef(msg string, args ...interface{}) {
+	if lg.GetLevel() >= TraceLevel {
+		lg.traceLog().Printf(msg, args...)
+	}
+}
+
+// Info writes an info log in the format of Println
+func (lg *Logger) Info(args ...interface{}) {
+	if lg.GetLevel() >= InfoLevel {
+		lg.infoLog().Println(args...)
+	}
+}
+
+// Infof writes an info log in the format of Printf
+func (lg *Logger) Infof(msg string, args ...interface{}) {
+	if lg.GetLevel() >= InfoLevel {
+		lg.infoLog().Printf(msg, args...)
+	}
+}
+
+// Error writes an error log in the format of Println
+func (lg *Logger) Error(args ...interface{}) {
+	if lg.GetLevel() >= ErrorLevel {
+		errorLimiter.output(lg.errorLog(), argsFormat(args), fmt.Sprintln(args...))
+	}
+}
+
+// Errorf writes an error log in the format of Printf
+func (lg *Logger) Errorf(msg string, args ...interface{}) {
+	if lg.GetLevel() >= ErrorLevel {
+		errorLimiter.output(lg.errorLog(), msg, fmt.Sprintf(msg, args...))
+	}
+}
+
 // SetLevel sets the logging level of this package. levelStr should be one of "trace", "info", or "error
 func SetLevel(levelStr string) {
+	level = parseLevel(levelStr)
+}
+
+func parseLevel(levelStr string) Level {
 	switch strings.ToLower(levelStr) {
 	case "trace":
-		level = TraceLevel
+		return TraceLevel
 	case "info":
-		level = InfoLevel
+		return InfoLevel
 	case "error":
-		level = ErrorLevel
+		return ErrorLevel
 	default:
-		level = NoneLevel
+		return NoneLevel
 	}
 }
 
@@ -80,14 +237,14 @@ func Infof(msg string, args ...interface{}) {
 // Error writes an error log in the format of Println
 func Error(args ...interface{}) {
 	if level >= ErrorLevel {
-		ErrorLog.Println(args...)
+		errorLimiter.output(ErrorLog, argsFormat(args), fmt.Sprintln(args...))
 	}
 }
 
 // Errorf writes an error log in the format of Printf
 func Errorf(msg string, args ...interface{}) {
 	if level >= ErrorLevel {
-		ErrorLog.Printf(msg, args...)
+		errorLimiter.output(ErrorLog, msg, fmt.Sprintf(msg, args...))
 	}
 }
 
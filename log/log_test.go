@@ -0,0 +1,140 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+func TestLogger(t *testing.T) {
+	defer SetLevel("")
+	SetLevel("info")
+
+	text := &bytes.Buffer{}
+	New(text, TextFormat).Infof("Hello %s", "world")
+	if !strings.HasPrefix(text.String(), "[BOLT][INFO]") || !strings.HasSuffix(text.String(), "Hello world\n") {
+		t.Fatalf("Unexpected text log: %q", text.String())
+	}
+
+	jsonOut := &bytes.Buffer{}
+	logger := New(jsonOut, JSONFormat)
+	logger.Error("An error")
+	logger.Trace("Not logged at info level")
+
+	var line map[string]string
+	if err := json.Unmarshal(jsonOut.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a single line of JSON. Got %q: %s", jsonOut.String(), err)
+	}
+	if line["level"] != "error" || line["msg"] != "An error" || line["time"] == "" {
+		t.Fatalf("Unexpected JSON log: %#v", line)
+	}
+}
+
+func TestErrorRateLimit(t *testing.T) {
+	defer SetLevel("")
+	defer SetErrorRateLimit(0)
+	SetLevel("error")
+	SetErrorRateLimit(50 * time.Millisecond)
+
+	out := &syncBuffer{}
+	logger := New(out, JSONFormat)
+	for i := 0; i < 5; i++ {
+		logger.Error("Bad Connection state detected")
+	}
+	logger.Errorf("A different error")
+
+	time.Sleep(100 * time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 log lines. Got: %q", lines)
+	}
+	if !strings.Contains(lines[2], "Bad Connection state detected (repeated 4 times") {
+		t.Fatalf("Expected a summary of the repeats. Got: %q", lines[2])
+	}
+}
+
+func TestErrorRateLimit_WrappedErrors(t *testing.T) {
+	defer SetLevel("")
+	defer SetErrorRateLimit(0)
+	SetLevel("error")
+	SetErrorRateLimit(50 * time.Millisecond)
+
+	out := &syncBuffer{}
+	logger := New(out, TextFormat)
+	// Errors from each connection differ in their local port and stack trace
+	for i := 0; i < 5; i++ {
+		opErr := &net.OpError{
+			Op:     "read",
+			Net:    "tcp",
+			Source: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000 + i},
+			Addr:   &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7687},
+			Err:    syscall.ECONNRESET,
+		}
+		err := errors.Wrap(opErr, "An error occurred reading from stream")
+		logger.Errorf("Idle monitor detected a bad connection: %s", err)
+		logger.Error(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	output := out.String()
+	if count := strings.Count(output, "Internal Error(*net.OpError)"); count != 2 {
+		t.Fatalf("Expected each error to be logged once. Got %d:\n%s", count, output)
+	}
+	if !strings.Contains(output, "Idle monitor detected a bad connection: An error occurred reading from stream (repeated 4 times") {
+		t.Fatalf("Expected a summary of the repeated errors. Got:\n%s", output)
+	}
+	if count := strings.Count(output, "An error occurred reading from stream (repeated 4 times"); count != 2 {
+		t.Fatalf("Expected a summary of the repeated wrapped errors. Got:\n%s", output)
+	}
+	if strings.Contains(output, "127.0.0.1:50001") {
+		t.Fatalf("Expected the repeats not to be logged. Got:\n%s", output)
+	}
+}
+
+// syncBuffer is a buffer that can be written to by the limiter's timers
+type syncBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerLevel(t *testing.T) {
+	defer SetLevel("")
+	SetLevel("error")
+
+	out := &bytes.Buffer{}
+	logger := New(out, TextFormat)
+	logger.Info("Not logged at the package level")
+	if out.Len() != 0 {
+		t.Fatalf("Expected no logs at the package level. Got: %q", out.String())
+	}
+
+	logger.SetLevel("trace")
+	logger.Trace("Logged at the logger's level")
+	if !strings.Contains(out.String(), "Logged at the logger's level") {
+		t.Fatalf("Expected trace log at the logger's level. Got: %q", out.String())
+	}
+	if GetLevel() != ErrorLevel {
+		t.Fatalf("Expected the package level to be unchanged. Got: %d", GetLevel())
+	}
+}
@@ -0,0 +1,79 @@
+package golangNeo4jBoltDriver
+
+import (
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// QueryMetrics describes a query once the end of its results is read,
+// so apps can monitor what they send, e.g. to alert on parameter maps
+// far larger than expected before the server feels the memory pressure.
+type QueryMetrics struct {
+	// Query is the text of the query, and Fingerprint identifies
+	// it without the text
+	Query       string
+	Fingerprint string
+	// Params is the number of parameters, and ParamBytes their encoded size
+	Params     int
+	ParamBytes int
+	// Rows is the number of records read.  It's 0 for Exec, as the server
+	// discards the records.
+	Rows int
+	// Duration is the time from sending the query to reading the end of its results
+	Duration time.Duration
+	// Failed is set if the server failed, or ignored, the query
+	Failed bool
+	start  time.Time
+}
+
+// MetricsHook is called with the metrics of each query run on a connection.
+// It's called from the go routine using the connection, so it should return quickly.
+type MetricsHook func(QueryMetrics)
+
+// SetMetricsHook sets the hook called with the metrics of each query
+func (c *boltConn) SetMetricsHook(hook MetricsHook) {
+	c.metricsHook = hook
+}
+
+// startMetrics starts the metrics of a query just sent, if there's a hook for
+// them.  They're attached to the PULL_ALL or DISCARD_ALL sent after it.
+func (c *boltConn) startMetrics(prepared *preparedQuery, params map[string]interface{}) {
+	if c.metricsHook == nil {
+		return
+	}
+
+	size, err := encoding.EstimateSize(params)
+	if err != nil {
+		c.logger.Errorf("An error occurred measuring query parameters: %s", err)
+	}
+	c.runMetrics = &QueryMetrics{
+		Query:       prepared.query,
+		Fingerprint: prepared.queryFingerprint(),
+		Params:      len(params),
+		ParamBytes:  size,
+		start:       time.Now(),
+	}
+}
+
+// attachMetrics attaches the metrics of the last query sent to the request
+// just sent, the response to which ends the results of the query
+func (c *boltConn) attachMetrics() {
+	if c.runMetrics == nil || len(c.inFlight) == 0 {
+		return
+	}
+	c.inFlight[len(c.inFlight)-1] = c.runMetrics
+	c.runMetrics = nil
+}
+
+// finishMetrics reports the metrics of a query to the hook, given the summary ending its results
+func (c *boltConn) finishMetrics(metrics *QueryMetrics, summary interface{}) {
+	if metrics == nil || c.metricsHook == nil {
+		return
+	}
+	_, success := summary.(messages.SuccessMessage)
+	metrics.Failed = !success
+	metrics.Duration = time.Since(metrics.start)
+	c.metricsHook(*metrics)
+}
@@ -0,0 +1,61 @@
+package golangNeo4jBoltDriver
+
+import (
+	"io"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+func TestBoltConn_MetricsHook(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	reported := []QueryMetrics{}
+	c.SetMetricsHook(func(metrics QueryMetrics) {
+		reported = append(reported, metrics)
+	})
+
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		// RUN and PULL_ALL
+		decoder.Decode()
+		decoder.Decode()
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}))
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(1)}))
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(2)}))
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+	}()
+
+	params := map[string]interface{}{"a": 1, "b": "xy"}
+	rows, err := c.QueryNeo("UNWIND [1, 2] AS n RETURN n", params)
+	if err != nil {
+		t.Fatalf("Error running query: %s", err)
+	}
+	for {
+		if _, _, err := rows.NextNeo(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Error reading rows: %s", err)
+		}
+	}
+	rows.Close()
+
+	size, _ := encoding.EstimateSize(params)
+	if len(reported) != 1 {
+		t.Fatalf("Expected metrics for a single query. Got: %#v", reported)
+	}
+	metrics := reported[0]
+	if metrics.Rows != 2 || metrics.Params != 2 || metrics.ParamBytes != size || metrics.Failed {
+		t.Fatalf("Unexpected query metrics: %#v", metrics)
+	}
+	if metrics.Fingerprint != queryFingerprint(metrics.Query) || metrics.Duration <= 0 {
+		t.Fatalf("Unexpected query metrics: %#v", metrics)
+	}
+}
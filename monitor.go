@@ -0,0 +1,63 @@
+package golangNeo4jBoltDriver
+
+import (
+	"net"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// startIdleMonitor starts a background read on an idle pooled connection, so
+// an unexpected EOF or message from the server (e.g. a FAILURE when the
+// server is shutting down) is noticed as soon as it happens, instead of by
+// the next borrower after a full query timeout.
+//
+// Enabled with the `idle_monitor` connection string parameter.
+func (c *boltConn) startIdleMonitor() {
+	if !c.idleMonitor || c.conn == nil || c.monitor != nil {
+		return
+	}
+
+	if err := c.conn.SetReadDeadline(time.Time{}); err != nil {
+		c.logger.Errorf("An error occurred clearing read deadline for idle monitor: %s", err)
+		return
+	}
+
+	monitor := make(chan error, 1)
+	c.monitor = monitor
+	go func(conn net.Conn) {
+		err := safely("monitoring an idle connection", func() error {
+			_, err := conn.Read(make([]byte, 1))
+			return err
+		})
+		if err == nil {
+			err = errors.New("Received unexpected data from server on idle connection")
+		}
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			c.logger.Errorf("Idle connection marked bad: %s", err)
+		}
+		monitor <- err
+	}(c.conn)
+}
+
+// stopIdleMonitor interrupts the background read on the connection, returning
+// an error if the monitor found the connection to be bad
+func (c *boltConn) stopIdleMonitor() error {
+	if c.monitor == nil {
+		return nil
+	}
+
+	monitor := c.monitor
+	c.monitor = nil
+
+	// Interrupt the read, the deadline is reset on the next Read
+	if err := c.conn.SetReadDeadline(time.Now()); err != nil {
+		return errors.Wrap(err, "An error occurred interrupting idle monitor")
+	}
+
+	err := <-monitor
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package golangNeo4jBoltDriver
+
+import (
+	"net"
+	"testing"
+)
+
+func TestBoltConn_IdleMonitor(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.idleMonitor = true
+
+	c.startIdleMonitor()
+	if err := c.stopIdleMonitor(); err != nil {
+		t.Fatalf("Unexpected error stopping idle monitor on healthy connection: %s", err)
+	}
+
+	c.startIdleMonitor()
+	server.Close()
+	if err := c.stopIdleMonitor(); err == nil {
+		t.Fatal("Expected idle monitor to detect closed connection")
+	}
+}
@@ -0,0 +1,119 @@
+package golangNeo4jBoltDriver
+
+import (
+	"sync"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// Multiplexer serializes queries from many go routines onto a single
+// connection.  Requests are queued, and run one at a time by a go routine
+// that owns the connection, with each response handed back to the
+// go routine that made the request.
+//
+// This is useful for highly concurrent read workloads where a connection
+// per go routine would open too many connections to Neo4j.  Since rows can't
+// be shared between go routines, query results are read in full before they
+// are returned.
+//
+// Multiplexer objects ARE THREAD SAFE.
+type Multiplexer struct {
+	conn     Conn
+	requests chan *muxRequest
+	closed   bool
+	lock     sync.RWMutex
+	done     sync.WaitGroup
+}
+
+type muxRequest struct {
+	query    string
+	params   map[string]interface{}
+	exec     bool
+	response chan *muxResponse
+}
+
+type muxResponse struct {
+	data     [][]interface{}
+	metadata map[string]interface{}
+	result   Result
+	err      error
+}
+
+// NewMultiplexer creates a new Multiplexer that takes ownership of the
+// connection.  queueSize is the number of requests that can wait for the
+// connection before callers block.
+func NewMultiplexer(conn Conn, queueSize int) *Multiplexer {
+	m := &Multiplexer{
+		conn:     conn,
+		requests: make(chan *muxRequest, queueSize),
+	}
+
+	m.done.Add(1)
+	go m.run()
+
+	return m
+}
+
+func (m *Multiplexer) run() {
+	defer m.done.Done()
+	for request := range m.requests {
+		response := &muxResponse{}
+		err := safely("running a multiplexed query", func() (err error) {
+			if request.exec {
+				response.result, err = m.conn.ExecNeo(request.query, request.params)
+			} else {
+				response.data, _, response.metadata, err = m.conn.QueryNeoAll(request.query, request.params)
+			}
+			return err
+		})
+		response.err = err
+		request.response <- response
+	}
+}
+
+func (m *Multiplexer) send(request *muxRequest) (*muxResponse, error) {
+	m.lock.RLock()
+	if m.closed {
+		m.lock.RUnlock()
+		return nil, errors.New("Multiplexer has been closed")
+	}
+	request.response = make(chan *muxResponse, 1)
+	m.requests <- request
+	m.lock.RUnlock()
+
+	return <-request.response, nil
+}
+
+// QueryNeoAll queries using the neo4j-specific interface, returning all rows
+// and the metadata from the end of the result
+func (m *Multiplexer) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, error) {
+	response, err := m.send(&muxRequest{query: query, params: params})
+	if err != nil {
+		return nil, nil, err
+	}
+	return response.data, response.metadata, response.err
+}
+
+// ExecNeo executes a query that returns no rows using the neo4j-specific interface
+func (m *Multiplexer) ExecNeo(query string, params map[string]interface{}) (Result, error) {
+	response, err := m.send(&muxRequest{query: query, params: params, exec: true})
+	if err != nil {
+		return nil, err
+	}
+	return response.result, response.err
+}
+
+// Close waits for all queued requests to finish, then closes the connection
+func (m *Multiplexer) Close() error {
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.requests)
+	m.lock.Unlock()
+
+	m.done.Wait()
+	return m.conn.Close()
+}
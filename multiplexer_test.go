@@ -0,0 +1,52 @@
+package golangNeo4jBoltDriver
+
+import "testing"
+
+func TestMultiplexer(t *testing.T) {
+	driver := NewDriver()
+
+	// Runs the same session as TestBoltConn_SelectAll through the multiplexer
+	driver.(*boltDriver).recorder = newRecorder("TestBoltConn_SelectAll", neo4jConnStr)
+
+	conn, err := driver.OpenNeo(neo4jConnStr)
+	if err != nil {
+		t.Fatalf("An error occurred opening conn: %s", err)
+	}
+
+	mux := NewMultiplexer(conn, 10)
+
+	results, err := mux.ExecNeo("CREATE (f:NODE {a: 1}), (b:NODE {a: 2})", nil)
+	if err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+	if affected, _ := results.RowsAffected(); affected != int64(2) {
+		t.Fatalf("Incorrect number of rows affected: %d", affected)
+	}
+
+	data, metadata, err := mux.QueryNeoAll("MATCH (n:NODE) RETURN n.a ORDER BY n.a", nil)
+	if err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+	if len(data) != 2 || data[0][0] != int64(1) || data[1][0] != int64(2) {
+		t.Fatalf("Incorrect data returned: %#v", data)
+	}
+	if metadata["type"].(string) != "r" {
+		t.Fatalf("Unexpected request metadata: %#v", metadata)
+	}
+
+	results, err = mux.ExecNeo("MATCH (n:NODE) DELETE n", nil)
+	if err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+	if affected, _ := results.RowsAffected(); affected != int64(2) {
+		t.Fatalf("Incorrect number of rows affected: %d", affected)
+	}
+
+	if err := mux.Close(); err != nil {
+		t.Fatalf("Error closing multiplexer: %s", err)
+	}
+
+	if _, err := mux.ExecNeo("RETURN 1", nil); err == nil {
+		t.Fatal("Expected error using closed multiplexer")
+	}
+}
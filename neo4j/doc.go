@@ -0,0 +1,26 @@
+/*Package neo4j is a migration shim exposing types shaped like the official
+neo4j-go-driver's Driver, Session, Transaction and Result interfaces, implemented
+on top of this bolt driver.
+
+It lets code written against the official driver run on this driver (for example,
+to use its recordings in tests), and lets large codebases migrate between the two
+a package at a time instead of all at once.
+
+Only the parts of the official API that map onto Bolt v1 are implemented:
+
+	driver, err := neo4j.NewDriver("bolt://localhost:7687", neo4j.BasicAuth("neo4j", "password", ""))
+	session, err := driver.Session(neo4j.AccessModeWrite)
+	result, err := session.Run("CREATE (n:NODE {a: $a}) RETURN n.a", map[string]interface{}{"a": 1})
+	for result.Next() {
+		fmt.Println(result.Record().GetByIndex(0))
+	}
+	err = result.Err()
+
+Some differences from the official driver to be aware of:
+
+* Results are read in full when the query is run, not streamed.
+* ReadTransaction and WriteTransaction only retry deadlocks, and only when
+Config.DeadlockRetry allows it.
+* The access mode is accepted but ignored, since there is no routing.
+*/
+package neo4j
@@ -0,0 +1,105 @@
+package neo4j
+
+import (
+	"net/url"
+
+	bolt "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// AccessMode defines the kind of work a session will do
+type AccessMode int
+
+const (
+	// AccessModeWrite means the session will write to the database
+	AccessModeWrite AccessMode = 0
+	// AccessModeRead means the session will only read from the database
+	AccessModeRead AccessMode = 1
+)
+
+// AuthToken holds the credentials used to connect to Neo4j
+type AuthToken struct {
+	username string
+	password string
+}
+
+// NoAuth is used when Neo4j has authentication disabled
+func NoAuth() AuthToken {
+	return AuthToken{}
+}
+
+// BasicAuth authenticates with a username and password.  The realm is
+// accepted for compatibility and ignored.
+func BasicAuth(username string, password string, realm string) AuthToken {
+	return AuthToken{username: username, password: password}
+}
+
+// Config holds the settings of a driver
+type Config struct {
+	// MaxConnectionPoolSize is the number of connections opened to Neo4j.
+	// Defaults to 10.
+	MaxConnectionPoolSize int
+	// DeadlockRetry is how ReadTransaction and WriteTransaction retry work
+	// that failed because Neo4j detected a deadlock.  Defaults to no retries.
+	DeadlockRetry bolt.RetryPolicy
+}
+
+// Driver creates sessions against a single Neo4j server
+//
+// Driver objects ARE THREAD SAFE.
+type Driver interface {
+	// Target gets the url the driver connects to
+	Target() url.URL
+	// Session creates a session which waits on the given bookmarks
+	// before beginning transactions
+	Session(accessMode AccessMode, bookmarks ...string) (Session, error)
+	// Close closes all of the connections opened by the driver
+	Close() error
+}
+
+type driver struct {
+	target url.URL
+	pool   bolt.ClosableDriverPool
+	retry  bolt.RetryPolicy
+}
+
+// NewDriver creates a driver connecting to the bolt:// url with the given credentials
+func NewDriver(target string, auth AuthToken, configurers ...func(*Config)) (Driver, error) {
+	config := &Config{MaxConnectionPoolSize: 10}
+	for _, configurer := range configurers {
+		configurer(config)
+	}
+
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, errors.Wrap(err, "An error occurred parsing target url: %s", target)
+	}
+
+	connURL := *targetURL
+	if auth.username != "" {
+		connURL.User = url.UserPassword(auth.username, auth.password)
+	}
+
+	pool, err := bolt.NewClosableDriverPool(connURL.String(), config.MaxConnectionPoolSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "An error occurred creating the connection pool")
+	}
+
+	return &driver{target: *targetURL, pool: pool, retry: config.DeadlockRetry}, nil
+}
+
+func (d *driver) Target() url.URL {
+	return d.target
+}
+
+func (d *driver) Session(accessMode AccessMode, bookmarks ...string) (Session, error) {
+	conn, err := d.pool.OpenPool()
+	if err != nil {
+		return nil, errors.Wrap(err, "An error occurred opening a connection for the session")
+	}
+	return newSession(conn, bookmarks, d.retry), nil
+}
+
+func (d *driver) Close() error {
+	return d.pool.Close()
+}
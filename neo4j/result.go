@@ -0,0 +1,203 @@
+package neo4j
+
+import (
+	"time"
+
+	bolt "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+)
+
+// Record is a row of a result
+type Record interface {
+	// Keys gets the names of the fields in the record
+	Keys() []string
+	// Values gets the values of the fields in the record
+	Values() []interface{}
+	// Get gets the value of the named field
+	Get(key string) (interface{}, bool)
+	// GetByIndex gets the value of the field at the index
+	GetByIndex(index int) interface{}
+}
+
+// Result is the outcome of running a query
+type Result interface {
+	// Keys gets the names of the fields in the result
+	Keys() ([]string, error)
+	// Next moves to the next record, returning false when there are no more
+	Next() bool
+	// Err gets the error that stopped the result, if any
+	Err() error
+	// Record gets the current record
+	Record() Record
+	// Summary gets the summary of the result
+	Summary() (ResultSummary, error)
+	// Consume discards the remaining records and gets the summary of the result
+	Consume() (ResultSummary, error)
+}
+
+// StatementType is the kind of query that was run
+type StatementType int
+
+const (
+	// StatementTypeUnknown means the kind of query isn't known
+	StatementTypeUnknown StatementType = 0
+	// StatementTypeReadOnly means the query only read data
+	StatementTypeReadOnly StatementType = 1
+	// StatementTypeReadWrite means the query read and wrote data
+	StatementTypeReadWrite StatementType = 2
+	// StatementTypeWriteOnly means the query only wrote data
+	StatementTypeWriteOnly StatementType = 3
+	// StatementTypeSchemaWrite means the query changed the schema
+	StatementTypeSchemaWrite StatementType = 4
+)
+
+// ResultSummary summarizes the outcome of a query
+type ResultSummary interface {
+	// StatementType gets the kind of query that was run
+	StatementType() StatementType
+	// Counters gets the update counters of the query
+	Counters() Counters
+	// ResultAvailableAfter is how long the server took to start streaming the result
+	ResultAvailableAfter() time.Duration
+	// ResultConsumedAfter is how long the server took to stream the result
+	ResultConsumedAfter() time.Duration
+}
+
+// Counters holds the update counters of a query
+type Counters interface {
+	NodesCreated() int
+	NodesDeleted() int
+	RelationshipsCreated() int
+	RelationshipsDeleted() int
+	PropertiesSet() int
+	LabelsAdded() int
+	LabelsRemoved() int
+	IndexesAdded() int
+	IndexesRemoved() int
+	ConstraintsAdded() int
+	ConstraintsRemoved() int
+}
+
+func run(conn bolt.Conn, cypher string, params map[string]interface{}) (Result, error) {
+	data, keys, summary, err := conn.QueryNeoAllSummary(cypher, params)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(data, keys, summary), nil
+}
+
+type result struct {
+	data    [][]interface{}
+	keys    []string
+	summary bolt.Summary
+	index   int
+	current Record
+}
+
+func newResult(data [][]interface{}, keys []string, summary bolt.Summary) *result {
+	return &result{data: data, keys: keys, summary: summary, index: -1}
+}
+
+func (r *result) Keys() ([]string, error) {
+	return r.keys, nil
+}
+
+func (r *result) Next() bool {
+	if r.index+1 >= len(r.data) {
+		r.index = len(r.data)
+		r.current = nil
+		return false
+	}
+	r.index++
+	r.current = &record{keys: r.keys, values: r.data[r.index]}
+	return true
+}
+
+// Err always returns nil, since results are read in full when the query is run
+func (r *result) Err() error {
+	return nil
+}
+
+func (r *result) Record() Record {
+	return r.current
+}
+
+func (r *result) Summary() (ResultSummary, error) {
+	return resultSummary{r.summary}, nil
+}
+
+func (r *result) Consume() (ResultSummary, error) {
+	r.index = len(r.data)
+	r.current = nil
+	return r.Summary()
+}
+
+type record struct {
+	keys   []string
+	values []interface{}
+}
+
+func (r *record) Keys() []string {
+	return r.keys
+}
+
+func (r *record) Values() []interface{} {
+	return r.values
+}
+
+func (r *record) Get(key string) (interface{}, bool) {
+	for i, k := range r.keys {
+		if k == key && i < len(r.values) {
+			return r.values[i], true
+		}
+	}
+	return nil, false
+}
+
+func (r *record) GetByIndex(index int) interface{} {
+	return r.values[index]
+}
+
+type resultSummary struct {
+	summary bolt.Summary
+}
+
+func (s resultSummary) StatementType() StatementType {
+	switch s.summary.Type {
+	case "r":
+		return StatementTypeReadOnly
+	case "rw":
+		return StatementTypeReadWrite
+	case "w":
+		return StatementTypeWriteOnly
+	case "s":
+		return StatementTypeSchemaWrite
+	default:
+		return StatementTypeUnknown
+	}
+}
+
+func (s resultSummary) Counters() Counters {
+	return counters(s.summary.Stats)
+}
+
+func (s resultSummary) ResultAvailableAfter() time.Duration {
+	return s.summary.ResultAvailableAfter
+}
+
+func (s resultSummary) ResultConsumedAfter() time.Duration {
+	return s.summary.ResultConsumedAfter
+}
+
+type counters map[string]int64
+
+func (c counters) NodesCreated() int         { return int(c["nodes-created"]) }
+func (c counters) NodesDeleted() int         { return int(c["nodes-deleted"]) }
+func (c counters) RelationshipsCreated() int { return int(c["relationships-created"]) }
+func (c counters) RelationshipsDeleted() int { return int(c["relationships-deleted"]) }
+func (c counters) PropertiesSet() int        { return int(c["properties-set"]) }
+func (c counters) LabelsAdded() int          { return int(c["labels-added"]) }
+func (c counters) LabelsRemoved() int        { return int(c["labels-removed"]) }
+func (c counters) IndexesAdded() int         { return int(c["indexes-added"]) }
+func (c counters) IndexesRemoved() int       { return int(c["indexes-removed"]) }
+func (c counters) ConstraintsAdded() int     { return int(c["constraints-added"]) }
+func (c counters) ConstraintsRemoved() int   { return int(c["constraints-removed"]) }
@@ -0,0 +1,36 @@
+package neo4j
+
+import (
+	"reflect"
+	"testing"
+
+	bolt "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+)
+
+func TestResult(t *testing.T) {
+	summary := bolt.Summary{Type: "rw", Stats: map[string]int64{"nodes-created": 2}}
+	res := newResult([][]interface{}{{int64(1), "a"}, {int64(2), "b"}}, []string{"n", "s"}, summary)
+
+	values := []interface{}{}
+	for res.Next() {
+		value, ok := res.Record().Get("s")
+		if !ok {
+			t.Fatalf("Expected value for key s. Got: %#v", res.Record())
+		}
+		values = append(values, res.Record().GetByIndex(0), value)
+	}
+	if !reflect.DeepEqual(values, []interface{}{int64(1), "a", int64(2), "b"}) {
+		t.Fatalf("Unexpected values: %#v", values)
+	}
+	if res.Record() != nil || res.Err() != nil {
+		t.Fatalf("Expected no record or error after the last record. Got: %#v %#v", res.Record(), res.Err())
+	}
+
+	resultSummary, err := res.Consume()
+	if err != nil {
+		t.Fatalf("Unexpected error consuming result: %s", err)
+	}
+	if resultSummary.StatementType() != StatementTypeReadWrite || resultSummary.Counters().NodesCreated() != 2 {
+		t.Fatalf("Unexpected summary: %#v", resultSummary)
+	}
+}
@@ -0,0 +1,192 @@
+package neo4j
+
+import (
+	sqldriver "database/sql/driver"
+
+	bolt "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// TransactionWork is a unit of work run in a transaction by
+// Session.ReadTransaction or Session.WriteTransaction
+type TransactionWork func(tx Transaction) (interface{}, error)
+
+// TransactionConfig holds the settings of a transaction.  None are
+// supported on Bolt v1, so it's accepted for compatibility and ignored.
+type TransactionConfig struct{}
+
+// Session runs queries and transactions on a connection borrowed from the driver
+//
+// Session objects ARE NOT THREAD SAFE.
+type Session interface {
+	// LastBookmark gets the bookmark of the last transaction committed in the session
+	LastBookmark() string
+	// BeginTransaction begins an explicit transaction
+	BeginTransaction(configurers ...func(*TransactionConfig)) (Transaction, error)
+	// ReadTransaction runs the work in a transaction, committing it if the work succeeds
+	ReadTransaction(work TransactionWork, configurers ...func(*TransactionConfig)) (interface{}, error)
+	// WriteTransaction runs the work in a transaction, committing it if the work succeeds
+	WriteTransaction(work TransactionWork, configurers ...func(*TransactionConfig)) (interface{}, error)
+	// Run runs an auto-commit query
+	Run(cypher string, params map[string]interface{}, configurers ...func(*TransactionConfig)) (Result, error)
+	// Close returns the session's connection to the driver
+	Close() error
+}
+
+// Transaction is an explicit transaction in a session
+type Transaction interface {
+	// Run runs a query in the transaction
+	Run(cypher string, params map[string]interface{}) (Result, error)
+	// Commit commits the transaction
+	Commit() error
+	// Rollback rolls back the transaction
+	Rollback() error
+	// Close rolls back the transaction, if it hasn't been committed or rolled back
+	Close() error
+}
+
+type session struct {
+	conn      bolt.Conn
+	bookmarks []string
+	tx        *transaction
+	retry     bolt.RetryPolicy
+}
+
+func newSession(conn bolt.Conn, bookmarks []string, retry bolt.RetryPolicy) *session {
+	return &session{conn: conn, bookmarks: bookmarks, retry: retry}
+}
+
+func (s *session) LastBookmark() string {
+	if bookmark := s.conn.LastBookmark(); bookmark != "" {
+		return bookmark
+	}
+	if len(s.bookmarks) > 0 {
+		return s.bookmarks[len(s.bookmarks)-1]
+	}
+	return ""
+}
+
+func (s *session) BeginTransaction(configurers ...func(*TransactionConfig)) (Transaction, error) {
+	return s.beginTransaction(false, configurers...)
+}
+
+// beginTransaction begins a transaction, in read access mode if read is set
+func (s *session) beginTransaction(read bool, configurers ...func(*TransactionConfig)) (Transaction, error) {
+	if s.tx != nil {
+		return nil, errors.New("A transaction is already open in the session")
+	}
+
+	var tx sqldriver.Tx
+	var err error
+	if read {
+		tx, err = s.conn.BeginReadWithBookmarks(s.bookmarks...)
+	} else {
+		tx, err = s.conn.BeginWithBookmarks(s.bookmarks...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.tx = &transaction{session: s, tx: tx}
+	return s.tx, nil
+}
+
+func (s *session) ReadTransaction(work TransactionWork, configurers ...func(*TransactionConfig)) (interface{}, error) {
+	return s.runTransaction(true, work, configurers...)
+}
+
+func (s *session) WriteTransaction(work TransactionWork, configurers ...func(*TransactionConfig)) (interface{}, error) {
+	return s.runTransaction(false, work, configurers...)
+}
+
+// runTransaction runs the work in a transaction, running it again in a new
+// transaction when it fails on a deadlock, as the retry policy allows
+func (s *session) runTransaction(read bool, work TransactionWork, configurers ...func(*TransactionConfig)) (interface{}, error) {
+	var output interface{}
+	err := bolt.Retry(s.retry, bolt.IsDeadlock, func() error {
+		var err error
+		output, err = s.runTransactionOnce(read, work, configurers...)
+		return err
+	})
+	return output, err
+}
+
+func (s *session) runTransactionOnce(read bool, work TransactionWork, configurers ...func(*TransactionConfig)) (interface{}, error) {
+	tx, err := s.beginTransaction(read, configurers...)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	output, err := work(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (s *session) Run(cypher string, params map[string]interface{}, configurers ...func(*TransactionConfig)) (Result, error) {
+	if s.tx != nil {
+		return nil, errors.New("Can't run an auto-commit query while a transaction is open in the session")
+	}
+	return run(s.conn, cypher, params)
+}
+
+func (s *session) Close() error {
+	if s.tx != nil {
+		s.tx.Close()
+	}
+	return s.conn.Close()
+}
+
+type transaction struct {
+	session *session
+	tx      sqldriver.Tx
+	done    bool
+}
+
+func (t *transaction) Run(cypher string, params map[string]interface{}) (Result, error) {
+	if t.done {
+		return nil, errors.New("Transaction has already been committed or rolled back")
+	}
+	return run(t.session.conn, cypher, params)
+}
+
+func (t *transaction) Commit() error {
+	if t.done {
+		return errors.New("Transaction has already been committed or rolled back")
+	}
+	t.finish()
+
+	if err := t.tx.Commit(); err != nil {
+		return err
+	}
+	if bookmark := t.session.conn.LastBookmark(); bookmark != "" {
+		t.session.bookmarks = []string{bookmark}
+	}
+	return nil
+}
+
+func (t *transaction) Rollback() error {
+	if t.done {
+		return errors.New("Transaction has already been committed or rolled back")
+	}
+	t.finish()
+	return t.tx.Rollback()
+}
+
+func (t *transaction) Close() error {
+	if t.done {
+		return nil
+	}
+	return t.Rollback()
+}
+
+func (t *transaction) finish() {
+	t.done = true
+	t.session.tx = nil
+}
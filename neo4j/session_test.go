@@ -0,0 +1,110 @@
+package neo4j
+
+import (
+	sqldriver "database/sql/driver"
+	"testing"
+	"time"
+
+	bolt "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// txConn counts the transactions begun on it
+type txConn struct {
+	bolt.Conn
+	begun      int
+	readBegun  int
+	rolledBack int
+	committed  int
+}
+
+func (c *txConn) BeginWithBookmarks(bookmarks ...string) (sqldriver.Tx, error) {
+	c.begun++
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *txConn) BeginReadWithBookmarks(bookmarks ...string) (sqldriver.Tx, error) {
+	c.readBegun++
+	return c.BeginWithBookmarks(bookmarks...)
+}
+
+func (c *txConn) LastBookmark() string {
+	return ""
+}
+
+type fakeTx struct {
+	conn *txConn
+}
+
+func (t *fakeTx) Commit() error {
+	t.conn.committed++
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.conn.rolledBack++
+	return nil
+}
+
+func TestSession_DeadlockRetry(t *testing.T) {
+	deadlock := &bolt.TxFailedError{Failure: messages.NewFailureMessage(map[string]interface{}{"code": bolt.DeadlockCode})}
+
+	conn := &txConn{}
+	s := newSession(conn, nil, bolt.RetryPolicy{MaxRetries: 2, InitialBackoff: 10 * time.Millisecond, MaxBackoff: time.Minute})
+
+	attempts := 0
+	start := time.Now()
+	output, err := s.WriteTransaction(func(tx Transaction) (interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, deadlock
+		}
+		return "done", nil
+	})
+	if err != nil || output != "done" {
+		t.Fatalf("Expected the work to succeed on the last retry. Got: %#v %v", output, err)
+	}
+	if conn.begun != 3 || conn.rolledBack != 2 || conn.committed != 1 {
+		t.Fatalf("Expected each deadlocked transaction to be rolled back. Got: %#v", conn)
+	}
+	// Backing off 10ms, then 20ms
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("Expected the retries to back off. Took: %s", elapsed)
+	}
+
+	attempts = 0
+	if _, err := s.WriteTransaction(func(tx Transaction) (interface{}, error) {
+		attempts++
+		return nil, deadlock
+	}); err != deadlock || attempts != 3 {
+		t.Fatalf("Expected the deadlock after running out of retries. Got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	s = newSession(conn, nil, bolt.RetryPolicy{})
+	if _, err := s.ReadTransaction(func(tx Transaction) (interface{}, error) {
+		attempts++
+		return nil, deadlock
+	}); err != deadlock || attempts != 1 {
+		t.Fatalf("Expected no retries by default. Got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestSession_ReadTransaction(t *testing.T) {
+	conn := &txConn{}
+	s := newSession(conn, nil, bolt.RetryPolicy{})
+
+	if _, err := s.ReadTransaction(func(tx Transaction) (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("An error occurred running the read transaction: %s", err)
+	}
+	if conn.begun != 1 || conn.readBegun != 1 {
+		t.Fatalf("Expected the read transaction to be begun in read mode. Got: %#v", conn)
+	}
+
+	if _, err := s.WriteTransaction(func(tx Transaction) (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("An error occurred running the write transaction: %s", err)
+	}
+	if conn.begun != 2 || conn.readBegun != 1 {
+		t.Fatalf("Expected the write transaction not to be begun in read mode. Got: %#v", conn)
+	}
+}
@@ -0,0 +1,60 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// The As* helpers read a value from NextNeo, or from a property map, that
+// may be null, so optional properties don't need a nil check and a type
+// assertion each.  A nil value gives an invalid sql.Null type.  Values are
+// converted with the sql.Scanner of the sql.Null type, so the same
+// conversions apply as scanning a row with database/sql, e.g. an integer
+// can be read as a string.  Values that can't be converted are errors.
+
+// AsNullString reads a value that may be null as a sql.NullString
+func AsNullString(v interface{}) (sql.NullString, error) {
+	var n sql.NullString
+	if err := n.Scan(v); err != nil {
+		return n, errors.Wrap(err, "Can't read %T as a string", v)
+	}
+	return n, nil
+}
+
+// AsNullInt64 reads a value that may be null as a sql.NullInt64
+func AsNullInt64(v interface{}) (sql.NullInt64, error) {
+	var n sql.NullInt64
+	if err := n.Scan(v); err != nil {
+		return n, errors.Wrap(err, "Can't read %T as an integer", v)
+	}
+	return n, nil
+}
+
+// AsNullFloat64 reads a value that may be null as a sql.NullFloat64
+func AsNullFloat64(v interface{}) (sql.NullFloat64, error) {
+	var n sql.NullFloat64
+	if err := n.Scan(v); err != nil {
+		return n, errors.Wrap(err, "Can't read %T as a float", v)
+	}
+	return n, nil
+}
+
+// AsNullBool reads a value that may be null as a sql.NullBool
+func AsNullBool(v interface{}) (sql.NullBool, error) {
+	var n sql.NullBool
+	if err := n.Scan(v); err != nil {
+		return n, errors.Wrap(err, "Can't read %T as a boolean", v)
+	}
+	return n, nil
+}
+
+// ScanValue reads a value from NextNeo, or from a property map, into any
+// sql.Scanner, such as the sql.Null types, or an application's own types
+// that already implement sql.Scanner for use with database/sql
+func ScanValue(v interface{}, dest sql.Scanner) error {
+	if err := dest.Scan(v); err != nil {
+		return errors.Wrap(err, "An error occurred scanning %T into %T", v, dest)
+	}
+	return nil
+}
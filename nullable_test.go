@@ -0,0 +1,45 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNullableHelpers(t *testing.T) {
+	row := map[string]interface{}{"name": "foo", "age": int64(30), "score": 1.5, "active": true, "nickname": nil}
+
+	if name, err := AsNullString(row["name"]); err != nil || !name.Valid || name.String != "foo" {
+		t.Fatalf("Expected a valid string. Got: %#v, %v", name, err)
+	}
+	if nickname, err := AsNullString(row["nickname"]); err != nil || nickname.Valid {
+		t.Fatalf("Expected null to be invalid. Got: %#v, %v", nickname, err)
+	}
+	if age, err := AsNullInt64(row["age"]); err != nil || !age.Valid || age.Int64 != 30 {
+		t.Fatalf("Expected a valid integer. Got: %#v, %v", age, err)
+	}
+	if score, err := AsNullFloat64(row["score"]); err != nil || !score.Valid || score.Float64 != 1.5 {
+		t.Fatalf("Expected a valid float. Got: %#v, %v", score, err)
+	}
+	if active, err := AsNullBool(row["active"]); err != nil || !active.Valid || !active.Bool {
+		t.Fatalf("Expected a valid boolean. Got: %#v, %v", active, err)
+	}
+	if missing, err := AsNullInt64(row["missing"]); err != nil || missing.Valid {
+		t.Fatalf("Expected a missing property to be invalid. Got: %#v, %v", missing, err)
+	}
+
+	// Conversions follow database/sql
+	if age, err := AsNullString(row["age"]); err != nil || age.String != "30" {
+		t.Fatalf("Expected an integer to be read as a string. Got: %#v, %v", age, err)
+	}
+	if _, err := AsNullInt64(row["name"]); err == nil {
+		t.Fatal("Expected an error reading a string as an integer")
+	}
+	if _, err := AsNullString([]interface{}{"a"}); err == nil {
+		t.Fatal("Expected an error reading a list as a string")
+	}
+
+	var score sql.NullFloat64
+	if err := ScanValue(row["score"], &score); err != nil || score.Float64 != 1.5 {
+		t.Fatalf("Expected the value to be scanned. Got: %#v, %v", score, err)
+	}
+}
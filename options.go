@@ -0,0 +1,184 @@
+package golangNeo4jBoltDriver
+
+import (
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+// ConnOptions are the settings of a connection that can be changed once it's
+// open.  Get the current settings with Conn.Options, change the ones needed,
+// and apply them with Conn.SetOptions, so the rest are left as they are:
+//
+//	opts := conn.Options()
+//	opts.ReadOnly = true
+//	conn.SetOptions(opts)
+type ConnOptions struct {
+	// PropertyValidation enables checking query parameters against
+	// Neo4j's property storage rules before sending them. See ValidateProperties.
+	PropertyValidation bool
+	// MaxDepth and MaxSize are the maximum nesting depth and encoded size in
+	// bytes of the messages sent to Neo4j. 0 means no limit. Messages are
+	// checked against the limits before any bytes are sent.  A query over the
+	// limits fails with an error wrapping an *encoding.LimitError, which
+	// errors.InnerMost gets.
+	MaxDepth int
+	MaxSize  int
+	// FailureAck overrides how failures from the server are acknowledged.
+	// Defaults to FailureAckAuto. Mostly useful for testing.
+	FailureAck FailureAck
+	// StatementCacheSize is the number of prepared queries remembered by the
+	// connection, so the work done on a query's text before it's sent, like
+	// checking if it writes, isn't repeated each time it's run.
+	// 0 disables the cache.
+	StatementCacheSize int
+	// DefaultParams are merged into the parameters of every query on the
+	// connection, e.g. a tenant id. Parameters passed to a query override
+	// the defaults.
+	DefaultParams map[string]interface{}
+	// AsyncClose makes closing rows that haven't been fully read return
+	// immediately, discarding the rest of the stream in the background.
+	// The next query on the connection waits for the discard to finish.
+	AsyncClose bool
+	// Logger is the logger for the connection, to separate its logs from
+	// the package level loggers. nil uses the package level loggers.
+	Logger *log.Logger
+	// RawStructures makes the connection return structures it doesn't
+	// recognize, e.g. types from a newer server, as structures.Raw values
+	// instead of failing to decode them
+	RawStructures bool
+	// LazyMetadata makes the connection keep query plans, profiles and
+	// notifications in SUCCESS metadata encoded, as *encoding.LazyValue, until
+	// they're read, instead of decoding them for every query.  Summary decodes
+	// them.  Overrides the lazy_metadata connection param.
+	LazyMetadata bool
+	// MissingFields is what rows do when Neo4j doesn't return the names of
+	// their columns.  Overrides the missing_fields connection param.
+	MissingFields MissingFields
+	// MaxIgnored and IgnoreTimeout bound the number of IGNORED messages, and
+	// the time spent, draining the stream while acknowledging a failure. Past
+	// either, the connection is closed with an IgnoredFloodError. 0 means no
+	// limit.
+	MaxIgnored    int
+	IgnoreTimeout time.Duration
+	// ErrorQueryLength is how much of the query text is included in errors
+	// from running queries, along with the parameter keys. 0 leaves the query
+	// text out, e.g. when queries contain sensitive literals. Less than 0
+	// includes all of it. Defaults to 200 characters.
+	ErrorQueryLength int
+	// LegacyTxFailures keeps a transaction usable after a statement in it
+	// fails, as in earlier versions of the driver. By default the transaction
+	// can only be rolled back, and committing it rolls it back. See TxFailedError.
+	LegacyTxFailures bool
+	// PipelineWindow is the maximum number of pipelined queries sent before
+	// their results are read. ExecPipeline reads results as it goes once the
+	// window is full, and QueryPipeline rejects pipelines larger than the
+	// window. 0 means no limit. Defaults to 100.
+	PipelineWindow int
+	// AdaptiveChunking makes the connection size its writes instead of
+	// writing each chunk separately.  Messages are sent in the largest chunks,
+	// and in a single write when they're small.  Large messages are written in
+	// batches sized by the write throughput seen on the connection, so each
+	// write finishes well within the timeout. SetChunkSize turns it off.
+	AdaptiveChunking bool
+	// MaxExecutionTime is how long a query may run before the connection
+	// interrupts it with RESET, failing it with a QueryInterruptedError.  An
+	// open transaction can then only be rolled back.  It applies to queries
+	// run after it's set, so it can be changed around a single query.
+	// 0 means no limit.  Overrides the max_execution_time connection param.
+	MaxExecutionTime time.Duration
+	// MetricsHook is called with the metrics of each query run on the
+	// connection, once the end of its results is read.  See QueryMetrics.
+	MetricsHook MetricsHook
+	// QueryGuard checks each query before it's sent.  Blocked queries fail
+	// with a *QueryBlockedError.  See BlockQueries.
+	QueryGuard QueryGuard
+	// ReadOnly makes the connection refuse queries that write, with a
+	// *QueryBlockedError, so writes sent to a read replica by mistake fail
+	// clearly.  See IsWriteQuery.  Overrides the read_only connection param.
+	ReadOnly bool
+}
+
+// Options gets the settings of the connection
+func (c *boltConn) Options() ConnOptions {
+	opts := ConnOptions{
+		PropertyValidation: c.validateProps,
+		MaxDepth:           c.maxDepth,
+		MaxSize:            c.maxSize,
+		FailureAck:         c.failureAck,
+		DefaultParams:      c.defaultParams,
+		AsyncClose:         c.asyncClose,
+		Logger:             c.logger,
+		RawStructures:      c.rawStructs,
+		LazyMetadata:       c.lazyMeta,
+		MissingFields:      c.missingFields,
+		MaxIgnored:         c.maxIgnored,
+		IgnoreTimeout:      c.ignoreTimeout,
+		ErrorQueryLength:   c.errQueryLen,
+		LegacyTxFailures:   c.legacyTxFail,
+		PipelineWindow:     c.pipeWindow,
+		AdaptiveChunking:   c.adaptiveChunk,
+		MaxExecutionTime:   c.maxExecTime,
+		MetricsHook:        c.metricsHook,
+		QueryGuard:         c.queryGuard,
+		ReadOnly:           c.readOnly,
+	}
+	if c.stmtCache != nil {
+		opts.StatementCacheSize = c.stmtCache.maxSize
+	}
+	return opts
+}
+
+// SetOptions changes the settings of the connection
+func (c *boltConn) SetOptions(opts ConnOptions) {
+	c.SetPropertyValidation(opts.PropertyValidation)
+	if opts.MaxDepth != c.maxDepth || opts.MaxSize != c.maxSize {
+		c.SetEncodingLimits(opts.MaxDepth, opts.MaxSize)
+	}
+	c.SetFailureAck(opts.FailureAck)
+	c.SetStatementCacheSize(opts.StatementCacheSize)
+	c.SetDefaultParams(opts.DefaultParams)
+	c.SetAsyncClose(opts.AsyncClose)
+	c.SetLogger(opts.Logger)
+	c.SetRawStructures(opts.RawStructures)
+	c.SetLazyMetadata(opts.LazyMetadata)
+	c.SetMissingFields(opts.MissingFields)
+	c.SetIgnoredLimit(opts.MaxIgnored, opts.IgnoreTimeout)
+	c.SetErrorQueryLength(opts.ErrorQueryLength)
+	c.SetLegacyTxFailures(opts.LegacyTxFailures)
+	c.SetPipelineWindow(opts.PipelineWindow)
+	if opts.AdaptiveChunking != c.adaptiveChunk {
+		c.SetAdaptiveChunking(opts.AdaptiveChunking)
+	}
+	c.SetMaxExecutionTime(opts.MaxExecutionTime)
+	c.SetMetricsHook(opts.MetricsHook)
+	c.SetQueryGuard(opts.QueryGuard)
+	c.SetReadOnly(opts.ReadOnly)
+}
+
+// connDefaults are the settings a connection was opened with, which it's
+// reset to when it's returned to the pool, so one borrower's settings
+// don't carry over to the next
+type connDefaults struct {
+	opts      ConnOptions
+	timeout   time.Duration
+	chunkSize uint16
+}
+
+// saveDefaults saves the settings the connection was opened with
+func (c *boltConn) saveDefaults() {
+	c.defaults = &connDefaults{opts: c.Options(), timeout: c.timeout, chunkSize: c.chunkSize}
+}
+
+// restoreDefaults resets the settings of the connection to the ones it was opened with
+func (c *boltConn) restoreDefaults() {
+	if c.defaults == nil {
+		return
+	}
+	c.timeout = c.defaults.timeout
+	if c.chunkSize != c.defaults.chunkSize {
+		c.chunkSize = c.defaults.chunkSize
+		c.encoder = nil
+	}
+	c.SetOptions(c.defaults.opts)
+}
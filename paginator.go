@@ -0,0 +1,156 @@
+package golangNeo4jBoltDriver
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+const (
+	// cursorPrefix marks the offset cursors of SKIP/LIMIT pagination
+	cursorPrefix = "skip:"
+	// skipParam and limitParam are the parameters pages are read with
+	skipParam  = "paginationSkip"
+	limitParam = "paginationLimit"
+)
+
+// Page is a page of rows read by a Paginator
+type Page struct {
+	Rows    [][]interface{}
+	Columns []string
+	// Cursor resumes reading from the next page, with Paginator.Resume, e.g.
+	// in another request.  It's empty on the last page.
+	Cursor string
+}
+
+// Paginator reads the rows of a query a page at a time, for APIs serving
+// paged graph data.  Each page is read with SKIP and LIMIT added to the end
+// of the query, so it must end with a RETURN clause without its own SKIP or
+// LIMIT, and should ORDER BY something unique for the pages to be stable.
+// Pages are read on connections from the pool, retried as set by
+// SetRetryPolicy until the context passed to NextPage is done.
+//
+// Bolt v4 PULL n would let a page be read from an open result instead, but
+// the driver doesn't support Bolt v4 yet.  See FeaturePullN.
+//
+// Paginator objects ARE NOT THREAD SAFE.
+type Paginator struct {
+	run      func(ctx context.Context, query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error)
+	query    string
+	params   map[string]interface{}
+	pageSize int
+	offset   int64
+	done     bool
+}
+
+// NewPaginator creates a paginator reading pages of pageSize rows of the query
+func NewPaginator(pool DriverPool, query string, params map[string]interface{}, pageSize int) (*Paginator, error) {
+	run := func(ctx context.Context, query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+		return pool.QueryNeoAll(query, params)
+	}
+	if d, ok := pool.(*boltDriverPool); ok {
+		run = func(ctx context.Context, query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+			return runPage(ctx, d.OpenPool, d.poolLogger(), query, params, d.retry())
+		}
+	}
+	return newPaginator(run, query, params, pageSize)
+}
+
+// runPage runs the query as runWithRetry does, but stops retrying once the
+// context is done, including while waiting to retry.  An attempt that's
+// already running is finished first, as queries can't be interrupted.
+func runPage(ctx context.Context, open func() (Conn, error), logger *log.Logger, query string, params map[string]interface{}, policy RetryPolicy) ([][]interface{}, []string, Summary, error) {
+	var data [][]interface{}
+	var fields []string
+	var summary Summary
+	retryable := func(err error) bool {
+		return ctx.Err() == nil && IsRetryable(err)
+	}
+	wait := func(d time.Duration) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	err := retryWork(logger, policy, retryable, wait, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		data, fields, summary, err = runOnce(open, query, params)
+		return err
+	})
+	if err != nil {
+		return nil, nil, Summary{}, err
+	}
+	return data, fields, summary, nil
+}
+
+func newPaginator(run func(context.Context, string, map[string]interface{}) ([][]interface{}, []string, Summary, error), query string, params map[string]interface{}, pageSize int) (*Paginator, error) {
+	if pageSize <= 0 {
+		return nil, errors.New("Page size must be positive, but got %d", pageSize)
+	}
+
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	paged := make(map[string]interface{}, len(params)+2)
+	for k, v := range params {
+		paged[k] = v
+	}
+	return &Paginator{
+		run:      run,
+		query:    query + "\nSKIP $" + skipParam + " LIMIT $" + limitParam,
+		params:   paged,
+		pageSize: pageSize,
+	}, nil
+}
+
+// Resume continues from the page the cursor of an earlier Page points to
+func (p *Paginator) Resume(cursor string) error {
+	offset, err := strconv.ParseInt(strings.TrimPrefix(cursor, cursorPrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(cursor, cursorPrefix) || offset < 0 {
+		return errors.New("Invalid page cursor: %q", cursor)
+	}
+	p.offset = offset
+	p.done = false
+	return nil
+}
+
+// NextPage reads the next page of rows.  Returns io.EOF once there are no
+// more pages.  Once the context is done, it fails with the context's error,
+// without starting the page or retrying it again.
+func (p *Paginator) NextPage(ctx context.Context) (*Page, error) {
+	if p.done {
+		return nil, io.EOF
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// One row more than the page tells whether there's a next page
+	p.params[skipParam] = p.offset
+	p.params[limitParam] = int64(p.pageSize + 1)
+	rows, columns, _, err := p.run(ctx, p.query, p.params)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, errors.Wrap(err, "An error occurred reading page at offset %d", p.offset)
+	}
+
+	page := &Page{Rows: rows, Columns: columns}
+	if len(rows) > p.pageSize {
+		page.Rows = rows[:p.pageSize]
+		p.offset += int64(p.pageSize)
+		page.Cursor = cursorPrefix + strconv.FormatInt(p.offset, 10)
+	} else {
+		p.done = true
+	}
+	return page, nil
+}
@@ -0,0 +1,93 @@
+package golangNeo4jBoltDriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaginator(t *testing.T) {
+	var queries []string
+	run := func(ctx context.Context, query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+		queries = append(queries, query)
+		var rows [][]interface{}
+		for i := params["paginationSkip"].(int64); i < 5 && int64(len(rows)) < params["paginationLimit"].(int64); i++ {
+			rows = append(rows, []interface{}{i})
+		}
+		return rows, []string{"n"}, Summary{}, nil
+	}
+
+	paginator, err := newPaginator(run, "MATCH (n) RETURN n ORDER BY n.id;", nil, 2)
+	if err != nil {
+		t.Fatalf("An error occurred creating the paginator: %s", err)
+	}
+
+	var read []interface{}
+	var cursors []string
+	for {
+		page, err := paginator.NextPage(context.Background())
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("An error occurred reading a page: %s", err)
+		}
+		for _, row := range page.Rows {
+			read = append(read, row[0])
+		}
+		cursors = append(cursors, page.Cursor)
+	}
+	if len(read) != 5 || read[4] != int64(4) || len(cursors) != 3 || cursors[0] != "skip:2" || cursors[2] != "" {
+		t.Fatalf("Expected every row in pages of 2, with a cursor for each next page. Got: %v, %v", read, cursors)
+	}
+	if !strings.HasSuffix(queries[0], "ORDER BY n.id\nSKIP $paginationSkip LIMIT $paginationLimit") {
+		t.Fatalf("Expected SKIP and LIMIT to be added to the query. Got: %s", queries[0])
+	}
+
+	if err := paginator.Resume(cursors[1]); err != nil {
+		t.Fatalf("An error occurred resuming: %s", err)
+	}
+	if page, err := paginator.NextPage(context.Background()); err != nil || len(page.Rows) != 1 || page.Rows[0][0] != int64(4) {
+		t.Fatalf("Expected to resume from the last page. Got: %#v, %v", page, err)
+	}
+	if err := paginator.Resume("4"); err == nil {
+		t.Fatal("Expected an error resuming from an invalid cursor")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	paginator.Resume(cursors[0])
+	if _, err := paginator.NextPage(ctx); err != context.Canceled {
+		t.Fatalf("Expected the context's error. Got: %v", err)
+	}
+	if _, err := newPaginator(run, "RETURN 1", nil, 0); err == nil {
+		t.Fatal("Expected an error for a page size of 0")
+	}
+}
+
+func TestPaginator_CancelRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	opened := 0
+	open := func() (Conn, error) {
+		opened++
+		return nil, driver.ErrBadConn
+	}
+	run := func(ctx context.Context, query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+		return runPage(ctx, open, nil, query, params, RetryPolicy{MaxRetries: 3, InitialBackoff: time.Hour})
+	}
+
+	paginator, err := newPaginator(run, "MATCH (n) RETURN n", nil, 2)
+	if err != nil {
+		t.Fatalf("An error occurred creating the paginator: %s", err)
+	}
+	// Cancelled while waiting an hour to retry
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := paginator.NextPage(ctx); err != context.Canceled {
+		t.Fatalf("Expected the context's error. Got: %v", err)
+	}
+	if opened != 1 {
+		t.Fatalf("Expected no retries once the context was cancelled. Got %d attempts", opened)
+	}
+}
@@ -0,0 +1,69 @@
+package golangNeo4jBoltDriver
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+// PanicError is returned in place of a panic recovered in the driver, e.g.
+// decoding a malformed message, or in one of its background goroutines,
+// so a driver bug fails the operation instead of crashing the process
+type PanicError struct {
+	// Op is what the driver was doing when it panicked
+	Op string
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Recovered from panic %s: %v", e.Op, e.Value)
+}
+
+// PanicHook is called with each panic recovered by the driver, e.g. to send
+// it to an error reporting service.  It may be called from any goroutine.
+type PanicHook func(*PanicError)
+
+var (
+	panicHookLock sync.RWMutex
+	panicHook     PanicHook
+)
+
+// SetPanicHook sets the hook called with each panic the driver recovers.
+// Recovered panics are logged as errors whether or not a hook is set.
+func SetPanicHook(hook PanicHook) {
+	panicHookLock.Lock()
+	defer panicHookLock.Unlock()
+	panicHook = hook
+}
+
+// safely runs f, returning a *PanicError for op if it panics.  Goroutines
+// the driver starts run their work through it, so the goroutine waiting on
+// them gets an error instead of waiting forever, or the process crashing.
+func safely(op string, f func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = recovered(op, value)
+		}
+	}()
+	return f()
+}
+
+// recovered reports a recovered panic, returning it as an error
+func recovered(op string, value interface{}) *PanicError {
+	panicErr := &PanicError{Op: op, Value: value, Stack: debug.Stack()}
+	log.Errorf("%s\n%s", panicErr, panicErr.Stack)
+
+	panicHookLock.RLock()
+	hook := panicHook
+	panicHookLock.RUnlock()
+	if hook != nil {
+		hook(panicErr)
+	}
+	return panicErr
+}
@@ -0,0 +1,57 @@
+package golangNeo4jBoltDriver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+func TestSafely(t *testing.T) {
+	var hooked []*PanicError
+	SetPanicHook(func(err *PanicError) { hooked = append(hooked, err) })
+	defer SetPanicHook(nil)
+
+	err := safely("testing", func() error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	panicErr, ok := err.(*PanicError)
+	if !ok || panicErr.Op != "testing" || !strings.Contains(panicErr.Error(), "Recovered from panic testing") || len(panicErr.Stack) == 0 {
+		t.Fatalf("Expected the panic to be returned as a PanicError. Got: %#v", err)
+	}
+	if len(hooked) != 1 || hooked[0] != panicErr {
+		t.Fatalf("Expected the hook to be called with the panic. Got: %#v", hooked)
+	}
+
+	failed := errors.New("failed")
+	if err := safely("testing", func() error { return failed }); err != failed || len(hooked) != 1 {
+		t.Fatalf("Expected errors to be returned as is. Got: %#v", err)
+	}
+}
+
+// panicConn panics running any query
+type panicConn struct {
+	Conn
+}
+
+func (c panicConn) QueryNeoAllSummary(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	panic("driver bug")
+}
+
+func (c panicConn) Close() error {
+	return nil
+}
+
+func TestQueryConcurrently_Panic(t *testing.T) {
+	open := func() (Conn, error) { return panicConn{}, nil }
+	results, err := queryConcurrently(context.Background(), open, nil, []QuerySpec{{Query: "RETURN 1"}}, 1, RetryPolicy{})
+	if _, ok := err.(*FanOutError); !ok {
+		t.Fatalf("Expected the panic to fail the query. Got: %#v", err)
+	}
+	if panicErr, ok := results[0].Err.(*PanicError); !ok || panicErr.Value != "driver bug" {
+		t.Fatalf("Expected the query's error to be the recovered panic. Got: %#v", results[0].Err)
+	}
+}
@@ -0,0 +1,113 @@
+package golangNeo4jBoltDriver
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// PoolConfig configures a pool created with NewDriverPoolWithConfig.  Zero
+// values keep the behavior of NewDriverPool.
+type PoolConfig struct {
+	// MaxOpen is the maximum number of connections borrowed at once
+	MaxOpen int
+	// MaxIdle is the maximum number of connections kept connected while
+	// they're in the pool.  Connections returned past it are disconnected,
+	// and connect again when they're next borrowed.  0 means MaxOpen.
+	MaxIdle int
+	// MinIdle is the number of connections connected when the pool is
+	// created, so the first queries don't wait on connecting
+	MinIdle int
+	// MaxLifetime is how long a connection is kept from when it connected.
+	// See DriverPool.SetMaxConnLifetime.
+	MaxLifetime time.Duration
+	// IdleTimeout is how long a connection can go unused before it's
+	// replaced.  See DriverPool.SetIdleTimeout.
+	IdleTimeout time.Duration
+	// BorrowTimeout is how long OpenPool waits for a connection to be
+	// returned when they're all borrowed.  0 waits for as long as it takes,
+	// or the operation budget, if there is one.
+	BorrowTimeout time.Duration
+}
+
+// NewDriverPoolWithConfig creates a new Driver object with connection
+// pooling configured by config
+func NewDriverPoolWithConfig(connStr string, config PoolConfig) (DriverPool, error) {
+	return createDriverPoolWithConfig(connStr, config)
+}
+
+// NewClosableDriverPoolWithConfig creates a closable driver pool configured by config
+func NewClosableDriverPoolWithConfig(connStr string, config PoolConfig) (ClosableDriverPool, error) {
+	return createDriverPoolWithConfig(connStr, config)
+}
+
+func createDriverPoolWithConfig(connStr string, config PoolConfig) (*boltDriverPool, error) {
+	switch {
+	case config.MaxOpen <= 0:
+		return nil, errors.New("MaxOpen must be positive, but got %d", config.MaxOpen)
+	case config.MaxIdle < 0 || config.MaxIdle > config.MaxOpen:
+		return nil, errors.New("MaxIdle must be between 0 and MaxOpen (%d), but got %d", config.MaxOpen, config.MaxIdle)
+	case config.MinIdle < 0 || config.MinIdle > config.MaxOpen || (config.MaxIdle > 0 && config.MinIdle > config.MaxIdle):
+		return nil, errors.New("MinIdle must be between 0 and MaxIdle, but got %d", config.MinIdle)
+	}
+
+	d, err := createDriverPool(connStr, config.MaxOpen)
+	if err != nil {
+		return nil, err
+	}
+	d.maxIdle = config.MaxIdle
+	d.maxLifetime = config.MaxLifetime
+	d.idleTimeout = config.IdleTimeout
+	d.borrowWait = config.BorrowTimeout
+
+	if err := d.warmUp(config.MinIdle); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// warmUp connects n of the pool's connections, returning them to the pool
+// connected
+func (d *boltDriverPool) warmUp(n int) error {
+	conns := make([]Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		conn, err := d.OpenPool()
+		if err != nil {
+			return errors.Wrap(err, "An error occurred connecting %d of %d idle connections", i+1, n)
+		}
+		conns = append(conns, conn)
+	}
+	return nil
+}
+
+// reserveIdle counts a connection returned to the pool connected, returning
+// false if the pool already has the maximum number of idle connections.
+// Connections that aren't connected aren't idle, and mustn't be counted.
+func (d *boltDriverPool) reserveIdle() bool {
+	for {
+		idle := atomic.LoadInt32(&d.idleCount)
+		if d.maxIdle > 0 && idle >= int32(d.maxIdle) {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&d.idleCount, idle, idle+1) {
+			return true
+		}
+	}
+}
+
+// borrowTimeout gets how long to wait for a connection: the borrow timeout,
+// unless the operation budget runs out first.  Called with confLock held.
+func (d *boltDriverPool) borrowTimeout() time.Duration {
+	if d.borrowWait > 0 && (d.opBudget <= 0 || d.borrowWait < d.opBudget) {
+		return d.borrowWait
+	}
+	return d.opBudget
+}
@@ -0,0 +1,83 @@
+package golangNeo4jBoltDriver
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCreateDriverPoolWithConfig(t *testing.T) {
+	for _, config := range []PoolConfig{
+		{},
+		{MaxOpen: 2, MaxIdle: 3},
+		{MaxOpen: 2, MaxIdle: 1, MinIdle: 2},
+		{MaxOpen: 2, MinIdle: -1},
+	} {
+		if _, err := createDriverPoolWithConfig("bolt://localhost:7687", config); err == nil {
+			t.Fatalf("Expected an error for an invalid config: %#v", config)
+		}
+	}
+
+	pool, err := createDriverPoolWithConfig("bolt://localhost:7687", PoolConfig{
+		MaxOpen:       2,
+		MaxIdle:       1,
+		MaxLifetime:   time.Hour,
+		IdleTimeout:   time.Minute,
+		BorrowTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("An error occurred creating the pool: %s", err)
+	}
+	if pool.maxLifetime != time.Hour || pool.idleTimeout != time.Minute || pool.borrowTimeout() != 10*time.Millisecond {
+		t.Fatalf("Expected the config to be applied. Got: %#v", pool)
+	}
+	pool.SetOperationBudget(5 * time.Millisecond)
+	if pool.borrowTimeout() != 5*time.Millisecond {
+		t.Fatalf("Expected the operation budget to bound the wait. Got: %s", pool.borrowTimeout())
+	}
+
+	// Return both connections connected: only one stays connected
+	first, second := <-pool.pool, <-pool.pool
+	first.conn, _ = net.Pipe()
+	second.conn, _ = net.Pipe()
+	if err := pool.reclaim(first); err != nil {
+		t.Fatalf("An error occurred reclaiming the connection: %s", err)
+	}
+	if err := pool.reclaim(second); err != nil {
+		t.Fatalf("An error occurred reclaiming the connection: %s", err)
+	}
+	if !second.closed || pool.idleCount != 1 {
+		t.Fatalf("Expected the connection past the maximum idle to be disconnected. Got %d idle", pool.idleCount)
+	}
+	connected := 0
+	for i := 0; i < 2; i++ {
+		if conn := <-pool.pool; conn.conn != nil {
+			connected++
+		}
+	}
+	if connected != 1 {
+		t.Fatalf("Expected one connected connection in the pool. Got: %d", connected)
+	}
+
+	start := time.Now()
+	if _, err := pool.OpenPool(); err == nil || time.Since(start) > time.Second {
+		t.Fatalf("Expected borrowing from an empty pool to time out. Got: %v", err)
+	}
+}
+
+func TestDriverPoolWithConfig_FailedDials(t *testing.T) {
+	pool, err := NewDriverPoolWithConfig("bolt://127.0.0.1:1", PoolConfig{MaxOpen: 2, MaxIdle: 1})
+	if err != nil {
+		t.Fatalf("An error occurred creating the pool: %s", err)
+	}
+
+	// Connections that failed to connect aren't idle, so they don't count towards MaxIdle
+	for i := 0; i < 3; i++ {
+		if _, err := pool.OpenPool(); err == nil {
+			t.Fatal("Expected an error connecting to a closed port")
+		}
+	}
+	if idle := pool.(*boltDriverPool).idleCount; idle != 0 {
+		t.Fatalf("Expected no idle connections. Got: %d", idle)
+	}
+}
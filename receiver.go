@@ -0,0 +1,181 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// receiver runs the receive loop of a connection, a go routine that decodes
+// the stream and hands each message back over a channel.  It's only used
+// while a watchdog may give up waiting on a message; otherwise messages are
+// decoded inline.
+//
+// Messages are read one per request, so nothing is read that no one is
+// waiting on, and the read deadline only applies while a response is
+// expected.  The loop never touches the connection's state: failure
+// handling, acknowledgements, result bookkeeping and marking the connection
+// defunct stay with the caller, which only sees typed messages or errors.
+type receiver struct {
+	requests chan struct{}
+	received chan received
+	// done is closed when the loop exits
+	done chan struct{}
+}
+
+// received is a message from the stream, or the error reading it
+type received struct {
+	msg interface{}
+	err error
+	// defunct is the error that broke the connection, for the
+	// connection's goroutine to record
+	defunct error
+}
+
+// receive gets the next message from the stream.  If the query being run
+// has a maximum execution time, the message comes from the receive loop,
+// started on first use, and the query is interrupted if it goes past the
+// maximum while waiting on it.
+func (c *boltConn) receive() (interface{}, error) {
+	if c.connErr != nil {
+		return nil, c.defunctError("receive a message")
+	}
+
+	var resp received
+	if c.execStart.IsZero() || c.maxExecTime <= 0 {
+		resp = c.decode()
+	} else {
+		if c.receiver == nil {
+			c.startReceiver()
+		}
+		c.receiver.requests <- struct{}{}
+
+		watchdog := time.NewTimer(time.Until(c.execStart.Add(c.maxExecTime)))
+		defer watchdog.Stop()
+		select {
+		case resp = <-c.receiver.received:
+		case <-watchdog.C:
+			return nil, c.interrupt()
+		}
+	}
+
+	if resp.defunct != nil {
+		c.markDefunct(resp.defunct)
+	}
+	c.track(resp.msg)
+	return resp.msg, resp.err
+}
+
+// track matches summaries to the requests waiting on them, in the order the
+// requests were sent, and counts the records of the query being read.
+// Once no requests are left, the query being run is done.
+func (c *boltConn) track(msg interface{}) {
+	switch msg.(type) {
+	case messages.RecordMessage:
+		if len(c.inFlight) > 0 && c.inFlight[0] != nil {
+			c.inFlight[0].Rows++
+		}
+	case messages.SuccessMessage, messages.FailureMessage, messages.IgnoredMessage:
+		if len(c.inFlight) > 0 {
+			done := c.inFlight[0]
+			c.inFlight = c.inFlight[1:]
+			c.finishMetrics(done, msg)
+		}
+		if len(c.inFlight) == 0 {
+			c.execStart = time.Time{}
+		}
+	}
+}
+
+// interrupt stops a query that ran past the maximum execution time with
+// RESET.  The message already requested from the receive loop is the first
+// of the responses drained, up to the response to the RESET.
+func (c *boltConn) interrupt() error {
+	interrupted := &QueryInterruptedError{Fingerprint: queryFingerprint(c.execQuery), Elapsed: time.Since(c.execStart)}
+	c.logger.Errorf("%s, past the maximum of %s. Interrupting it with RESET", interrupted, c.maxExecTime)
+	c.execStart = time.Time{}
+
+	if err := c.encode(messages.NewResetMessage()); err != nil {
+		c.markDefunct(errors.Wrap(err, "An error occurred interrupting query"))
+		c.Close()
+		return driver.ErrBadConn
+	}
+
+	resp := <-c.receiver.received
+	for {
+		if resp.defunct != nil {
+			c.markDefunct(resp.defunct)
+		}
+		if resp.err != nil {
+			return errors.Wrap(resp.err, "An error occurred reading responses to interrupted query")
+		}
+		c.track(resp.msg)
+		if len(c.inFlight) == 0 {
+			break
+		}
+		c.receiver.requests <- struct{}{}
+		resp = <-c.receiver.received
+	}
+
+	if failure, ok := resp.msg.(messages.FailureMessage); ok {
+		c.markDefunct(errors.Wrap(failure, "Error resetting session to interrupt query. CLOSING SESSION!"))
+		c.Close()
+		return driver.ErrBadConn
+	}
+
+	// The server has ended the result stream, and any transaction
+	if c.statement != nil && c.statement.rows != nil {
+		c.statement.rows.consumed = true
+		c.statement.rows.finishedConsume = true
+	}
+	if c.transaction != nil {
+		c.transaction.reset = true
+		if c.transaction.failure == nil {
+			c.transaction.failure = &messages.FailureMessage{Metadata: map[string]interface{}{"message": interrupted.Error()}}
+		}
+	}
+	return interrupted
+}
+
+// startReceiver starts the receive loop of the connection
+func (c *boltConn) startReceiver() {
+	r := &receiver{
+		requests: make(chan struct{}),
+		// Buffered for the one message requested at a time, so the loop
+		// doesn't block forever when the connection gives up waiting on it
+		received: make(chan received, 1),
+		done:     make(chan struct{}),
+	}
+	c.receiver = r
+	go c.receiveLoop(r)
+}
+
+// receiveLoop decodes a message from the stream for each request,
+// until the receiver is stopped
+func (c *boltConn) receiveLoop(r *receiver) {
+	defer close(r.done)
+	for range r.requests {
+		resp := c.decode()
+		if resp.err == nil && c.logger.GetLevel() >= log.TraceLevel {
+			c.logger.Tracef("Received %T from stream", resp.msg)
+		}
+		r.received <- resp
+	}
+}
+
+// stopReceiver ends the receive loop of the connection, waiting for a message
+// it's decoding.  The loop is bound to the connection struct, so it must be
+// stopped before the struct is swapped out when reclaimed for the pool.
+func (c *boltConn) stopReceiver() {
+	if c.receiver == nil {
+		return
+	}
+
+	r := c.receiver
+	close(r.requests)
+	c.receiver = nil
+	<-r.done
+}
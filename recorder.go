@@ -2,31 +2,55 @@ package golangNeo4jBoltDriver
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
 )
 
 // recorder records a given session with Neo4j.
 // allows for playback of sessions as well
+//
+// Recordings store each event as the messages sent or received, so they can
+// be reviewed, and stay valid when the chunking changes.  Events that aren't
+// messages, like the handshake, are stored as bytes.
+//
+// On playback, recorded reads are encoded again, and writes are checked
+// against the recorded messages.  Writes to events recorded as bytes are
+// only checked against the length of the recorded writes, unless asserting.
+// When asserting, the messages written are decoded and checked against the
+// recorded messages, so a recording acts as a contract for the messages the
+// driver sends.  Set RECORD_ASSERT=1 to assert in all tests.
 type recorder struct {
 	net.Conn
 	name         string
 	events       []*Event
 	connStr      string
 	currentEvent int
+	assert       bool
+	// expected and written hold the recorded and written bytes of the
+	// current write event, when asserting
+	expected []byte
+	written  []byte
 }
 
 func newRecorder(name string, connStr string) *recorder {
 	r := &recorder{
 		name:    name,
 		connStr: connStr,
+		assert:  os.Getenv("RECORD_ASSERT") != "",
 	}
 
 	if r.connStr == "" {
@@ -138,6 +162,27 @@ func (r *recorder) Write(b []byte) (n int, err error) {
 		return 0, errors.New("Recorder expected Write, got Read! %#v, Event: %#v", r, event)
 	}
 
+	if event.Messages != nil {
+		// Wait for all of the recorded messages to be written, however
+		// they're chunked, then compare them
+		r.written = append(r.written, b...)
+		if count, complete := countMessages(r.written); !complete || count < len(event.Messages) {
+			return len(b), nil
+		}
+
+		written := r.written
+		r.written = nil
+		r.currentEvent++
+		return len(b), assertRecordedMessages(event.Messages, written)
+	}
+
+	if r.assert {
+		if r.expected == nil {
+			r.expected = event.Event
+		}
+		r.written = append(r.written, b...)
+	}
+
 	for i := 0; i < len(b); i++ {
 		if len(event.Event) == 0 {
 			return i, errors.New("Attempted to write past current event in recorder! %#v, Event: %#v", r, event)
@@ -147,11 +192,135 @@ func (r *recorder) Write(b []byte) (n int, err error) {
 
 	if len(event.Event) == 0 {
 		r.currentEvent++
+		if r.assert {
+			expected, written := r.expected, r.written
+			r.expected, r.written = nil, nil
+			if err := assertWritten(expected, written); err != nil {
+				return len(b), err
+			}
+		}
 	}
 
 	return len(b), nil
 }
 
+// assertWritten checks the bytes written for an event recorded as bytes.
+// The handshake isn't chunked messages, so only its magic preamble is
+// compared byte for byte.  The versions proposed aren't, as playback answers
+// with the recorded version whatever's proposed.
+func assertWritten(expected []byte, written []byte) error {
+	if !bytes.HasPrefix(expected, magicPreamble) {
+		return assertMessages(expected, written)
+	}
+	if !bytes.HasPrefix(written, magicPreamble) {
+		return errors.New("Handshake written doesn't match the recording. Recorded: %s. Written: %s", sprintByteHex(expected), sprintByteHex(written))
+	}
+	return nil
+}
+
+// assertMessages checks the messages written match the recorded messages,
+// by type, and by query text for RUN messages
+func assertMessages(expected []byte, written []byte) error {
+	expectedMsgs, err := describeMessages(expected)
+	if err != nil {
+		return errors.Wrap(err, "An error occurred decoding recorded messages")
+	}
+	writtenMsgs, err := describeMessages(written)
+	if err != nil {
+		return errors.Wrap(err, "An error occurred decoding written messages")
+	}
+
+	if diff, same := diffLines(expectedMsgs, writtenMsgs); !same {
+		return errors.New("Messages written don't match the recording (- recorded, + written):\n\n%s", diff)
+	}
+	return nil
+}
+
+// assertRecordedMessages checks the messages written match the messages
+// recorded, field for field
+func assertRecordedMessages(expected []*RecordedMessage, written []byte) error {
+	writtenMsgs, err := decodeRecordedMessages(written)
+	if err != nil {
+		return errors.Wrap(err, "An error occurred decoding written messages")
+	}
+
+	expectedLines, err := marshalLines(expected)
+	if err != nil {
+		return err
+	}
+	writtenLines, err := marshalLines(writtenMsgs)
+	if err != nil {
+		return err
+	}
+
+	if diff, same := diffLines(expectedLines, writtenLines); !same {
+		return errors.New("Messages written don't match the recording (- recorded, + written):\n\n%s", diff)
+	}
+	return nil
+}
+
+// marshalLines marshals each message to a line of JSON.  Map keys are
+// sorted, so the lines can be compared.
+func marshalLines(msgs []*RecordedMessage) ([]string, error) {
+	lines := make([]string, len(msgs))
+	for i, msg := range msgs {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return nil, errors.Wrap(err, "An error occurred marshalling recorded message")
+		}
+		lines[i] = string(line)
+	}
+	return lines, nil
+}
+
+// diffLines diffs the recorded and written lines, returning whether they're the same
+func diffLines(expected []string, written []string) (string, bool) {
+	diff, same := "", len(expected) == len(written)
+	for i := 0; i < len(expected) || i < len(written); i++ {
+		switch {
+		case i >= len(written):
+			diff += "- " + expected[i] + "\n"
+		case i >= len(expected):
+			diff += "+ " + written[i] + "\n"
+		case expected[i] != written[i]:
+			diff += "- " + expected[i] + "\n+ " + written[i] + "\n"
+			same = false
+		default:
+			diff += "  " + expected[i] + "\n"
+		}
+	}
+	return diff, same
+}
+
+// describeMessages decodes each message in the data to its type,
+// with the query text for RUN messages
+func describeMessages(data []byte) ([]string, error) {
+	reader := bytes.NewReader(data)
+	decoder := encoding.NewDecoder(reader)
+	decoder.SetRawStructures(true)
+
+	descriptions := []string{}
+	for reader.Len() > 0 {
+		msg, err := decoder.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		switch msg := msg.(type) {
+		case structures.Raw:
+			if msg.Signature == messages.RunMessageSignature && len(msg.Fields) > 0 {
+				descriptions = append(descriptions, fmt.Sprintf("Run %v", msg.Fields[0]))
+			} else {
+				descriptions = append(descriptions, fmt.Sprintf("Structure 0x%X", msg.Signature))
+			}
+		default:
+			name := strings.TrimPrefix(fmt.Sprintf("%T", msg), "messages.")
+			descriptions = append(descriptions, strings.TrimSuffix(name, "Message"))
+		}
+	}
+	return descriptions, nil
+}
+
 func (r *recorder) record(data []byte, isWrite bool) {
 	event := r.lastEvent()
 	if event == nil || event.Completed || event.IsWrite != isWrite {
@@ -181,8 +350,35 @@ func (r *recorder) load(name string) error {
 	if err != nil {
 		return err
 	}
+	defer file.Close()
+
+	return r.loadEvents(file)
+}
+
+// loadEvents loads the events of a recording for playback, encoding the
+// messages of recorded reads
+func (r *recorder) loadEvents(reader io.Reader) error {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	if err := decoder.Decode(&r.events); err != nil {
+		return err
+	}
+
+	for _, event := range r.events {
+		if event.IsWrite || event.Messages == nil {
+			continue
+		}
 
-	return json.NewDecoder(file).Decode(&r.events)
+		buf := &bytes.Buffer{}
+		encoder := encoding.NewEncoder(buf, math.MaxUint16)
+		for _, msg := range event.Messages {
+			if err := encoder.Encode(msg.raw()); err != nil {
+				return errors.Wrap(err, "An error occurred encoding recorded message: %#v", msg)
+			}
+		}
+		event.Event = buf.Bytes()
+	}
+	return nil
 }
 
 func (r *recorder) writeRecording() error {
@@ -190,7 +386,30 @@ func (r *recorder) writeRecording() error {
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(file).Encode(r.events)
+	defer file.Close()
+
+	r.recordMessages()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.events)
+}
+
+// recordMessages stores each completed event after the handshake as the
+// messages in it.  Events that don't decode to messages are kept as bytes.
+func (r *recorder) recordMessages() {
+	for i, event := range r.events {
+		// The first write and read are the handshake
+		if i < 2 || !event.Completed || event.Error != nil || event.Messages != nil {
+			continue
+		}
+
+		msgs, err := decodeRecordedMessages(event.Event)
+		if err != nil {
+			continue
+		}
+		event.Messages = msgs
+		event.Event = nil
+	}
 }
 
 func (r *recorder) flush() error {
@@ -214,6 +433,15 @@ func (r *recorder) print() {
 		}
 		fmt.Printf("%s @ %d:\n\n", typee, event.Timestamp)
 
+		if event.Messages != nil {
+			lines, err := marshalLines(event.Messages)
+			if err != nil {
+				fmt.Printf("Error marshalling messages! Error: %s\n", err)
+			}
+			fmt.Printf("Messages:\n\n%s\n\n", strings.Join(lines, "\n"))
+			continue
+		}
+
 		decoded, err := encoding.NewDecoder(bytes.NewBuffer(event.Event)).Decode()
 		if err != nil {
 			fmt.Printf("Error decoding data! Error: %s\n", err)
@@ -273,15 +501,161 @@ func (r *recorder) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
-// Event represents a single recording (read or write) event in the recorder
+// Event represents a single recording (read or write) event in the recorder.
+// The event is either recorded as Messages, or as the bytes in Event.
 type Event struct {
 	Timestamp int64 `json:"-"`
 	Event     []byte
+	Messages  []*RecordedMessage `json:",omitempty"`
 	IsWrite   bool
 	Completed bool
 	Error     error
 }
 
+// RecordedMessage is a message in a recording.  Type names the message for
+// whoever is reading the recording, and isn't used for playback.
+//
+// Fields are stored as JSON, with floats stored as {"$float": value}, and
+// structures stored as {"$signature": signature, "fields": [fields]}, so
+// they can be told apart from integers and maps on playback.  Map keys
+// starting with $ are escaped with another $, so they can't be mistaken
+// for either.
+type RecordedMessage struct {
+	Type      string
+	Signature byte
+	Fields    []interface{}
+}
+
+// recordedMessageTypes names the messages in a recording
+var recordedMessageTypes = map[byte]string{
+	messages.InitMessageSignature:       "INIT",
+	messages.RunMessageSignature:        "RUN",
+	messages.DiscardAllMessageSignature: "DISCARD_ALL",
+	messages.PullAllMessageSignature:    "PULL_ALL",
+	messages.AckFailureMessageSignature: "ACK_FAILURE",
+	messages.ResetMessageSignature:      "RESET",
+	messages.GoodbyeMessageSignature:    "GOODBYE",
+	messages.BeginMessageSignature:      "BEGIN",
+	messages.CommitMessageSignature:     "COMMIT",
+	messages.RollbackMessageSignature:   "ROLLBACK",
+	messages.RecordMessageSignature:     "RECORD",
+	messages.SuccessMessageSignature:    "SUCCESS",
+	messages.FailureMessageSignature:    "FAILURE",
+	messages.IgnoredMessageSignature:    "IGNORED",
+}
+
+// raw gets the message as a structure to encode
+func (m *RecordedMessage) raw() structures.Raw {
+	return structures.Raw{Signature: m.Signature, Fields: fromRecorded(m.Fields).([]interface{})}
+}
+
+// decodeRecordedMessages decodes the messages in the data to record them
+func decodeRecordedMessages(data []byte) ([]*RecordedMessage, error) {
+	reader := bytes.NewReader(data)
+	decoder := encoding.NewDecoder(reader)
+	decoder.SetRawStructures(true)
+
+	msgs := []*RecordedMessage{}
+	for reader.Len() > 0 {
+		decoded, err := decoder.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		msg, ok := toRecorded(decoded).(map[string]interface{})
+		if !ok || msg["$signature"] == nil {
+			return nil, errors.New("Expected a message, got: %#v", decoded)
+		}
+		signature := msg["$signature"].(byte)
+		msgs = append(msgs, &RecordedMessage{
+			Type:      recordedMessageTypes[signature],
+			Signature: signature,
+			Fields:    msg["fields"].([]interface{}),
+		})
+	}
+	return msgs, nil
+}
+
+// toRecorded converts a decoded value to the form stored in a recording
+func toRecorded(val interface{}) interface{} {
+	switch val := val.(type) {
+	case float64:
+		return map[string]interface{}{"$float": val}
+	case []interface{}:
+		recorded := make([]interface{}, len(val))
+		for i, item := range val {
+			recorded[i] = toRecorded(item)
+		}
+		return recorded
+	case map[string]interface{}:
+		recorded := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			if strings.HasPrefix(k, "$") {
+				k = "$" + k
+			}
+			recorded[k] = toRecorded(v)
+		}
+		return recorded
+	case structures.Raw:
+		return map[string]interface{}{"$signature": val.Signature, "fields": toRecorded(val.Fields)}
+	case time.Time:
+		return toRecorded(temporal.DateTime{Time: val})
+	case structures.Structure:
+		return map[string]interface{}{"$signature": byte(val.Signature()), "fields": toRecorded(val.AllFields())}
+	default:
+		return val
+	}
+}
+
+// fromRecorded converts a value stored in a recording back to the value to encode
+func fromRecorded(val interface{}) interface{} {
+	switch val := val.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		converted := make([]interface{}, len(val))
+		for i, item := range val {
+			converted[i] = fromRecorded(item)
+		}
+		return converted
+	case map[string]interface{}:
+		if f, ok := val["$float"].(json.Number); ok {
+			converted, _ := f.Float64()
+			return converted
+		}
+		if signature, ok := val["$signature"]; ok {
+			fields, _ := fromRecorded(val["fields"]).([]interface{})
+			return structures.Raw{Signature: byte(fromRecorded(signature).(int64)), Fields: fields}
+		}
+		converted := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			converted[strings.TrimPrefix(k, "$")] = fromRecorded(v)
+		}
+		return converted
+	default:
+		return val
+	}
+}
+
+// countMessages counts the complete messages in chunked data, and whether
+// the data ends at the end of a message
+func countMessages(data []byte) (int, bool) {
+	count, pos := 0, 0
+	for pos+2 <= len(data) {
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		pos += 2
+		if length == 0 {
+			count++
+		}
+		pos += length
+	}
+	return count, pos == len(data)
+}
+
 func newEvent(isWrite bool) *Event {
 	return &Event{
 		Timestamp: time.Now().UnixNano(),
@@ -0,0 +1,191 @@
+package golangNeo4jBoltDriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+func encodeMessages(t *testing.T, msgs ...interface{}) []byte {
+	buf := &bytes.Buffer{}
+	enc := encoding.NewEncoder(buf, math.MaxUint16)
+	for _, msg := range msgs {
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("An error occurred encoding message: %s", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRecorder_Assert(t *testing.T) {
+	recorded := encodeMessages(t, messages.NewRunMessage("RETURN 1", nil), messages.NewPullAllMessage())
+
+	matching := encodeMessages(t, messages.NewRunMessage("RETURN 1", nil), messages.NewPullAllMessage())
+	r := &recorder{assert: true, events: []*Event{{Event: append([]byte{}, recorded...), IsWrite: true}}}
+	if _, err := r.Write(matching); err != nil {
+		t.Fatalf("Expected matching messages to pass, got: %s", err)
+	}
+
+	different := encodeMessages(t, messages.NewRunMessage("RETURN 2", nil), messages.NewDiscardAllMessage())
+	if len(different) != len(recorded) {
+		t.Fatalf("Expected messages of the same length, got %d and %d", len(different), len(recorded))
+	}
+	r = &recorder{assert: true, events: []*Event{{Event: append([]byte{}, recorded...), IsWrite: true}}}
+	_, err := r.Write(different)
+	if err == nil {
+		t.Fatalf("Expected an error for messages that don't match the recording")
+	}
+	for _, line := range []string{"- Run RETURN 1", "+ Run RETURN 2", "- PullAll", "+ DiscardAll"} {
+		if !strings.Contains(err.Error(), line) {
+			t.Errorf("Expected diff to contain %q, got: %s", line, err)
+		}
+	}
+
+	r = &recorder{events: []*Event{{Event: append([]byte{}, recorded...), IsWrite: true}}}
+	if _, err := r.Write(different); err != nil {
+		t.Fatalf("Expected no assertion without assert mode, got: %s", err)
+	}
+}
+
+func TestRecorder_AssertPlayback(t *testing.T) {
+	if neo4jConnStr != "" {
+		t.Skip("Only asserts when playing back recordings")
+	}
+
+	driver := NewDriver()
+	r := newRecorder("TestBoltConn_SelectAll", "")
+	r.assert = true
+	driver.(*boltDriver).recorder = r
+
+	conn, err := driver.OpenNeo("")
+	if err != nil {
+		t.Fatalf("An error occurred opening conn asserting the handshake: %s", err)
+	}
+	// Any message that doesn't match the recording fails the query
+	if _, err := conn.ExecNeo("CREATE (f:NODE {a: 1}), (b:NODE {a: 2})", nil); err != nil {
+		t.Fatalf("An error occurred asserting create: %s", err)
+	}
+	if _, _, _, err := conn.QueryNeoAll("MATCH (n:NODE) RETURN n.a ORDER BY n.a", nil); err != nil {
+		t.Fatalf("An error occurred asserting query: %s", err)
+	}
+	if _, err := conn.ExecNeo("MATCH (n:NODE) DELETE n", nil); err != nil {
+		t.Fatalf("An error occurred asserting delete: %s", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("An error occurred closing conn: %s", err)
+	}
+
+	// Only the preamble of the handshake is asserted
+	handshake := append([]byte{}, magicPreamble...)
+	handshake = append(handshake, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	r = &recorder{assert: true, events: []*Event{{Event: append([]byte{}, handshake...), IsWrite: true}}}
+	if _, err := r.Write(handShake); err != nil {
+		t.Fatalf("Expected a handshake proposing other versions to pass, got: %s", err)
+	}
+	r = &recorder{assert: true, events: []*Event{{Event: append([]byte{}, handshake...), IsWrite: true}}}
+	if _, err := r.Write(make([]byte, len(handshake))); err == nil {
+		t.Fatal("Expected an error for a handshake without the magic preamble")
+	}
+}
+
+func TestRecorder_Messages(t *testing.T) {
+	params := map[string]interface{}{"a": int64(1), "b": 1.0, "c": []interface{}{"x", nil, true}}
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"Foo"}, Properties: map[string]interface{}{"f": 2.5}}
+	read := encodeMessages(t,
+		messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}),
+		messages.NewRecordMessage([]interface{}{node}),
+		messages.NewSuccessMessage(map[string]interface{}{}),
+	)
+
+	r := &recorder{events: []*Event{
+		{Event: []byte{0x60, 0x60, 0xB0, 0x17, 0, 0, 0, 1}, IsWrite: true, Completed: true},
+		{Event: []byte{0, 0, 0, 1}, Completed: true},
+		{Event: encodeMessages(t, messages.NewRunMessage("RETURN $a", params), messages.NewPullAllMessage()), IsWrite: true, Completed: true},
+		{Event: read, Completed: true},
+	}}
+	r.recordMessages()
+
+	recording, err := json.Marshal(r.events)
+	if err != nil {
+		t.Fatalf("An error occurred marshalling recording: %s", err)
+	}
+
+	r = &recorder{}
+	if err := r.loadEvents(bytes.NewReader(recording)); err != nil {
+		t.Fatalf("An error occurred loading recording: %s", err)
+	}
+	if r.events[0].Messages != nil || r.events[1].Messages != nil {
+		t.Fatalf("Expected the handshake to be recorded as bytes. Got: %s", recording)
+	}
+	if len(r.events[2].Messages) != 2 || r.events[2].Messages[0].Type != "RUN" || r.events[2].Messages[1].Type != "PULL_ALL" {
+		t.Fatalf("Expected RUN and PULL_ALL to be recorded. Got: %s", recording)
+	}
+
+	// Writes match the recorded messages, however they're chunked
+	r.currentEvent = 2
+	buf := &bytes.Buffer{}
+	encoder := encoding.NewEncoder(buf, 5)
+	encoder.Encode(messages.NewRunMessage("RETURN $a", params))
+	encoder.Encode(messages.NewPullAllMessage())
+	for _, b := range buf.Bytes() {
+		if _, err := r.Write([]byte{b}); err != nil {
+			t.Fatalf("Expected written messages to match the recording. Got: %s", err)
+		}
+	}
+
+	// Reads are encoded from the recorded messages
+	decoder := encoding.NewDecoder(r)
+	for _, expected := range []interface{}{
+		messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}),
+		messages.NewRecordMessage([]interface{}{node}),
+		messages.NewSuccessMessage(map[string]interface{}{}),
+	} {
+		decoded, err := decoder.Decode()
+		if err != nil {
+			t.Fatalf("An error occurred decoding recorded read: %s", err)
+		}
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Fatalf("Unexpected recorded read. Expected: %#v Got: %#v", expected, decoded)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Expected the whole recording to be played back. Got: %s", err)
+	}
+
+	r.currentEvent = 2
+	_, err = r.Write(encodeMessages(t, messages.NewRunMessage("RETURN $a", map[string]interface{}{"a": 1.0}), messages.NewPullAllMessage()))
+	if err == nil || !strings.Contains(err.Error(), `"$float"`) {
+		t.Fatalf("Expected an error for a parameter that doesn't match the recording. Got: %v", err)
+	}
+}
+
+func TestRecorder_DollarKeys(t *testing.T) {
+	// Keys like the ones tagging floats and structures in a recording
+	params := map[string]interface{}{
+		"$float":     int64(1),
+		"$signature": "a",
+		"fields":     []interface{}{"b"},
+		"$$a":        map[string]interface{}{"$float": 1.5},
+	}
+
+	recorded, err := json.Marshal(toRecorded(params))
+	if err != nil {
+		t.Fatalf("An error occurred marshalling recorded params: %s", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(recorded))
+	decoder.UseNumber()
+	var loaded interface{}
+	if err := decoder.Decode(&loaded); err != nil {
+		t.Fatalf("An error occurred loading recorded params: %s", err)
+	}
+	if played := fromRecorded(loaded); !reflect.DeepEqual(played, params) {
+		t.Fatalf("Expected the params to be played back as they were. Got: %#v", played)
+	}
+}
@@ -13,6 +13,12 @@ type Result interface {
 	RowsAffected() (int64, error)
 	// Metadata returns the metadata response from neo4j
 	Metadata() map[string]interface{}
+	// ContainsUpdates returns true if the query made any changes to the graph,
+	// including property updates
+	ContainsUpdates() bool
+	// ContainsSystemUpdates returns true if the query made any changes
+	// to the system database
+	ContainsSystemUpdates() bool
 }
 
 type boltResult struct {
@@ -72,3 +78,42 @@ func (r boltResult) RowsAffected() (int64, error) {
 
 	return rowsAffected, nil
 }
+
+// ContainsUpdates returns true if the query made any changes to the graph, including
+// property updates. Uses the server's contains-updates flag when it's sent, otherwise
+// checks all of the update counters in the stats.
+func (r boltResult) ContainsUpdates() bool {
+	stats, ok := r.metadata["stats"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	if containsUpdates, ok := stats["contains-updates"].(bool); ok {
+		return containsUpdates
+	}
+
+	for key, value := range stats {
+		if key == "contains-system-updates" || key == "system-updates" {
+			continue
+		}
+		if count, ok := value.(int64); ok && count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsSystemUpdates returns true if the query made any changes to the system database
+func (r boltResult) ContainsSystemUpdates() bool {
+	stats, ok := r.metadata["stats"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	if containsUpdates, ok := stats["contains-system-updates"].(bool); ok {
+		return containsUpdates
+	}
+
+	systemUpdates, ok := stats["system-updates"].(int64)
+	return ok && systemUpdates > 0
+}
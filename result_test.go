@@ -0,0 +1,30 @@
+package golangNeo4jBoltDriver
+
+import "testing"
+
+func TestBoltResult_ContainsUpdates(t *testing.T) {
+	result := newResult(map[string]interface{}{})
+	if result.ContainsUpdates() || result.ContainsSystemUpdates() {
+		t.Fatal("Expected no updates when stats are missing")
+	}
+
+	result = newResult(map[string]interface{}{
+		"stats": map[string]interface{}{"properties-set": int64(2)},
+	})
+	if !result.ContainsUpdates() {
+		t.Fatal("Expected updates from properties set")
+	}
+	if result.ContainsSystemUpdates() {
+		t.Fatal("Expected no system updates from properties set")
+	}
+
+	result = newResult(map[string]interface{}{
+		"stats": map[string]interface{}{"contains-updates": false, "system-updates": int64(1)},
+	})
+	if result.ContainsUpdates() {
+		t.Fatal("Expected the contains-updates flag to be used")
+	}
+	if !result.ContainsSystemUpdates() {
+		t.Fatal("Expected system updates")
+	}
+}
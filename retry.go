@@ -0,0 +1,193 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// RetryPolicy configures how RunWithRetry and pools retry a query, and how
+// the neo4j package retries deadlocked transactions
+type RetryPolicy struct {
+	// MaxRetries is the number of times to retry after the first attempt
+	MaxRetries int
+	// InitialBackoff is how long to wait before the first retry.
+	// The wait doubles after each retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+	// Jitter randomizes each wait by up to this fraction of it, e.g. 0.2 for
+	// up to 20% shorter or longer, so clients that failed together don't
+	// retry together.  0 means no jitter.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries 5 times, backing off from 100ms to 5s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// Backoff gets how long to wait before the given retry, starting from 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < retry && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return wait
+}
+
+// Delay gets how long to wait before the given retry, starting from 1, with
+// the jitter applied to the backoff
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	wait := p.Backoff(retry)
+	if p.Jitter > 0 {
+		wait += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(wait))
+	}
+	return wait
+}
+
+// DeadlockCode is the code of the failure Neo4j reports when it detects
+// a deadlock between transactions
+const DeadlockCode = "Neo.TransientError.Transaction.DeadlockDetected"
+
+// IsDeadlock checks whether an error is Neo4j detecting a deadlock between
+// transactions.  The transaction that failed can only be rolled back, but
+// running it again will usually succeed.
+func IsDeadlock(err error) bool {
+	if e, ok := err.(*errors.Error); ok {
+		err = e.InnerMost()
+	}
+
+	switch e := err.(type) {
+	case messages.FailureMessage:
+		code, _ := e.Metadata["code"].(string)
+		return code == DeadlockCode
+	case *TxFailedError:
+		return IsDeadlock(e.Failure)
+	case *BatchError:
+		return IsDeadlock(e.Err)
+	}
+	return false
+}
+
+// IsRetryable checks whether an error is worth retrying: a transient
+// failure reported by Neo4j (e.g. a deadlock), or a broken connection
+func IsRetryable(err error) bool {
+	if e, ok := err.(*errors.Error); ok {
+		err = e.InnerMost()
+	}
+
+	switch e := err.(type) {
+	case messages.FailureMessage:
+		code, _ := e.Metadata["code"].(string)
+		return strings.HasPrefix(code, "Neo.TransientError.")
+	case *ServerClosedError:
+		return true
+	case *TxFailedError:
+		return IsRetryable(e.Failure)
+	case *BatchError:
+		return IsRetryable(e.Err)
+	case net.Error:
+		return true
+	}
+	return err == driver.ErrBadConn
+}
+
+// RunWithRetry runs a query on a connection from the pool, retrying transient
+// and connection errors with capped exponential backoff.  Each attempt borrows
+// a connection, so a broken connection is replaced by a fresh one.
+//
+// The query is run outside of a transaction, and may be run more than once,
+// so it must be idempotent.
+func RunWithRetry(pool DriverPool, query string, params map[string]interface{}, policy RetryPolicy) ([][]interface{}, []string, Summary, error) {
+	var logger *log.Logger
+	if d, ok := pool.(*boltDriverPool); ok {
+		logger = d.poolLogger()
+	}
+	return runWithRetry(pool.OpenPool, logger, query, params, policy, time.Sleep)
+}
+
+// SetRetryPolicy sets the policy QueryNeoAll and QueryConcurrently retry with
+func (d *boltDriverPool) SetRetryPolicy(policy RetryPolicy) {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	d.retryPolicy = policy
+}
+
+// retry gets the pool's retry policy
+func (d *boltDriverPool) retry() RetryPolicy {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	return d.retryPolicy
+}
+
+// poolLogger gets the pool's logger
+func (d *boltDriverPool) poolLogger() *log.Logger {
+	d.confLock.Lock()
+	defer d.confLock.Unlock()
+	return d.logger
+}
+
+// QueryNeoAll runs a query on a connection from the pool, retrying it on a
+// fresh connection as set by SetRetryPolicy.  See RunWithRetry.
+func (d *boltDriverPool) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	return runWithRetry(d.OpenPool, d.poolLogger(), query, params, d.retry(), time.Sleep)
+}
+
+func runWithRetry(open func() (Conn, error), logger *log.Logger, query string, params map[string]interface{}, policy RetryPolicy, sleep func(time.Duration)) ([][]interface{}, []string, Summary, error) {
+	var data [][]interface{}
+	var fields []string
+	var summary Summary
+	err := retryWork(logger, policy, IsRetryable, sleep, func() error {
+		var err error
+		data, fields, summary, err = runOnce(open, query, params)
+		return err
+	})
+	if err != nil {
+		return nil, nil, Summary{}, err
+	}
+	return data, fields, summary, nil
+}
+
+// Retry runs the work, running it again with the policy's backoff while it
+// fails with an error retryable allows, e.g. IsRetryable or IsDeadlock.
+// The error from the last attempt is returned.
+func Retry(policy RetryPolicy, retryable func(error) bool, work func() error) error {
+	return retryWork(nil, policy, retryable, time.Sleep, work)
+}
+
+// retryWork runs the work as Retry does, logging each retry with the
+// logger. A nil logger logs with the package level loggers.
+func retryWork(logger *log.Logger, policy RetryPolicy, retryable func(error) bool, sleep func(time.Duration), work func() error) error {
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			sleep(policy.Delay(retry))
+		}
+
+		err := work()
+		if err == nil || !retryable(err) || retry >= policy.MaxRetries {
+			return err
+		}
+		logger.Errorf("Retrying after attempt %d failed: %s", retry+1, err)
+	}
+}
+
+func runOnce(open func() (Conn, error), query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	conn, err := open()
+	if err != nil {
+		return nil, nil, Summary{}, err
+	}
+	defer conn.Close()
+	return conn.QueryNeoAllSummary(query, params)
+}
@@ -0,0 +1,183 @@
+package golangNeo4jBoltDriver
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// retryConn fails with the queued errors, then returns a row
+type retryConn struct {
+	Conn
+	errs *[]error
+}
+
+func (c retryConn) QueryNeoAllSummary(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	if len(*c.errs) > 0 {
+		err := (*c.errs)[0]
+		*c.errs = (*c.errs)[1:]
+		return nil, nil, Summary{}, err
+	}
+	return [][]interface{}{{int64(1)}}, []string{"1"}, Summary{}, nil
+}
+
+func (c retryConn) Close() error {
+	return nil
+}
+
+func TestRunWithRetry(t *testing.T) {
+	transient := errors.Wrap(messages.NewFailureMessage(map[string]interface{}{"code": "Neo.TransientError.Transaction.DeadlockDetected"}), "Neo4J reported a failure for the query")
+	errs := []error{transient, driver.ErrBadConn}
+	opened := 0
+	open := func() (Conn, error) {
+		opened++
+		return retryConn{errs: &errs}, nil
+	}
+
+	waits := []time.Duration{}
+	sleep := func(d time.Duration) { waits = append(waits, d) }
+
+	policy := RetryPolicy{MaxRetries: 3, InitialBackoff: time.Second, MaxBackoff: 1500 * time.Millisecond}
+	data, _, _, err := runWithRetry(open, nil, "RETURN 1", nil, policy, sleep)
+	if err != nil {
+		t.Fatalf("Unexpected error after retries: %s", err)
+	}
+	if len(data) != 1 || opened != 3 {
+		t.Fatalf("Expected a result on the third connection. Got %#v after %d connections", data, opened)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 1500*time.Millisecond {
+		t.Fatalf("Unexpected backoff: %#v", waits)
+	}
+
+	errs = []error{messages.NewFailureMessage(map[string]interface{}{"code": "Neo.ClientError.Statement.SyntaxError"})}
+	if _, _, _, err := runWithRetry(open, nil, "RETURN", nil, policy, sleep); err == nil {
+		t.Fatal("Expected client errors not to be retried")
+	}
+	if len(errs) != 0 || len(waits) != 2 {
+		t.Fatalf("Expected a single attempt for a client error")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	deadlock := messages.NewFailureMessage(map[string]interface{}{"code": DeadlockCode})
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	attempts := 0
+	err := Retry(policy, IsDeadlock, func() error {
+		attempts++
+		return deadlock
+	})
+	if err == nil || attempts != 3 {
+		t.Fatalf("Expected the deadlock after running out of retries. Got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = Retry(policy, IsDeadlock, func() error {
+		attempts++
+		return driver.ErrBadConn
+	})
+	if err != driver.ErrBadConn || attempts != 1 {
+		t.Fatalf("Expected errors the predicate doesn't allow not to be retried. Got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestIsDeadlock(t *testing.T) {
+	failure := messages.NewFailureMessage(map[string]interface{}{"code": DeadlockCode})
+	for _, err := range []error{
+		failure,
+		errors.Wrap(failure, "Neo4J reported a failure for the query"),
+		&TxFailedError{Failure: failure, RolledBack: true},
+		&BatchError{Index: 1, Err: errors.Wrap(failure, "Neo4J reported a failure for the query")},
+	} {
+		if !IsDeadlock(err) {
+			t.Fatalf("Expected a deadlock: %#v", err)
+		}
+	}
+
+	lockTimeout := messages.NewFailureMessage(map[string]interface{}{"code": "Neo.TransientError.Transaction.LockClientStopped"})
+	if IsDeadlock(lockTimeout) || IsDeadlock(driver.ErrBadConn) {
+		t.Fatal("Expected only deadlocks to be detected")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	transient := messages.NewFailureMessage(map[string]interface{}{"code": "Neo.TransientError.Transaction.LockClientStopped"})
+	for _, err := range []error{
+		transient,
+		errors.Wrap(transient, "Neo4J reported a failure for the query"),
+		&TxFailedError{Failure: transient, RolledBack: true},
+		&BatchError{Index: 1, Err: errors.Wrap(transient, "Neo4J reported a failure for the query")},
+		driver.ErrBadConn,
+	} {
+		if !IsRetryable(err) {
+			t.Fatalf("Expected a retryable error: %#v", err)
+		}
+	}
+
+	syntax := messages.NewFailureMessage(map[string]interface{}{"code": "Neo.ClientError.Statement.SyntaxError"})
+	if IsRetryable(syntax) || IsRetryable(&TxFailedError{Failure: syntax}) {
+		t.Fatal("Expected client errors not to be retryable")
+	}
+}
+
+func TestRetry_Logger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.New(&logs, log.TextFormat)
+	logger.SetLevel("error")
+	attempts := 0
+	err := retryWork(logger, RetryPolicy{MaxRetries: 1}, IsRetryable, func(time.Duration) {}, func() error {
+		attempts++
+		if attempts == 1 {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed. Got: %s", err)
+	}
+	if !strings.Contains(logs.String(), "Retrying after attempt 1 failed") {
+		t.Fatalf("Expected the retry to be logged with the logger. Got: %q", logs.String())
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	if policy.Delay(2) != 2*time.Second {
+		t.Fatalf("Expected no jitter by default. Got: %s", policy.Delay(2))
+	}
+
+	policy.Jitter = 0.25
+	varied := false
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(2)
+		if delay < 1500*time.Millisecond || delay > 2500*time.Millisecond {
+			t.Fatalf("Expected the delay to be within 25%% of 2s. Got: %s", delay)
+		}
+		varied = varied || delay != 2*time.Second
+	}
+	if !varied {
+		t.Fatal("Expected the jitter to vary the delay")
+	}
+}
+
+func TestBoltDriverPool_SetRetryPolicy(t *testing.T) {
+	pool, err := createDriverPool("bolt://localhost:7687", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating the pool: %s", err)
+	}
+	pool.SetRetryPolicy(DefaultRetryPolicy)
+
+	partition, err := pool.Partition("bulk", 1)
+	if err != nil {
+		t.Fatalf("An error occurred creating the partition: %s", err)
+	}
+	if partition.(*boltDriverPool).retry() != DefaultRetryPolicy {
+		t.Fatalf("Expected the partition to inherit the retry policy. Got: %#v", partition.(*boltDriverPool).retry())
+	}
+}
@@ -2,6 +2,8 @@ package golangNeo4jBoltDriver
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"io"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
@@ -9,6 +11,7 @@ import (
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
 )
 
 // Rows represents results of rows from the DB
@@ -20,7 +23,14 @@ type Rows interface {
 	// Columns Gets the names of the columns in the returned dataset
 	Columns() []string
 	// Metadata Gets all of the metadata returned from Neo on query start
+	//
+	// Deprecated: Use RunMetadata, which is less easily confused with Summary
 	Metadata() map[string]interface{}
+	// RunMetadata Gets all of the metadata returned from Neo on query start
+	RunMetadata() map[string]interface{}
+	// Summary Gets the summary of the query, from the metadata returned from Neo
+	// once all of the rows are read.  Returns an error if NextNeo hasn't returned io.EOF yet.
+	Summary() (Summary, error)
 	// Close the rows, flushing any existing datastream
 	Close() error
 	// NextNeo gets the next row result
@@ -30,6 +40,33 @@ type Rows interface {
 	// All gets all of the results from the row set. It's recommended to use NextNeo when
 	// there are a lot of rows
 	All() ([][]interface{}, map[string]interface{}, error)
+	// Err gets the error that stopped iteration with NextNeo, if any.
+	// Reaching the end of the rows (io.EOF) is not an error.
+	Err() error
+	// Stream passes each row to the sink as it's read, without holding on
+	// to them like All.  When the rows are completed, returns the success
+	// metadata.  If the sink stops the stream, returns nil metadata, and
+	// the rest of the rows are discarded when the rows are closed.
+	Stream(sink RowSink) (map[string]interface{}, error)
+	// ColumnTypesNeo gets the type of each column, in the same order as
+	// Columns, as observed in the rows read so far.  Returns nil until
+	// the first row is read.  A column that has only been null is
+	// ColumnNull until a row with a value for it is read.
+	ColumnTypesNeo() []ColumnType
+	// StructScan reads the next row into the struct dest points to, by
+	// column and property names.  When the rows are completed, returns
+	// io.EOF.  See ScanStruct.
+	StructScan(dest interface{}) error
+}
+
+// RowSink receives the rows streamed by Rows.Stream.  The next row isn't
+// read until Row returns, so a slow sink holds back the stream.
+type RowSink interface {
+	// Columns is called once with the columns, before any rows
+	Columns(columns []string) error
+	// Row is called with each row as it's read.  Returning false stops
+	// the stream, and returning an error stops it with that error.
+	Row(row []interface{}) (bool, error)
 }
 
 // PipelineRows represents results of a set of rows from the DB
@@ -52,6 +89,33 @@ type PipelineRows interface {
 	NextPipeline() ([]interface{}, map[string]interface{}, PipelineRows, error)
 }
 
+// MissingFields is what rows do when Neo4j doesn't return the names of
+// their columns, as some procedures don't.  Set with ConnOptions.MissingFields
+// or the missing_fields connection param.
+type MissingFields string
+
+const (
+	// MissingFieldsEmpty makes Columns return no columns.  This is the default.
+	MissingFieldsEmpty MissingFields = "empty"
+	// MissingFieldsColumns makes Columns name the columns col0..colN, from
+	// the first row.  Columns reads the first row ahead if it hasn't been
+	// read yet, and NextNeo still returns it.
+	MissingFieldsColumns MissingFields = "columns"
+	// MissingFieldsError fails the query with a *NoFieldsError
+	MissingFieldsError MissingFields = "error"
+)
+
+// NoFieldsError is returned when Neo4j doesn't return the names of the
+// columns of a query, and the connection is set to MissingFieldsError
+type NoFieldsError struct {
+	Query string
+}
+
+// Error implements the error interface
+func (e *NoFieldsError) Error() string {
+	return fmt.Sprintf("Neo4j didn't return the fields of query: %s", e.Query)
+}
+
 type boltRows struct {
 	metadata        map[string]interface{}
 	statement       *boltStmt
@@ -60,6 +124,14 @@ type boltRows struct {
 	finishedConsume bool
 	pipelineIndex   int
 	closeStatement  bool
+	err             error
+	summary         map[string]interface{}
+	columnTypes     []ColumnType
+	pipelined       bool
+	peeked          bool
+	peekRow         []interface{}
+	peekMeta        map[string]interface{}
+	peekErr         error
 }
 
 func newRows(statement *boltStmt, metadata map[string]interface{}) *boltRows {
@@ -80,6 +152,7 @@ func newPipelineRows(statement *boltStmt, metadata map[string]interface{}, pipel
 	rows := newRows(statement, metadata)
 	rows.consumed = true // Already consumed from pipeline with PULL_ALL
 	rows.pipelineIndex = pipelineIndex
+	rows.pipelined = true
 	return rows
 }
 
@@ -91,25 +164,41 @@ func newQueryPipelineRows(statement *boltStmt, metadata map[string]interface{},
 
 // Columns returns the columns from the result
 func (r *boltRows) Columns() []string {
-	fieldsInt, ok := r.metadata["fields"]
-	if !ok {
-		return []string{}
+	fields, ok := metadataFields(r.metadata)
+	if ok || r.statement.conn.missingFields != MissingFieldsColumns {
+		return fields
 	}
+	return r.numberedColumns()
+}
 
-	fields, ok := fieldsInt.([]interface{})
-	if !ok {
-		log.Errorf("Unrecognized fields from success message: %#v", fieldsInt)
-		return []string{}
+// numberedColumns names the columns col0..colN, reading ahead to the
+// first row if needed.  Pipelined rows can't be read ahead, so they
+// only have columns once a row has been read.
+func (r *boltRows) numberedColumns() []string {
+	if r.columnTypes == nil && !r.pipelined && !r.closed && !r.finishedConsume && !r.peeked {
+		r.peekRow, r.peekMeta, r.peekErr = r.nextNeo()
+		r.peeked = true
 	}
 
-	fieldsStr := make([]string, len(fields))
-	for i, f := range fields {
-		if fieldsStr[i], ok = f.(string); !ok {
-			log.Errorf("Unrecognized fields from success message: %#v", fieldsInt)
-			return []string{}
-		}
+	columns := make([]string, len(r.columnTypes))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("col%d", i)
 	}
-	return fieldsStr
+	return columns
+}
+
+// metadataFields gets the field names from run metadata. Returns false
+// if the fields are missing or unrecognized.
+func metadataFields(metadata map[string]interface{}) ([]string, bool) {
+	if _, ok := metadata["fields"]; !ok {
+		return []string{}, false
+	}
+
+	fields, ok := messages.NewSuccessMessage(metadata).Fields()
+	if !ok {
+		log.Errorf("Unrecognized fields from success message: %#v", metadata["fields"])
+	}
+	return fields, ok
 }
 
 // Metadata Gets all of the metadata returned from Neo on query start
@@ -117,22 +206,59 @@ func (r *boltRows) Metadata() map[string]interface{} {
 	return r.metadata
 }
 
+// RunMetadata Gets all of the metadata returned from Neo on query start
+func (r *boltRows) RunMetadata() map[string]interface{} {
+	return r.metadata
+}
+
+// Summary Gets the summary of the query once all of the rows are read
+func (r *boltRows) Summary() (Summary, error) {
+	if r.summary == nil {
+		return Summary{}, errors.New("Summary is only available once all rows have been read")
+	}
+	return newSummary(r.metadata, r.summary), nil
+}
+
 // Close closes the rows
 func (r *boltRows) Close() error {
 	if r.closed {
 		return nil
 	}
 
+	conn := r.statement.conn
+	if conn.asyncClose && (!r.consumed || !r.finishedConsume) {
+		// Hand the rest of the stream off to be discarded in the background.
+		// The connection waits on it before it's used again.
+		drained := make(chan error, 1)
+		conn.draining = drained
+		go func() {
+			drained <- safely("draining rows", func() error { return r.drain(conn) })
+		}()
+	} else if err := r.drain(conn); err != nil {
+		return err
+	}
+
+	r.closed = true
+	r.statement.rows = nil
+
+	if r.closeStatement {
+		return r.statement.Close()
+	}
+	return nil
+}
+
+// drain discards or consumes the rest of the stream, if it hasn't been read
+func (r *boltRows) drain(conn *boltConn) error {
 	if !r.consumed {
 		// Discard all messages if not consumed
-		respInt, err := r.statement.conn.sendDiscardAllConsume()
+		respInt, err := conn.sendDiscardAllConsume()
 		if err != nil {
 			return errors.Wrap(err, "An error occurred discarding messages on row close")
 		}
 
 		switch resp := respInt.(type) {
 		case messages.SuccessMessage:
-			log.Infof("Got success message: %#v", resp)
+			conn.logger.Infof("Got success message: %#v", resp)
 		default:
 			return errors.New("Unrecognized response type discarding all rows: Value: %#v", resp)
 		}
@@ -154,18 +280,12 @@ func (r *boltRows) Close() error {
 
 		// Clear out all unconsumed messages if we
 		// never finished consuming them.
-		_, _, err := r.statement.conn.consumeAllMultiple(numConsume)
+		_, _, err := conn.consumeAllMultiple(numConsume)
 		if err != nil {
 			return errors.Wrap(err, "An error occurred clearing out unconsumed stream")
 		}
 	}
 
-	r.closed = true
-	r.statement.rows = nil
-
-	if r.closeStatement {
-		return r.statement.Close()
-	}
 	return nil
 }
 
@@ -179,10 +299,28 @@ func (r *boltRows) Next(dest []driver.Value) error {
 	for i, item := range data {
 		switch item := item.(type) {
 		case []interface{}, map[string]interface{}, graph.Node, graph.Path, graph.Relationship, graph.UnboundRelationship:
-			dest[i], err = encoding.Marshal(item)
+			if r.statement.conn.graphEncoding == "json" {
+				dest[i], err = json.Marshal(item)
+			} else {
+				dest[i], err = encoding.Marshal(item)
+			}
 			if err != nil {
 				return err
 			}
+		case graph.Point2D:
+			dest[i] = item.String()
+		case graph.Point3D:
+			dest[i] = item.String()
+		case temporal.Date:
+			dest[i] = item.Time
+		case temporal.Time:
+			dest[i] = item.Time
+		case temporal.LocalTime:
+			dest[i] = item.Time
+		case temporal.LocalDateTime:
+			dest[i] = item.Time
+		case temporal.Duration:
+			dest[i] = item.String()
 		default:
 			dest[i], err = driver.DefaultParameterConverter.ConvertValue(item)
 			if err != nil {
@@ -199,9 +337,26 @@ func (r *boltRows) Next(dest []driver.Value) error {
 // When the rows are completed, returns the success metadata
 // and io.EOF
 func (r *boltRows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	row, metadata, err := r.nextNeo()
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return row, metadata, err
+}
+
+// Err gets the error that stopped iteration with NextNeo, if any
+func (r *boltRows) Err() error {
+	return r.err
+}
+
+func (r *boltRows) nextNeo() ([]interface{}, map[string]interface{}, error) {
 	if r.closed {
 		return nil, nil, errors.New("Rows are already closed")
 	}
+	if r.peeked {
+		r.peeked = false
+		return r.peekRow, r.peekMeta, r.peekErr
+	}
 
 	if !r.consumed {
 		r.consumed = true
@@ -218,17 +373,29 @@ func (r *boltRows) NextNeo() ([]interface{}, map[string]interface{}, error) {
 
 	switch resp := respInt.(type) {
 	case messages.SuccessMessage:
-		log.Infof("Got success message: %#v", resp)
+		r.statement.conn.logger.Infof("Got success message: %#v", resp)
 		r.finishedConsume = true
+		r.summary = resp.Metadata
 		return nil, resp.Metadata, io.EOF
 	case messages.RecordMessage:
-		log.Infof("Got record message: %#v", resp)
+		r.statement.conn.logger.Infof("Got record message: %#v", resp)
+		r.columnTypes = observeColumnTypes(r.columnTypes, resp.Fields)
 		return resp.Fields, nil, nil
 	default:
 		return nil, nil, errors.New("Unrecognized response type getting next query row: %#v", resp)
 	}
 }
 
+// ColumnTypesNeo gets the type of each column observed in the rows read so far
+func (r *boltRows) ColumnTypesNeo() []ColumnType {
+	if r.columnTypes == nil {
+		return nil
+	}
+	types := make([]ColumnType, len(r.columnTypes))
+	copy(types, r.columnTypes)
+	return types
+}
+
 func (r *boltRows) All() ([][]interface{}, map[string]interface{}, error) {
 	output := [][]interface{}{}
 	for {
@@ -243,6 +410,30 @@ func (r *boltRows) All() ([][]interface{}, map[string]interface{}, error) {
 	}
 }
 
+// Stream passes each row to the sink as it's read
+func (r *boltRows) Stream(sink RowSink) (map[string]interface{}, error) {
+	if err := sink.Columns(r.Columns()); err != nil {
+		return nil, errors.Wrap(err, "An error occurred sending columns to the row sink")
+	}
+
+	for {
+		row, metadata, err := r.NextNeo()
+		if err == io.EOF {
+			return metadata, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		more, err := sink.Row(row)
+		if err != nil {
+			return nil, errors.Wrap(err, "An error occurred sending a row to the row sink")
+		}
+		if !more {
+			return nil, nil
+		}
+	}
+}
+
 // NextPipeline gets the next row result
 // When the rows are completed, returns the success metadata and the next
 // set of rows.
@@ -259,7 +450,7 @@ func (r *boltRows) NextPipeline() ([]interface{}, map[string]interface{}, Pipeli
 
 	switch resp := respInt.(type) {
 	case messages.SuccessMessage:
-		log.Infof("Got success message: %#v", resp)
+		r.statement.conn.logger.Infof("Got success message: %#v", resp)
 
 		if r.pipelineIndex == len(r.statement.queries)-1 {
 			r.finishedConsume = true
@@ -281,7 +472,7 @@ func (r *boltRows) NextPipeline() ([]interface{}, map[string]interface{}, Pipeli
 		return nil, success.Metadata, r.statement.rows, nil
 
 	case messages.RecordMessage:
-		log.Infof("Got record message: %#v", resp)
+		r.statement.conn.logger.Infof("Got record message: %#v", resp)
 		return resp.Fields, nil, nil, nil
 	default:
 		return nil, nil, nil, errors.New("Unrecognized response type getting next pipeline row: %#v", resp)
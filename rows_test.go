@@ -0,0 +1,328 @@
+package golangNeo4jBoltDriver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+func TestBoltRows_Err(t *testing.T) {
+	rows := newRows(newStmt("RETURN 1", createBoltConn("")), nil)
+	if rows.Err() != nil {
+		t.Fatalf("Expected no error before iterating. Got: %s", rows.Err())
+	}
+
+	rows.closed = true
+	_, _, err := rows.NextNeo()
+	if err == nil {
+		t.Fatalf("Expected an error iterating closed rows")
+	}
+	if rows.Err() != err {
+		t.Fatalf("Expected Err to return the terminal error. Got: %#v", rows.Err())
+	}
+}
+
+func TestBoltRows_Summary(t *testing.T) {
+	runMetadata := map[string]interface{}{"fields": []interface{}{"1"}, "result_available_after": int64(1)}
+	rows := newRows(newStmt("RETURN 1", createBoltConn("")), runMetadata)
+	if rows.RunMetadata()["result_available_after"] != int64(1) {
+		t.Fatalf("Unexpected run metadata: %#v", rows.RunMetadata())
+	}
+
+	if _, err := rows.Summary(); err == nil {
+		t.Fatalf("Expected an error getting the summary before the rows are read")
+	}
+
+	rows.summary = map[string]interface{}{"type": "r", "result_consumed_after": int64(2)}
+	summary, err := rows.Summary()
+	if err != nil {
+		t.Fatalf("Unexpected error getting summary: %s", err)
+	}
+	if summary.Type != "r" || summary.ResultAvailableAfter != time.Millisecond || summary.ResultConsumedAfter != 2*time.Millisecond {
+		t.Fatalf("Unexpected summary: %#v", summary)
+	}
+}
+
+func TestBoltRows_NextGraphEncodingJSON(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.graphEncoding = "json"
+
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"FOO"}, Properties: map[string]interface{}{"a": "b"}}
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		encoder.Encode(messages.NewRecordMessage([]interface{}{node, int64(1)}))
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+	}()
+
+	c.statement = newStmt("MATCH (n) RETURN n, 1", c)
+	rows := newRows(c.statement, map[string]interface{}{"fields": []interface{}{"n", "1"}})
+	dest := make([]driver.Value, 2)
+	if err := rows.Next(dest); err != nil {
+		t.Fatalf("Error getting next row: %s", err)
+	}
+
+	expected, _ := json.Marshal(node)
+	if !bytes.Equal(dest[0].([]byte), expected) {
+		t.Fatalf("Expected node as JSON. Expected %s. Got: %s", expected, dest[0])
+	}
+	if dest[1] != int64(1) {
+		t.Fatalf("Expected scalars to be returned as is. Got: %#v", dest[1])
+	}
+}
+
+type testSink struct {
+	columns []string
+	rows    [][]interface{}
+	max     int
+}
+
+func (s *testSink) Columns(columns []string) error {
+	s.columns = columns
+	return nil
+}
+
+func (s *testSink) Row(row []interface{}) (bool, error) {
+	s.rows = append(s.rows, row)
+	return len(s.rows) < s.max, nil
+}
+
+func TestBoltRows_Stream(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		for i := int64(0); i < 3; i++ {
+			encoder.Encode(messages.NewRecordMessage([]interface{}{i}))
+		}
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"type": "r"}))
+	}()
+
+	c.statement = newStmt("UNWIND range(0, 2) AS x RETURN x", c)
+	rows := newRows(c.statement, map[string]interface{}{"fields": []interface{}{"x"}})
+	sink := &testSink{max: 10}
+	metadata, err := rows.Stream(sink)
+	if err != nil {
+		t.Fatalf("An error occurred streaming rows: %s", err)
+	}
+	if metadata["type"] != "r" {
+		t.Fatalf("Expected the success metadata. Got: %#v", metadata)
+	}
+	if len(sink.columns) != 1 || sink.columns[0] != "x" {
+		t.Fatalf("Expected the columns to be sent to the sink. Got: %#v", sink.columns)
+	}
+	if len(sink.rows) != 3 || sink.rows[2][0] != int64(2) {
+		t.Fatalf("Expected every row to be sent to the sink. Got: %#v", sink.rows)
+	}
+}
+
+func TestBoltRows_StreamStopped(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		for i := int64(0); i < 3; i++ {
+			encoder.Encode(messages.NewRecordMessage([]interface{}{i}))
+		}
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+	}()
+
+	c.statement = newStmt("UNWIND range(0, 2) AS x RETURN x", c)
+	rows := newRows(c.statement, map[string]interface{}{"fields": []interface{}{"x"}})
+	sink := &testSink{max: 1}
+	metadata, err := rows.Stream(sink)
+	if err != nil || metadata != nil {
+		t.Fatalf("Expected the sink to stop the stream. Got: %#v, %v", metadata, err)
+	}
+	if len(sink.rows) != 1 {
+		t.Fatalf("Expected the stream to stop after the first row. Got: %#v", sink.rows)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("An error occurred closing stopped rows: %s", err)
+	}
+}
+
+func TestBoltRows_ColumnTypesNeo(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(1), "a", nil, graph.Node{NodeIdentity: 1}}))
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(2), "b", []interface{}{1.5}, graph.Node{NodeIdentity: 2}}))
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+	}()
+
+	c.statement = newStmt("MATCH (n) RETURN n.id, n.name, n.scores, n", c)
+	rows := newRows(c.statement, map[string]interface{}{"fields": []interface{}{"n.id", "n.name", "n.scores", "n"}})
+	if types := rows.ColumnTypesNeo(); types != nil {
+		t.Fatalf("Expected no column types before the first row. Got: %#v", types)
+	}
+
+	if _, _, err := rows.NextNeo(); err != nil {
+		t.Fatalf("An error occurred getting the first row: %s", err)
+	}
+	expected := []ColumnType{ColumnInteger, ColumnString, ColumnNull, ColumnNode}
+	if types := rows.ColumnTypesNeo(); !reflect.DeepEqual(types, expected) {
+		t.Fatalf("Unexpected column types after the first row. Expected: %#v. Got: %#v", expected, types)
+	}
+
+	if _, _, err := rows.NextNeo(); err != nil {
+		t.Fatalf("An error occurred getting the second row: %s", err)
+	}
+	expected[2] = ColumnList
+	if types := rows.ColumnTypesNeo(); !reflect.DeepEqual(types, expected) {
+		t.Fatalf("Expected the null column to take the type of its first value. Expected: %#v. Got: %#v", expected, types)
+	}
+}
+
+func TestBoltRows_MissingFields(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.missingFields = MissingFieldsColumns
+
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(1), "a"}))
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(2), "b"}))
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+	}()
+
+	c.statement = newStmt("CALL db.something()", c)
+	rows := newRows(c.statement, map[string]interface{}{})
+	if columns := rows.Columns(); !reflect.DeepEqual(columns, []string{"col0", "col1"}) {
+		t.Fatalf("Expected numbered columns from the first row. Got: %#v", columns)
+	}
+	for i := int64(1); i <= 2; i++ {
+		row, _, err := rows.NextNeo()
+		if err != nil || row[0] != i {
+			t.Fatalf("Expected row %d after reading ahead for the columns. Got: %#v, %v", i, row, err)
+		}
+	}
+	if _, _, err := rows.NextNeo(); err != io.EOF {
+		t.Fatalf("Expected the end of the rows. Got: %v", err)
+	}
+
+	c.missingFields = MissingFieldsError
+	c.statement = newStmt("CALL db.something()", c)
+	rows = newRows(c.statement, map[string]interface{}{})
+	rows.consumed = true
+	err := c.checkFields(c.statement.query, rows)
+	if _, ok := err.(*NoFieldsError); !ok {
+		t.Fatalf("Expected a NoFieldsError. Got: %#v", err)
+	}
+	if !rows.closed {
+		t.Fatal("Expected the rows to be closed")
+	}
+	if err := c.checkFields("RETURN 1", newRows(c.statement, map[string]interface{}{"fields": []interface{}{"1"}})); err != nil {
+		t.Fatalf("Expected no error when the fields are returned. Got: %s", err)
+	}
+}
+
+func TestBoltRows_NextTemporal(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	date := temporal.NewDate(2018, time.March, 4)
+	dateTime := time.Date(2018, time.March, 4, 10, 30, 0, 0, time.FixedZone("", 3600))
+	duration := temporal.Duration{Days: 1, Seconds: 90}
+	point := graph.Point2D{SRID: graph.SRIDCartesian, X: 1, Y: 2.5}
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		encoder.Encode(messages.NewRecordMessage([]interface{}{date, dateTime, duration, point}))
+	}()
+
+	c.statement = newStmt("RETURN date(), datetime(), duration(), point({x: 1, y: 2.5})", c)
+	rows := newRows(c.statement, map[string]interface{}{"fields": []interface{}{"date()", "datetime()", "duration()", "point"}})
+	dest := make([]driver.Value, 4)
+	if err := rows.Next(dest); err != nil {
+		t.Fatalf("An error occurred getting temporal values: %s", err)
+	}
+	if dest[0] != date.Time {
+		t.Fatalf("Expected a date to be scanned as time.Time. Got: %#v", dest[0])
+	}
+	if scanned, ok := dest[1].(time.Time); !ok || !scanned.Equal(dateTime) {
+		t.Fatalf("Expected a date time to be scanned as time.Time. Got: %#v", dest[1])
+	}
+	if dest[2] != "P1DT90S" {
+		t.Fatalf("Expected a duration to be scanned as an ISO 8601 string. Got: %#v", dest[2])
+	}
+	if dest[3] != "SRID=7203;POINT(1 2.5)" {
+		t.Fatalf("Expected a point to be scanned as an extended WKT string. Got: %#v", dest[3])
+	}
+}
+
+// connector opens connections for database/sql with a function
+type connector func() (driver.Conn, error)
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c() }
+func (c connector) Driver() driver.Driver                        { return &boltDriver{} }
+
+func TestBoltRows_ScanTemporal(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := createBoltConn("")
+	c.conn = client
+	copy(c.serverVersion, []byte{0x00, 0x00, 0x00, 0x02})
+
+	date := temporal.NewDate(2018, time.March, 4)
+	dateTime := time.Date(2018, time.March, 4, 10, 30, 0, 0, time.FixedZone("", 3600))
+	localTime := temporal.NewLocalTime(10, 30, 0, 0)
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		// RUN and PULL_ALL, then the record
+		decoder.Decode()
+		decoder.Decode()
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"d", "dt", "t", "dur"}}))
+		encoder.Encode(messages.NewRecordMessage([]interface{}{date, dateTime, localTime, temporal.Duration{Days: 1}}))
+		encoder.Encode(messages.NewSuccessMessage(nil))
+		io.Copy(ioutil.Discard, server)
+	}()
+
+	db := sql.OpenDB(connector(func() (driver.Conn, error) { return c, nil }))
+	defer db.Close()
+
+	var scannedDate, scannedDateTime, scannedTime time.Time
+	var scannedDuration string
+	row := db.QueryRow("RETURN date() AS d, datetime() AS dt, localtime() AS t, duration('P1D') AS dur")
+	if err := row.Scan(&scannedDate, &scannedDateTime, &scannedTime, &scannedDuration); err != nil {
+		t.Fatalf("An error occurred scanning temporal values: %s", err)
+	}
+	if !scannedDate.Equal(date.Time) || !scannedDateTime.Equal(dateTime) || !scannedTime.Equal(localTime.Time) {
+		t.Fatalf("Unexpected times: %s %s %s", scannedDate, scannedDateTime, scannedTime)
+	}
+	if scannedDuration != "P1D" {
+		t.Fatalf("Unexpected duration: %s", scannedDuration)
+	}
+}
@@ -0,0 +1,510 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// SafeConn wraps a Conn so it can be shared between go routines.  Every
+// call on the connection, and on the statements, rows and transactions
+// created from it, is guarded by a single lock.
+//
+// A connection can still only run one statement at a time.  While a
+// statement or rows are open, calls that would start another one return
+// an error instead of interleaving with the open stream.  Transactions are
+// not tied to a go routine, so queries from other go routines while a
+// transaction is open will run inside of that transaction.
+//
+// If you need throughput rather than just safety, use a DriverPool or a Multiplexer.
+type SafeConn struct {
+	conn Conn
+	busy bool
+	lock sync.Mutex
+}
+
+var _ Conn = &SafeConn{}
+
+// NewSafeConn wraps the connection in a SafeConn
+func NewSafeConn(conn Conn) *SafeConn {
+	return &SafeConn{conn: conn}
+}
+
+func (s *SafeConn) checkBusy() error {
+	if s.busy {
+		return errors.New("Connection is in use by an open statement or rows. They must be closed before running another query")
+	}
+	return nil
+}
+
+// PrepareNeo prepares a neo4j specific statement
+func (s *SafeConn) PrepareNeo(query string) (Stmt, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.conn.PrepareNeo(query)
+	if err != nil {
+		return nil, err
+	}
+	s.busy = true
+	return &safeStmt{stmt: stmt, conn: s}, nil
+}
+
+// PreparePipeline prepares a neo4j specific pipeline statement
+func (s *SafeConn) PreparePipeline(query ...string) (PipelineStmt, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.conn.PreparePipeline(query...)
+	if err != nil {
+		return nil, err
+	}
+	s.busy = true
+	return &safePipelineStmt{stmt: stmt, conn: s}, nil
+}
+
+// QueryNeo queries using the neo4j-specific interface
+func (s *SafeConn) QueryNeo(query string, params map[string]interface{}) (Rows, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn.QueryNeo(query, params)
+	if err != nil {
+		return nil, err
+	}
+	s.busy = true
+	return &safeRows{rows: rows, conn: s, release: true}, nil
+}
+
+// QueryNeoAll queries using the neo4j-specific interface and returns all row data and output metadata
+func (s *SafeConn) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, nil, nil, err
+	}
+	return s.conn.QueryNeoAll(query, params)
+}
+
+// QueryNeoAllSummary queries using the neo4j-specific interface and returns all row data, the column names and the summary of the query
+func (s *SafeConn) QueryNeoAllSummary(query string, params map[string]interface{}) ([][]interface{}, []string, Summary, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, nil, Summary{}, err
+	}
+	return s.conn.QueryNeoAllSummary(query, params)
+}
+
+// QueryPipeline queries using the neo4j-specific interface pipelining multiple statements
+func (s *SafeConn) QueryPipeline(query []string, params ...map[string]interface{}) (PipelineRows, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn.QueryPipeline(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	s.busy = true
+	return &safePipelineRows{rows: rows, conn: s, release: true}, nil
+}
+
+// ExecNeo executes a query using the neo4j-specific interface
+func (s *SafeConn) ExecNeo(query string, params map[string]interface{}) (Result, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+	return s.conn.ExecNeo(query, params)
+}
+
+// ExecPipeline executes a query using the neo4j-specific interface pipelining multiple statements
+func (s *SafeConn) ExecPipeline(query []string, params ...map[string]interface{}) ([]Result, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+	return s.conn.ExecPipeline(query, params...)
+}
+
+// Close closes the connection
+func (s *SafeConn) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.Close()
+}
+
+// Begin starts a new transaction
+func (s *SafeConn) Begin() (driver.Tx, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &safeTx{tx: tx, conn: s}, nil
+}
+
+// BeginWithBookmarks starts a new transaction that waits on the given bookmarks
+func (s *SafeConn) BeginWithBookmarks(bookmarks ...string) (driver.Tx, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.conn.BeginWithBookmarks(bookmarks...)
+	if err != nil {
+		return nil, err
+	}
+	return &safeTx{tx: tx, conn: s}, nil
+}
+
+// BeginReadWithBookmarks starts a new read transaction that waits on the given bookmarks
+func (s *SafeConn) BeginReadWithBookmarks(bookmarks ...string) (driver.Tx, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.conn.BeginReadWithBookmarks(bookmarks...)
+	if err != nil {
+		return nil, err
+	}
+	return &safeTx{tx: tx, conn: s}, nil
+}
+
+// SetChunkSize sets the max chunk size of the bytes to send to Neo4j at once
+func (s *SafeConn) SetChunkSize(chunkSize uint16) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conn.SetChunkSize(chunkSize)
+}
+
+// SetTimeout sets the read/write timeouts for the connection to Neo4j
+func (s *SafeConn) SetTimeout(timeout time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conn.SetTimeout(timeout)
+}
+
+// SetBookmarkManager attaches the connection to a bookmark chain
+func (s *SafeConn) SetBookmarkManager(manager *BookmarkManager, chain string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conn.SetBookmarkManager(manager, chain)
+}
+
+// LastBookmark gets the bookmark returned by the last transaction committed on this connection
+func (s *SafeConn) LastBookmark() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.LastBookmark()
+}
+
+// StatementCacheStats gets the hit rate of the statement cache
+func (s *SafeConn) StatementCacheStats() StatementCacheStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.StatementCacheStats()
+}
+
+// Stats gets the age and usage of the connection
+func (s *SafeConn) Stats() ConnStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.Stats()
+}
+
+// Supports checks whether the connection supports a feature
+func (s *SafeConn) Supports(feature Feature) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.Supports(feature)
+}
+
+// Options gets the settings of the connection
+func (s *SafeConn) Options() ConnOptions {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conn.Options()
+}
+
+// SetOptions changes the settings of the connection
+func (s *SafeConn) SetOptions(opts ConnOptions) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conn.SetOptions(opts)
+}
+
+type safeStmt struct {
+	stmt Stmt
+	conn *SafeConn
+}
+
+func (s *safeStmt) Close() error {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	err := s.stmt.Close()
+	s.conn.busy = false
+	return err
+}
+
+func (s *safeStmt) ExecNeo(params map[string]interface{}) (Result, error) {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	return s.stmt.ExecNeo(params)
+}
+
+func (s *safeStmt) Columns() []string {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	return s.stmt.Columns()
+}
+
+func (s *safeStmt) QueryNeo(params map[string]interface{}) (Rows, error) {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	rows, err := s.stmt.QueryNeo(params)
+	if err != nil {
+		return nil, err
+	}
+	return &safeRows{rows: rows, conn: s.conn}, nil
+}
+
+type safePipelineStmt struct {
+	stmt PipelineStmt
+	conn *SafeConn
+}
+
+func (s *safePipelineStmt) Close() error {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	err := s.stmt.Close()
+	s.conn.busy = false
+	return err
+}
+
+func (s *safePipelineStmt) ExecPipeline(params ...map[string]interface{}) ([]Result, error) {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	return s.stmt.ExecPipeline(params...)
+}
+
+func (s *safePipelineStmt) QueryPipeline(params ...map[string]interface{}) (PipelineRows, error) {
+	s.conn.lock.Lock()
+	defer s.conn.lock.Unlock()
+	rows, err := s.stmt.QueryPipeline(params...)
+	if err != nil {
+		return nil, err
+	}
+	return &safePipelineRows{rows: rows, conn: s.conn}, nil
+}
+
+// safeRows guards rows with the connection lock. Rows from
+// a query on the connection itself release the connection when closed.
+type safeRows struct {
+	rows    Rows
+	conn    *SafeConn
+	release bool
+}
+
+func (r *safeRows) Columns() []string {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Columns()
+}
+
+func (r *safeRows) Metadata() map[string]interface{} {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Metadata()
+}
+
+func (r *safeRows) Close() error {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	err := r.rows.Close()
+	if r.release {
+		r.conn.busy = false
+	}
+	return err
+}
+
+func (r *safeRows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.NextNeo()
+}
+
+func (r *safeRows) StructScan(dest interface{}) error {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.StructScan(dest)
+}
+
+func (r *safeRows) RunMetadata() map[string]interface{} {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.RunMetadata()
+}
+
+func (r *safeRows) Summary() (Summary, error) {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Summary()
+}
+
+func (r *safeRows) Err() error {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Err()
+}
+
+func (r *safeRows) All() ([][]interface{}, map[string]interface{}, error) {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.All()
+}
+
+func (r *safeRows) Stream(sink RowSink) (map[string]interface{}, error) {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Stream(sink)
+}
+
+func (r *safeRows) ColumnTypesNeo() []ColumnType {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.ColumnTypesNeo()
+}
+
+type safePipelineRows struct {
+	rows    PipelineRows
+	conn    *SafeConn
+	release bool
+}
+
+func (r *safePipelineRows) Columns() []string {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Columns()
+}
+
+func (r *safePipelineRows) Metadata() map[string]interface{} {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	return r.rows.Metadata()
+}
+
+func (r *safePipelineRows) Close() error {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	err := r.rows.Close()
+	if r.release {
+		r.conn.busy = false
+	}
+	return err
+}
+
+func (r *safePipelineRows) NextPipeline() ([]interface{}, map[string]interface{}, PipelineRows, error) {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	row, metadata, next, err := r.rows.NextPipeline()
+	if next != nil {
+		next = &safePipelineRows{rows: next, conn: r.conn, release: r.release}
+	}
+	return row, metadata, next, err
+}
+
+type safeTx struct {
+	tx   driver.Tx
+	conn *SafeConn
+}
+
+func (t *safeTx) Commit() error {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	return t.tx.Commit()
+}
+
+func (t *safeTx) Rollback() error {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	return t.tx.Rollback()
+}
+
+func (t *safeTx) ExecNeo(query string, params map[string]interface{}) (Result, error) {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	if err := t.conn.checkBusy(); err != nil {
+		return nil, err
+	}
+	return t.tx.(Tx).ExecNeo(query, params)
+}
+
+func (t *safeTx) QueryNeo(query string, params map[string]interface{}) (Rows, error) {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	if err := t.conn.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	rows, err := t.tx.(Tx).QueryNeo(query, params)
+	if err != nil {
+		return nil, err
+	}
+	t.conn.busy = true
+	return &safeRows{rows: rows, conn: t.conn, release: true}, nil
+}
+
+func (t *safeTx) RunBatch(statements []Statement) ([]Result, error) {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	return t.tx.(Tx).RunBatch(statements)
+}
+
+func (t *safeTx) ExecPipeline(queries []string, params ...map[string]interface{}) ([]Result, error) {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	if err := t.conn.checkBusy(); err != nil {
+		return nil, err
+	}
+	return t.tx.(Tx).ExecPipeline(queries, params...)
+}
+
+func (t *safeTx) QueryPipeline(queries []string, params ...map[string]interface{}) (PipelineRows, error) {
+	t.conn.lock.Lock()
+	defer t.conn.lock.Unlock()
+	if err := t.conn.checkBusy(); err != nil {
+		return nil, err
+	}
+
+	rows, err := t.tx.(Tx).QueryPipeline(queries, params...)
+	if err != nil {
+		return nil, err
+	}
+	t.conn.busy = true
+	return &safePipelineRows{rows: rows, conn: t.conn, release: true}, nil
+}
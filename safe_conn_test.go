@@ -0,0 +1,51 @@
+package golangNeo4jBoltDriver
+
+import (
+	"io"
+	"testing"
+)
+
+func TestSafeConn(t *testing.T) {
+	driver := NewDriver()
+
+	// Runs the same session as TestBoltConn_SelectOne through a SafeConn
+	driver.(*boltDriver).recorder = newRecorder("TestBoltConn_SelectOne", neo4jConnStr)
+
+	neoConn, err := driver.OpenNeo(neo4jConnStr)
+	if err != nil {
+		t.Fatalf("An error occurred opening conn: %s", err)
+	}
+	conn := NewSafeConn(neoConn)
+
+	rows, err := conn.QueryNeo("RETURN 1;", nil)
+	if err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+
+	if _, err := conn.ExecNeo("RETURN 2;", nil); err == nil {
+		t.Fatal("Expected error running a query while rows are open")
+	}
+
+	output, _, err := rows.NextNeo()
+	if err != nil {
+		t.Fatalf("An error occurred getting next row: %s", err)
+	}
+	if output[0].(int64) != 1 {
+		t.Fatalf("Unexpected output. Expected 1. Got: %d", output)
+	}
+
+	if _, _, err := rows.NextNeo(); err != io.EOF {
+		t.Fatalf("Unexpected row closed output. Expected io.EOF. Got: %s", err)
+	}
+
+	if err := rows.Close(); err != nil {
+		t.Fatalf("Error closing rows: %s", err)
+	}
+	if conn.busy {
+		t.Fatal("Expected connection to be released when rows are closed")
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatalf("Error closing connection: %s", err)
+	}
+}
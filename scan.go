@@ -0,0 +1,246 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// StructScan reads the next row into the struct dest points to.  Returns
+// io.EOF once the rows are completed.  See ScanStruct.
+func (r *boltRows) StructScan(dest interface{}) error {
+	row, _, err := r.NextNeo()
+	if err != nil {
+		return err
+	}
+	return ScanStruct(r.Columns(), row, dest)
+}
+
+// ScanAll reads the rest of the rows into the slice dest points to, which
+// can be a slice of structs or of pointers to structs.  See ScanStruct.
+func ScanAll(rows Rows, dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return errors.New("Expected a pointer to a slice to scan rows into, but got %T", dest)
+	}
+	slice = slice.Elem()
+
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	for {
+		row, _, err := rows.NextNeo()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		elem := reflect.New(elemType)
+		if err := ScanStruct(rows.Columns(), row, elem.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+}
+
+// ScanStruct maps a row onto the struct dest points to.  A column is read
+// into the field it names, and the properties of a column that doesn't name
+// a field, but holds a node, relationship or map, are read into the fields
+// they name.  Like parameters, a field is named with a `bolt:"name"` tag,
+// or by its field name, in any case.  Fields tagged `bolt:"-"` are left out.
+//
+// Values are converted to the field's type where Go would convert them, e.g.
+// an integer to an int32, and nodes and maps are read into nested structs.
+// Fields implementing sql.Scanner, such as sql.NullString, are scanned.
+// Nulls leave the field's zero value.
+func ScanStruct(columns []string, row []interface{}, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("Expected a pointer to a struct to scan a row into, but got %T", dest)
+	}
+	v = v.Elem()
+
+	var spread []map[string]interface{}
+	for i, column := range columns {
+		if i >= len(row) {
+			break
+		}
+		if field, ok := fieldByName(v, column); ok {
+			if err := assignValue(field, row[i]); err != nil {
+				return errors.Wrap(err, "An error occurred scanning column %s", column)
+			}
+		} else if properties, ok := propertiesOf(row[i]); ok {
+			spread = append(spread, properties)
+		}
+	}
+
+	for _, properties := range spread {
+		if err := assignProperties(v, properties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByName finds the exported field named by its tag, or its name in any case
+func fieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	match := -1
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("bolt"), ",")[0]
+		switch {
+		case tag == "-":
+			continue
+		case tag != "":
+			if tag == name {
+				return v.Field(i), true
+			}
+		case field.Name == name:
+			return v.Field(i), true
+		case match < 0 && strings.EqualFold(field.Name, name):
+			match = i
+		}
+	}
+	if match < 0 {
+		return reflect.Value{}, false
+	}
+	return v.Field(match), true
+}
+
+// propertiesOf gets the properties of a node, relationship or map
+func propertiesOf(value interface{}) (map[string]interface{}, bool) {
+	switch val := value.(type) {
+	case graph.Node:
+		return val.Properties, true
+	case graph.Relationship:
+		return val.Properties, true
+	case graph.UnboundRelationship:
+		return val.Properties, true
+	case map[string]interface{}:
+		return val, true
+	}
+	return nil, false
+}
+
+// assignProperties reads the properties into the fields of the struct they name
+func assignProperties(v reflect.Value, properties map[string]interface{}) error {
+	for name, value := range properties {
+		field, ok := fieldByName(v, name)
+		if !ok {
+			continue
+		}
+		if err := assignValue(field, value); err != nil {
+			return errors.Wrap(err, "An error occurred scanning property %s", name)
+		}
+	}
+	return nil
+}
+
+// assignValue sets the field to the value, converting it to the field's type
+func assignValue(field reflect.Value, value interface{}) error {
+	if reflect.PtrTo(field.Type()).Implements(scannerType) {
+		return field.Addr().Interface().(sql.Scanner).Scan(value)
+	}
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	fieldType := field.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		elem := reflect.New(fieldType.Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(fieldType) {
+		field.Set(v)
+		return nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := value.(int64); ok && !field.OverflowInt(i) {
+			field.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, ok := value.(int64); ok && i >= 0 && !field.OverflowUint(uint64(i)) {
+			field.SetUint(uint64(i))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch val := value.(type) {
+		case float64:
+			field.SetFloat(val)
+			return nil
+		case int64:
+			field.SetFloat(float64(val))
+			return nil
+		}
+	case reflect.Slice:
+		if items, ok := value.([]interface{}); ok {
+			slice := reflect.MakeSlice(fieldType, len(items), len(items))
+			for i, item := range items {
+				if err := assignValue(slice.Index(i), item); err != nil {
+					return errors.Wrap(err, "An error occurred scanning list item %d", i)
+				}
+			}
+			field.Set(slice)
+			return nil
+		}
+	case reflect.Map:
+		if properties, ok := value.(map[string]interface{}); ok && fieldType.Key().Kind() == reflect.String {
+			mapp := reflect.MakeMapWithSize(fieldType, len(properties))
+			for key, item := range properties {
+				elem := reflect.New(fieldType.Elem()).Elem()
+				if err := assignValue(elem, item); err != nil {
+					return errors.Wrap(err, "An error occurred scanning map key %s", key)
+				}
+				mapp.SetMapIndex(reflect.ValueOf(key).Convert(fieldType.Key()), elem)
+			}
+			field.Set(mapp)
+			return nil
+		}
+	case reflect.Struct:
+		// Dates and times embed the time.Time they're read as
+		if fieldType == timeType && v.Kind() == reflect.Struct {
+			if embedded := v.FieldByName("Time"); embedded.IsValid() && embedded.Type() == timeType {
+				field.Set(embedded)
+				return nil
+			}
+		}
+		if properties, ok := propertiesOf(value); ok && fieldType != timeType {
+			return assignProperties(field, properties)
+		}
+	}
+
+	return errors.New("Can't scan %T %+v into a field of type %s", value, value, fieldType)
+}
@@ -0,0 +1,108 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+type scanAddress struct {
+	City string `bolt:"city"`
+}
+
+type scanPerson struct {
+	Name     string `bolt:"name"`
+	Age      int32
+	Score    *float64
+	Nickname sql.NullString `bolt:"nickname"`
+	Tags     []string
+	Born     time.Time
+	Address  scanAddress
+	Friend   graph.Node
+	Secret   string `bolt:"-"`
+}
+
+func TestScanStruct(t *testing.T) {
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"Person"}, Properties: map[string]interface{}{
+		"name": "Ann", "age": int64(42), "secret": "hidden", "unknown": true,
+	}}
+	friend := graph.Node{NodeIdentity: 2, Properties: map[string]interface{}{"name": "Bob"}}
+	columns := []string{"n", "score", "nickname", "tags", "born", "address", "friend"}
+	row := []interface{}{
+		node, int64(7), nil, []interface{}{"a", "b"},
+		temporal.NewDate(1980, time.May, 6), map[string]interface{}{"city": "Malmö"}, friend,
+	}
+
+	var person scanPerson
+	if err := ScanStruct(columns, row, &person); err != nil {
+		t.Fatalf("An error occurred scanning the row: %s", err)
+	}
+
+	score := 7.0
+	expected := scanPerson{
+		Name:    "Ann",
+		Age:     42,
+		Score:   &score,
+		Tags:    []string{"a", "b"},
+		Born:    time.Date(1980, time.May, 6, 0, 0, 0, 0, time.UTC),
+		Address: scanAddress{City: "Malmö"},
+		Friend:  friend,
+	}
+	if !reflect.DeepEqual(person, expected) {
+		t.Fatalf("Unexpected scanned struct.\nExpected: %#v\nGot: %#v", expected, person)
+	}
+
+	if err := ScanStruct([]string{"age"}, []interface{}{"old"}, &person); err == nil {
+		t.Fatal("Expected an error scanning a string into an integer field")
+	}
+	if err := ScanStruct([]string{"age"}, []interface{}{int64(1) << 40}, &person); err == nil {
+		t.Fatal("Expected an error scanning an integer that overflows the field")
+	}
+	if err := ScanStruct(columns, row, person); err == nil {
+		t.Fatal("Expected an error scanning into a struct that isn't a pointer")
+	}
+}
+
+// scanRows returns the given rows from NextNeo
+type scanRows struct {
+	Rows
+	rows [][]interface{}
+}
+
+func (r *scanRows) Columns() []string {
+	return []string{"name", "age"}
+}
+
+func (r *scanRows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	if len(r.rows) == 0 {
+		return nil, nil, io.EOF
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil, nil
+}
+
+func TestScanAll(t *testing.T) {
+	var people []*scanPerson
+	rows := &scanRows{rows: [][]interface{}{{"Ann", int64(42)}, {"Bob", nil}}}
+	if err := ScanAll(rows, &people); err != nil {
+		t.Fatalf("An error occurred scanning the rows: %s", err)
+	}
+	if len(people) != 2 || people[0].Name != "Ann" || people[0].Age != 42 || people[1].Name != "Bob" || people[1].Age != 0 {
+		t.Fatalf("Unexpected scanned rows: %#v", people)
+	}
+
+	var values []scanPerson
+	rows = &scanRows{rows: [][]interface{}{{"Ann", int64(42)}}}
+	if err := ScanAll(rows, &values); err != nil || len(values) != 1 || values[0].Name != "Ann" {
+		t.Fatalf("Expected rows to be scanned into a slice of structs. Got: %#v, %v", values, err)
+	}
+	if err := ScanAll(rows, values); err == nil {
+		t.Fatal("Expected an error scanning into a slice that isn't a pointer")
+	}
+}
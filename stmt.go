@@ -4,7 +4,6 @@ import (
 	"database/sql/driver"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
-	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
 )
 
@@ -20,6 +19,9 @@ type Stmt interface {
 	ExecNeo(params map[string]interface{}) (Result, error)
 	// QueryNeo executes a query that returns data. Implements a Neo-friendly alternative to sql/driver.
 	QueryNeo(params map[string]interface{}) (Rows, error)
+	// Columns gets the names of the fields the query returns, from the metadata
+	// of the last time it was run.  Returns nil if the statement hasn't been run yet.
+	Columns() []string
 }
 
 // PipelineStmt represents a set of statements to run against the database
@@ -43,12 +45,25 @@ type boltStmt struct {
 	conn    *boltConn
 	closed  bool
 	rows    *boltRows
+	fields  []string
 }
 
 func newStmt(query string, conn *boltConn) *boltStmt {
 	return &boltStmt{query: query, conn: conn}
 }
 
+// learn records what the run metadata says about the query
+func (s *boltStmt) learn(metadata map[string]interface{}) {
+	if fields, ok := metadataFields(metadata); ok {
+		s.fields = fields
+	}
+}
+
+// Columns gets the names of the fields the query returns
+func (s *boltStmt) Columns() []string {
+	return s.fields
+}
+
 func newPipelineStmt(queries []string, conn *boltConn) *boltStmt {
 	return &boltStmt{queries: queries, conn: conn}
 }
@@ -96,25 +111,28 @@ func (s *boltStmt) ExecNeo(params map[string]interface{}) (Result, error) {
 		return nil, errors.New("Another query is already open")
 	}
 
-	runResp, pullResp, _, err := s.conn.sendRunPullAllConsumeAll(s.query, params)
+	params = s.conn.withDefaults(params)
+	// Records aren't needed, so they're discarded by the server instead of streamed
+	runResp, discardResp, err := s.conn.sendRunDiscardAllConsumeAll(s.query, params)
 	if err != nil {
-		return nil, err
+		return nil, s.conn.queryError(err, s.query, params)
 	}
 
 	success, ok := runResp.(messages.SuccessMessage)
 	if !ok {
-		return nil, errors.New("Unrecognized response type when running exec query: %#v", success)
+		return nil, s.conn.queryError(errors.New("Unrecognized response type when running exec query: %#v", success), s.query, params)
 
 	}
 
-	log.Infof("Got run success message: %#v", success)
+	s.conn.logger.Infof("Got run success message: %#v", success)
+	s.learn(success.Metadata)
 
-	success, ok = pullResp.(messages.SuccessMessage)
+	success, ok = discardResp.(messages.SuccessMessage)
 	if !ok {
-		return nil, errors.New("Unrecognized response when discarding exec rows: %#v", success)
+		return nil, s.conn.queryError(errors.New("Unrecognized response when discarding exec rows: %#v", discardResp), s.query, params)
 	}
 
-	log.Infof("Got discard all success message: %#v", success)
+	s.conn.logger.Infof("Got discard all success message: %#v", success)
 
 	return newResult(success.Metadata), nil
 }
@@ -131,42 +149,65 @@ func (s *boltStmt) ExecPipeline(params ...map[string]interface{}) ([]Result, err
 		return nil, errors.New("Must pass same number of params as there are queries")
 	}
 
+	// Check the whole pipeline up front, so it's never half sent
 	for i, query := range s.queries {
-		err := s.conn.sendRunPullAll(query, params[i])
-		if err != nil {
-			return nil, errors.Wrap(err, "Error running exec query:\n\n%s\n\nWith Params:\n%#v", query, params[i])
+		if err := s.conn.checkQuery(s.conn.prepareQuery(query), params[i]); err != nil {
+			return nil, err
 		}
 	}
 
-	log.Info("Successfully ran all pipeline queries")
-
+	// Results are read once the pipeline window is full, so the server is
+	// never left blocked writing results while we're blocked writing queries
+	window := s.conn.pipeWindow
 	results := make([]Result, len(s.queries))
-	for i := range s.queries {
-		runResp, err := s.conn.consume()
-		if err != nil {
-			return nil, errors.Wrap(err, "An error occurred getting result of exec command: %#v", runResp)
+	sent, received := 0, 0
+	for received < len(s.queries) {
+		if sent < len(s.queries) && (window <= 0 || sent-received < window) {
+			err := s.conn.sendRunDiscardAll(s.queries[sent], s.conn.withDefaults(params[sent]))
+			if err != nil {
+				return nil, errors.Wrap(err, "Error running exec query:\n\n%s\n\nWith Params:\n%#v", s.queries[sent], params[sent])
+			}
+			sent++
+			if sent == len(s.queries) {
+				s.conn.logger.Info("Successfully ran all pipeline queries")
+			}
+			continue
 		}
 
-		success, ok := runResp.(messages.SuccessMessage)
-		if !ok {
-			return nil, errors.New("Unexpected response when getting exec query result: %#v", runResp)
-		}
-
-		_, pullResp, err := s.conn.consumeAll()
+		result, err := s.consumeExecResult()
 		if err != nil {
-			return nil, errors.Wrap(err, "An error occurred getting result of exec discard command: %#v", pullResp)
+			return nil, err
 		}
+		results[received] = result
+		received++
+	}
 
-		success, ok = pullResp.(messages.SuccessMessage)
-		if !ok {
-			return nil, errors.New("Unexpected response when getting exec query discard result: %#v", pullResp)
-		}
+	return results, nil
+}
 
-		results[i] = newResult(success.Metadata)
+// consumeExecResult consumes the responses to the RUN and DISCARD_ALL of an exec query
+func (s *boltStmt) consumeExecResult() (Result, error) {
+	runResp, err := s.conn.consume()
+	if err != nil {
+		return nil, errors.Wrap(err, "An error occurred getting result of exec command: %#v", runResp)
+	}
 
+	success, ok := runResp.(messages.SuccessMessage)
+	if !ok {
+		return nil, errors.New("Unexpected response when getting exec query result: %#v", runResp)
 	}
 
-	return results, nil
+	discardResp, err := s.conn.consumeDiscard()
+	if err != nil {
+		return nil, errors.Wrap(err, "An error occurred getting result of exec discard command: %#v", discardResp)
+	}
+
+	success, ok = discardResp.(messages.SuccessMessage)
+	if !ok {
+		return nil, errors.New("Unexpected response when getting exec query discard result: %#v", discardResp)
+	}
+
+	return newResult(success.Metadata), nil
 }
 
 // Query executes a query that returns data. See sql/driver.Stmt.
@@ -192,19 +233,25 @@ func (s *boltStmt) queryNeo(params map[string]interface{}) (*boltRows, error) {
 		return nil, errors.New("Another query is already open")
 	}
 
+	params = s.conn.withDefaults(params)
 	respInt, err := s.conn.sendRunConsume(s.query, params)
 	if err != nil {
-		return nil, err
+		return nil, s.conn.queryError(err, s.query, params)
 	}
 
 	resp, ok := respInt.(messages.SuccessMessage)
 	if !ok {
-		return nil, errors.New("Unrecognized response type running query: %#v", resp)
+		return nil, s.conn.queryError(errors.New("Unrecognized response type running query: %#v", resp), s.query, params)
 	}
 
-	log.Infof("Got success message on run query: %#v", resp)
-	s.rows = newRows(s, resp.Metadata)
-	return s.rows, nil
+	s.conn.logger.Infof("Got success message on run query: %#v", resp)
+	s.learn(resp.Metadata)
+	rows := newRows(s, resp.Metadata)
+	s.rows = rows
+	if err := s.conn.checkFields(s.query, rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
 }
 
 func (s *boltStmt) QueryPipeline(params ...map[string]interface{}) (PipelineRows, error) {
@@ -219,14 +266,26 @@ func (s *boltStmt) QueryPipeline(params ...map[string]interface{}) (PipelineRows
 		return nil, errors.New("Must pass same number of params as there are queries")
 	}
 
+	// Records aren't read until the caller reads the rows, so the whole
+	// pipeline has to fit in the window
+	if s.conn.pipeWindow > 0 && len(s.queries) > s.conn.pipeWindow {
+		return nil, errors.New("Can't query a pipeline of %d queries, more than the pipeline window of %d. The server could block writing results while the pipeline is sent. Use smaller pipelines, or ExecPipeline", len(s.queries), s.conn.pipeWindow)
+	}
+
+	for i, query := range s.queries {
+		if err := s.conn.checkQuery(s.conn.prepareQuery(query), params[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	for i, query := range s.queries {
-		err := s.conn.sendRunPullAll(query, params[i])
+		err := s.conn.sendRunPullAll(query, s.conn.withDefaults(params[i]))
 		if err != nil {
 			return nil, errors.Wrap(err, "Error running query:\n\n%s\n\nWith Params:\n%#v", query, params[i])
 		}
 	}
 
-	log.Info("Successfully ran all pipeline queries")
+	s.conn.logger.Info("Successfully ran all pipeline queries")
 
 	resp, err := s.conn.consume()
 	if err != nil {
@@ -0,0 +1,112 @@
+package golangNeo4jBoltDriver
+
+import "container/list"
+
+// StatementCacheStats reports how well the statement cache of a connection is working
+type StatementCacheStats struct {
+	Hits   int64
+	Misses int64
+	Size   int
+}
+
+// HitRate gets the fraction of statements prepared from the cache
+func (s StatementCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// preparedQuery holds the work done on a query's text before it's sent, so
+// it isn't done again each time the query is run.  Each part is worked out
+// the first time it's needed.
+type preparedQuery struct {
+	query string
+
+	checked bool
+	write   bool
+
+	fingerprint string
+
+	// tagged is the query tagged with the application name it was tagged
+	// with, worked out again if the application name changes
+	tagged   string
+	taggedAs string
+}
+
+// isWrite checks if the query writes.  See IsWriteQuery.
+func (p *preparedQuery) isWrite() bool {
+	if !p.checked {
+		p.write = IsWriteQuery(p.query)
+		p.checked = true
+	}
+	return p.write
+}
+
+// queryFingerprint gets the fingerprint of the query.  See queryFingerprint.
+func (p *preparedQuery) queryFingerprint() string {
+	if p.fingerprint == "" {
+		p.fingerprint = queryFingerprint(p.query)
+	}
+	return p.fingerprint
+}
+
+// taggedQuery gets the query as it's sent before Bolt v3.  See tagQuery.
+func (p *preparedQuery) taggedQuery(c *boltConn) string {
+	if p.tagged == "" || p.taggedAs != c.appName {
+		p.tagged = c.tagQuery(p.query)
+		p.taggedAs = c.appName
+	}
+	return p.tagged
+}
+
+// stmtCache is an LRU cache of prepared queries keyed by the query text
+type stmtCache struct {
+	maxSize int
+	entries map[string]*list.Element
+	order   *list.List
+	hits    int64
+	misses  int64
+}
+
+func newStmtCache(maxSize int) *stmtCache {
+	return &stmtCache{
+		maxSize: maxSize,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// get gets the prepared query for the query text, adding it
+// to the cache if it's not there
+func (s *stmtCache) get(query string) *preparedQuery {
+	if elem, ok := s.entries[query]; ok {
+		s.hits++
+		s.order.MoveToFront(elem)
+		return elem.Value.(*preparedQuery)
+	}
+
+	s.misses++
+	prepared := &preparedQuery{query: query}
+	s.entries[query] = s.order.PushFront(prepared)
+	for s.order.Len() > s.maxSize {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*preparedQuery).query)
+	}
+	return prepared
+}
+
+func (s *stmtCache) stats() StatementCacheStats {
+	return StatementCacheStats{Hits: s.hits, Misses: s.misses, Size: s.order.Len()}
+}
+
+// prepareQuery gets the prepared query for the query text, from the
+// statement cache if the connection has one
+func (c *boltConn) prepareQuery(query string) *preparedQuery {
+	if c.stmtCache == nil {
+		return &preparedQuery{query: query}
+	}
+	return c.stmtCache.get(query)
+}
@@ -0,0 +1,70 @@
+package golangNeo4jBoltDriver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoltConn_StatementCache(t *testing.T) {
+	c := createBoltConn("")
+	c.SetStatementCacheSize(2)
+
+	prepared := c.prepareQuery("CREATE (n)")
+	if !prepared.isWrite() || prepared.queryFingerprint() != queryFingerprint("CREATE (n)") {
+		t.Fatalf("Unexpected prepared query: %#v", prepared)
+	}
+	c.prepareQuery("RETURN 2")
+	if c.prepareQuery("CREATE (n)") != prepared || !prepared.checked {
+		t.Fatal("Expected the checks of the query to be remembered")
+	}
+
+	// Evicts RETURN 2, the least recently used
+	c.prepareQuery("RETURN 3")
+	c.prepareQuery("RETURN 2")
+
+	stats := c.StatementCacheStats()
+	expected := StatementCacheStats{Hits: 1, Misses: 4, Size: 2}
+	if stats != expected {
+		t.Fatalf("Unexpected cache stats. Expected %#v. Got: %#v", expected, stats)
+	}
+	if stats.HitRate() != 0.2 {
+		t.Fatalf("Unexpected hit rate: %f", stats.HitRate())
+	}
+}
+
+func TestBoltConn_StatementCacheTagging(t *testing.T) {
+	c := createBoltConn("")
+	c.SetStatementCacheSize(1)
+
+	prepared := c.prepareQuery("RETURN 1")
+	if tagged := prepared.taggedQuery(c); tagged != "RETURN 1" {
+		t.Fatalf("Expected the query not to be tagged without an application name. Got: %s", tagged)
+	}
+
+	// A cached query is tagged again when the application name changes
+	c.appName = "billing"
+	if tagged := c.prepareQuery("RETURN 1").taggedQuery(c); tagged != "/* billing */ RETURN 1" {
+		t.Fatalf("Unexpected tagged query: %s", tagged)
+	}
+	if c.StatementCacheStats().Hits != 1 {
+		t.Fatalf("Expected the cache to be keyed on the untagged query. Got: %#v", c.StatementCacheStats())
+	}
+}
+
+func TestBoltStmt_Columns(t *testing.T) {
+	stmt := newStmt("RETURN 1 AS a, 2 AS b", createBoltConn(""))
+	if stmt.Columns() != nil {
+		t.Fatalf("Expected no columns before running. Got: %#v", stmt.Columns())
+	}
+
+	stmt.learn(map[string]interface{}{"fields": []interface{}{"a", "b"}})
+	if !reflect.DeepEqual(stmt.Columns(), []string{"a", "b"}) {
+		t.Fatalf("Unexpected columns: %#v", stmt.Columns())
+	}
+
+	// Metadata without fields leaves the columns from the last run
+	stmt.learn(map[string]interface{}{})
+	if !reflect.DeepEqual(stmt.Columns(), []string{"a", "b"}) {
+		t.Fatalf("Unexpected columns: %#v", stmt.Columns())
+	}
+}
@@ -3,6 +3,7 @@ package golangNeo4jBoltDriver
 import (
 	"io"
 	"math"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
@@ -12,7 +13,9 @@ import (
 	"database/sql"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
 )
 
 func TestBoltStmt_SelectOne(t *testing.T) {
@@ -469,6 +472,75 @@ func TestBoltStmt_Discard(t *testing.T) {
 	}
 }
 
+func TestBoltStmt_AsyncClose(t *testing.T) {
+	driver := NewDriver()
+
+	// Runs the same session as TestBoltStmt_Discard, discarding in the background
+	driver.(*boltDriver).recorder = newRecorder("TestBoltStmt_Discard", neo4jConnStr)
+
+	conn, err := driver.OpenNeo(neo4jConnStr)
+	if err != nil {
+		t.Fatalf("An error occurred opening conn: %s", err)
+	}
+	opts := conn.Options()
+	opts.AsyncClose = true
+	conn.SetOptions(opts)
+
+	stmt, err := conn.PrepareNeo(`CREATE (f:FOO {a: "1"}), (b:FOO {a: "2"}) RETURN f, b`)
+	if err != nil {
+		t.Fatalf("An error occurred preparing statement: %s", err)
+	}
+
+	if _, err = stmt.QueryNeo(nil); err != nil {
+		t.Fatalf("An error occurred querying Neo: %s", err)
+	}
+
+	// Closing discards the stream in the background
+	if err := stmt.Close(); err != nil {
+		t.Fatalf("An error occurred closing statement: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		stmt, err = conn.PrepareNeo(`MATCH (f:FOO) RETURN f.a ORDER BY f.a`)
+		if err != nil {
+			t.Fatalf("An error occurred preparing statement: %s", err)
+		}
+
+		rows, err := stmt.QueryNeo(nil)
+		if err != nil {
+			t.Fatalf("An error occurred querying Neo: %s", err)
+		}
+
+		for j := 0; j <= i; j++ {
+			output, _, err := rows.NextNeo()
+			if err != nil {
+				t.Fatalf("An error occurred getting next row: %s", err)
+			}
+			if output[0].(string) != []string{"1", "2"}[j] {
+				t.Fatalf("Unexpected return data: %#v", output[0])
+			}
+		}
+
+		// Closing in the middle of the record stream
+		if err := stmt.Close(); err != nil {
+			t.Fatalf("An error occurred closing statement: %s", err)
+		}
+	}
+
+	stmt, err = conn.PrepareNeo(`MATCH (f:FOO) DELETE f`)
+	if err != nil {
+		t.Fatalf("An error occurred preparing delete statement: %s", err)
+	}
+
+	if _, err = stmt.ExecNeo(nil); err != nil {
+		t.Fatalf("An error occurred on delete query to Neo: %s", err)
+	}
+
+	if err = conn.Close(); err != nil {
+		t.Fatalf("Error closing connection: %s", err)
+	}
+}
+
 func TestBoltStmt_Failure(t *testing.T) {
 	driver := NewDriver()
 
@@ -1765,3 +1837,100 @@ func TestBoltStmt_SqlQueryAndExec(t *testing.T) {
 		t.Fatalf("Error closing connection: %s", err)
 	}
 }
+
+func TestBoltStmt_ExecDiscardsRecords(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	sent := make(chan interface{}, 2)
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		for i := 0; i < 2; i++ {
+			msg, _ := decoder.Decode()
+			sent <- msg
+		}
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}))
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"stats": map[string]interface{}{"nodes-created": int64(1)}}))
+	}()
+
+	result, err := c.ExecNeo("CREATE (n:FOO) RETURN n", nil)
+	if err != nil {
+		t.Fatalf("Error executing query: %s", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 1 {
+		t.Fatalf("Unexpected rows affected: %d", affected)
+	}
+
+	if msg, ok := (<-sent).(structures.Raw); !ok || msg.Signature != messages.RunMessageSignature {
+		t.Fatalf("Expected RUN to be sent first. Got: %#v", msg)
+	}
+	if msg, ok := (<-sent).(messages.DiscardAllMessage); !ok {
+		t.Fatalf("Expected DISCARD_ALL to be sent after RUN. Got: %#v", msg)
+	}
+}
+
+func TestBoltStmt_ExecPipelineWindow(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.SetPipelineWindow(1)
+
+	// net.Pipe is unbuffered, so the server is blocked writing the results
+	// of each query until they're read
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		for i := int64(1); i <= 3; i++ {
+			decoder.Decode()
+			decoder.Decode()
+			encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{}))
+			encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"stats": map[string]interface{}{"nodes-created": i}}))
+		}
+	}()
+
+	results, err := c.ExecPipeline([]string{"CREATE (a)", "CREATE (b)", "CREATE (c)"}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Error executing pipeline: %s", err)
+	}
+	for i, result := range results {
+		if affected, _ := result.RowsAffected(); affected != int64(i+1) {
+			t.Fatalf("Unexpected rows affected for query %d: %d", i, affected)
+		}
+	}
+
+	if _, err := c.QueryPipeline([]string{"RETURN 1", "RETURN 2"}, nil, nil); err == nil || !strings.Contains(err.Error(), "pipeline window") {
+		t.Fatalf("Expected an error querying a pipeline larger than the window. Got: %v", err)
+	}
+	if c.state() != stateReady {
+		t.Fatalf("Expected the connection to be ready after the rejected pipeline. Got: %s", c.state())
+	}
+}
+
+func TestBoltStmt_ExecRecordAfterDiscard(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		for i := 0; i < 2; i++ {
+			decoder.Decode()
+		}
+		// The server never streams records after DISCARD_ALL
+		encoder := encoding.NewEncoder(server, math.MaxUint16)
+		encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}}))
+		encoder.Encode(messages.NewRecordMessage([]interface{}{int64(1)}))
+	}()
+
+	if _, err := c.ExecNeo("CREATE (n:FOO) RETURN n", nil); err == nil {
+		t.Fatal("Expected an error for a record in response to DISCARD_ALL")
+	}
+	if c.connErr == nil {
+		t.Fatal("Expected the connection to be defunct after a record in response to DISCARD_ALL")
+	}
+}
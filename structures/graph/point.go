@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// Point2DSignature is the signature byte for a Point2D object
+	Point2DSignature = 0x58
+	// Point3DSignature is the signature byte for a Point3D object
+	Point3DSignature = 0x59
+)
+
+const (
+	// SRIDCartesian is the SRID of 2D cartesian points
+	SRIDCartesian = 7203
+	// SRIDCartesian3D is the SRID of 3D cartesian points
+	SRIDCartesian3D = 9157
+	// SRIDWGS84 is the SRID of 2D geographic points, with X the longitude
+	// and Y the latitude
+	SRIDWGS84 = 4326
+	// SRIDWGS843D is the SRID of 3D geographic points, with Z the height
+	SRIDWGS843D = 4979
+)
+
+// Point2D Represents a Point2D structure, a point in a 2D coordinate
+// reference system identified by its SRID
+type Point2D struct {
+	SRID int64
+	X    float64
+	Y    float64
+}
+
+// Signature gets the signature byte for the struct
+func (p Point2D) Signature() int {
+	return Point2DSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (p Point2D) AllFields() []interface{} {
+	return []interface{}{p.SRID, p.X, p.Y}
+}
+
+// String formats the point as extended WKT, e.g. SRID=7203;POINT(1 2)
+func (p Point2D) String() string {
+	return fmt.Sprintf("SRID=%d;POINT(%s %s)", p.SRID, formatCoordinate(p.X), formatCoordinate(p.Y))
+}
+
+// Point3D Represents a Point3D structure, a point in a 3D coordinate
+// reference system identified by its SRID
+type Point3D struct {
+	SRID int64
+	X    float64
+	Y    float64
+	Z    float64
+}
+
+// Signature gets the signature byte for the struct
+func (p Point3D) Signature() int {
+	return Point3DSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (p Point3D) AllFields() []interface{} {
+	return []interface{}{p.SRID, p.X, p.Y, p.Z}
+}
+
+// String formats the point as extended WKT, e.g. SRID=9157;POINT Z(1 2 3)
+func (p Point3D) String() string {
+	return fmt.Sprintf("SRID=%d;POINT Z(%s %s %s)", p.SRID, formatCoordinate(p.X), formatCoordinate(p.Y), formatCoordinate(p.Z))
+}
+
+func formatCoordinate(c float64) string {
+	return strconv.FormatFloat(c, 'g', -1, 64)
+}
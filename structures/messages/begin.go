@@ -0,0 +1,33 @@
+package messages
+
+const (
+	// BeginMessageSignature is the signature byte for the BEGIN message
+	BeginMessageSignature = 0x11
+)
+
+// BeginMessage Represents a BEGIN message, which begins an explicit
+// transaction from Bolt v3 on, instead of running a BEGIN statement
+type BeginMessage struct {
+	metadata map[string]interface{}
+}
+
+// NewBeginMessage Gets a new BeginMessage struct.  The metadata holds
+// e.g. the bookmarks to wait on.
+func NewBeginMessage(metadata map[string]interface{}) BeginMessage {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return BeginMessage{
+		metadata: metadata,
+	}
+}
+
+// Signature gets the signature byte for the struct
+func (i BeginMessage) Signature() int {
+	return BeginMessageSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (i BeginMessage) AllFields() []interface{} {
+	return []interface{}{i.metadata}
+}
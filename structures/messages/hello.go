@@ -0,0 +1,31 @@
+package messages
+
+const (
+	// HelloMessageSignature is the signature byte for the HELLO message
+	HelloMessageSignature = 0x01
+)
+
+// HelloMessage Represents a HELLO message, which replaces INIT from
+// Bolt v3 on.  The client name is sent in the same map as the auth token.
+type HelloMessage struct {
+	extra map[string]interface{}
+}
+
+// NewHelloMessage Gets a new HelloMessage struct
+func NewHelloMessage(clientName string, user string, password string) HelloMessage {
+	extra := NewInitMessage(clientName, user, password).authToken
+	extra["user_agent"] = clientName
+	return HelloMessage{
+		extra: extra,
+	}
+}
+
+// Signature gets the signature byte for the struct
+func (i HelloMessage) Signature() int {
+	return HelloMessageSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (i HelloMessage) AllFields() []interface{} {
+	return []interface{}{i.extra}
+}
@@ -9,6 +9,7 @@ const (
 type RunMessage struct {
 	statement  string
 	parameters map[string]interface{}
+	metadata   map[string]interface{}
 }
 
 // NewRunMessage Gets a new RunMessage struct
@@ -19,6 +20,19 @@ func NewRunMessage(statement string, parameters map[string]interface{}) RunMessa
 	}
 }
 
+// NewRunMessageWithMetadata Gets a new RunMessage struct with the metadata
+// field added in Bolt v3, e.g. for the bookmarks of an auto-commit query
+func NewRunMessageWithMetadata(statement string, parameters map[string]interface{}, metadata map[string]interface{}) RunMessage {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return RunMessage{
+		statement:  statement,
+		parameters: parameters,
+		metadata:   metadata,
+	}
+}
+
 // Signature gets the signature byte for the struct
 func (i RunMessage) Signature() int {
 	return RunMessageSignature
@@ -26,5 +40,8 @@ func (i RunMessage) Signature() int {
 
 // AllFields gets the fields to encode for the struct
 func (i RunMessage) AllFields() []interface{} {
+	if i.metadata != nil {
+		return []interface{}{i.statement, i.parameters, i.metadata}
+	}
 	return []interface{}{i.statement, i.parameters}
 }
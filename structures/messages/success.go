@@ -1,5 +1,7 @@
 package messages
 
+import "time"
+
 const (
 	// SuccessMessageSignature is the signature byte for the SUCCESS message
 	SuccessMessageSignature = 0x70
@@ -26,3 +28,64 @@ func (i SuccessMessage) Signature() int {
 func (i SuccessMessage) AllFields() []interface{} {
 	return []interface{}{i.Metadata}
 }
+
+// Fields gets the names of the fields of a query result, from the
+// response to RUN. Returns false if the fields are missing or unrecognized.
+func (i SuccessMessage) Fields() ([]string, bool) {
+	fieldsInt, ok := i.Metadata["fields"].([]interface{})
+	if !ok {
+		return []string{}, false
+	}
+
+	fields := make([]string, len(fieldsInt))
+	for idx, f := range fieldsInt {
+		if fields[idx], ok = f.(string); !ok {
+			return []string{}, false
+		}
+	}
+	return fields, true
+}
+
+// Bookmark gets the bookmark of a committed transaction
+func (i SuccessMessage) Bookmark() (string, bool) {
+	bookmark, ok := i.Metadata["bookmark"].(string)
+	return bookmark, ok
+}
+
+// TFirst gets how long the server took to start streaming a query result,
+// from t_first, or result_available_after before Bolt v3
+func (i SuccessMessage) TFirst() (time.Duration, bool) {
+	tFirst, ok := i.Metadata["t_first"].(int64)
+	if !ok {
+		tFirst, ok = i.Metadata["result_available_after"].(int64)
+	}
+	return time.Duration(tFirst) * time.Millisecond, ok
+}
+
+// Server gets the server agent, e.g. Neo4j/3.1.0, from the response to INIT
+func (i SuccessMessage) Server() (string, bool) {
+	server, ok := i.Metadata["server"].(string)
+	return server, ok
+}
+
+// ConnectionID gets the id the server gave the connection, from the response to HELLO
+func (i SuccessMessage) ConnectionID() (string, bool) {
+	id, ok := i.Metadata["connection_id"].(string)
+	return id, ok
+}
+
+// Stats gets the update counters of a query, e.g. nodes-created. Returns nil if there are none.
+func (i SuccessMessage) Stats() map[string]int64 {
+	statsInt, ok := i.Metadata["stats"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	stats := make(map[string]int64, len(statsInt))
+	for k, v := range statsInt {
+		if count, ok := v.(int64); ok {
+			stats[k] = count
+		}
+	}
+	return stats
+}
@@ -5,3 +5,10 @@ type Structure interface {
 	Signature() int
 	AllFields() []interface{}
 }
+
+// Raw is a structure with a signature the driver doesn't recognize, e.g. a
+// type from a newer server.  Its fields are decoded as generic values.
+type Raw struct {
+	Signature byte
+	Fields    []interface{}
+}
@@ -0,0 +1,47 @@
+package temporal
+
+import "time"
+
+const (
+	// DateSignature is the signature byte for a Date object
+	DateSignature = 0x44
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// Date Represents a Date structure, a day without a time or time zone.
+// The time is midnight UTC.
+type Date struct {
+	time.Time
+}
+
+// NewDate Gets a new Date struct
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateFromDays Gets the Date the given number of days after the Unix epoch
+func DateFromDays(days int64) Date {
+	return Date{time.Unix(days*secondsPerDay, 0).UTC()}
+}
+
+// Days gets the number of days from the Unix epoch to the date
+func (d Date) Days() int64 {
+	year, month, day := d.Date()
+	seconds := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+	days := seconds / secondsPerDay
+	if seconds%secondsPerDay < 0 {
+		days--
+	}
+	return days
+}
+
+// Signature gets the signature byte for the struct
+func (d Date) Signature() int {
+	return DateSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (d Date) AllFields() []interface{} {
+	return []interface{}{d.Days()}
+}
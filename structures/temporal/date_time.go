@@ -0,0 +1,87 @@
+package temporal
+
+import "time"
+
+const (
+	// DateTimeSignature is the signature byte for a DateTime object with a UTC offset
+	DateTimeSignature = 0x46
+	// DateTimeZoneIDSignature is the signature byte for a DateTime object with a time zone name
+	DateTimeZoneIDSignature = 0x66
+	// LocalDateTimeSignature is the signature byte for a LocalDateTime object
+	LocalDateTimeSignature = 0x64
+)
+
+// DateTime Represents a DateTime structure, for sending a time.Time.
+// A time in a named location, e.g. from time.LoadLocation, is sent with
+// the name of its time zone, and any other time with its UTC offset.
+type DateTime struct {
+	time.Time
+}
+
+// DateTimeFromOffset Gets the time.Time of a DateTime with a UTC offset.
+// The seconds are from the Unix epoch to the local clock time.
+func DateTimeFromOffset(seconds, nanos, offset int64) time.Time {
+	return time.Unix(seconds-offset, nanos).In(time.FixedZone("", int(offset)))
+}
+
+// DateTimeFromZoneID Gets the time.Time of a DateTime with a time zone name.
+// The seconds are from the Unix epoch to the local clock time.
+func DateTimeFromZoneID(seconds, nanos int64, zoneID string) (time.Time, error) {
+	location, err := time.LoadLocation(zoneID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := time.Unix(seconds, nanos).UTC()
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), location), nil
+}
+
+// zoneID gets the name of the time zone to send, or false to send the offset
+func (t DateTime) zoneID() (string, bool) {
+	name := t.Location().String()
+	return name, name != "UTC" && name != "Local" && name != ""
+}
+
+// Signature gets the signature byte for the struct
+func (t DateTime) Signature() int {
+	if _, ok := t.zoneID(); ok {
+		return DateTimeZoneIDSignature
+	}
+	return DateTimeSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (t DateTime) AllFields() []interface{} {
+	_, offset := t.Zone()
+	seconds := t.Unix() + int64(offset)
+	if zoneID, ok := t.zoneID(); ok {
+		return []interface{}{seconds, int64(t.Nanosecond()), zoneID}
+	}
+	return []interface{}{seconds, int64(t.Nanosecond()), int64(offset)}
+}
+
+// LocalDateTime Represents a LocalDateTime structure, a date and time
+// without a time zone.  The location is UTC.
+type LocalDateTime struct {
+	time.Time
+}
+
+// NewLocalDateTime Gets a new LocalDateTime struct with the date and
+// clock time of t, ignoring its location
+func NewLocalDateTime(t time.Time) LocalDateTime {
+	return LocalDateTime{time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)}
+}
+
+// LocalDateTimeFromEpoch Gets the LocalDateTime the given time after the Unix epoch
+func LocalDateTimeFromEpoch(seconds, nanos int64) LocalDateTime {
+	return LocalDateTime{time.Unix(seconds, nanos).UTC()}
+}
+
+// Signature gets the signature byte for the struct
+func (t LocalDateTime) Signature() int {
+	return LocalDateTimeSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (t LocalDateTime) AllFields() []interface{} {
+	return []interface{}{t.Unix(), int64(t.Nanosecond())}
+}
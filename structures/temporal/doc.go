@@ -0,0 +1,2 @@
+/*Package temporal contains the date, time and duration structs that can be sent to and returned from Neo4j from Bolt v2 on*/
+package temporal
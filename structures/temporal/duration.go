@@ -0,0 +1,52 @@
+package temporal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DurationSignature is the signature byte for a Duration object
+	DurationSignature = 0x45
+)
+
+// Duration Represents a Duration structure.  Unlike time.Duration, months
+// and days are kept apart from seconds, as their length varies.
+type Duration struct {
+	Months  int64
+	Days    int64
+	Seconds int64
+	Nanos   int64
+}
+
+// Signature gets the signature byte for the struct
+func (d Duration) Signature() int {
+	return DurationSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (d Duration) AllFields() []interface{} {
+	return []interface{}{d.Months, d.Days, d.Seconds, d.Nanos}
+}
+
+// String formats the duration in ISO 8601, e.g. P1M2DT3.5S, like Neo4j does
+func (d Duration) String() string {
+	out := "P"
+	if d.Months != 0 {
+		out += fmt.Sprintf("%dM", d.Months)
+	}
+	if d.Days != 0 {
+		out += fmt.Sprintf("%dD", d.Days)
+	}
+	if d.Seconds != 0 || d.Nanos != 0 || out == "P" {
+		seconds := fmt.Sprintf("%d", d.Seconds)
+		if d.Seconds < 0 && d.Nanos > 0 {
+			// Nanos count up from a negative second, e.g. -1s + 0.5s is -0.5s
+			seconds = strings.TrimRight(fmt.Sprintf("-%d.%09d", -(d.Seconds+1), 1000000000-d.Nanos), "0")
+		} else if d.Nanos != 0 {
+			seconds = strings.TrimRight(fmt.Sprintf("%d.%09d", d.Seconds, d.Nanos), "0")
+		}
+		out += "T" + seconds + "S"
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package temporal
+
+import "time"
+
+const (
+	// TimeSignature is the signature byte for a Time object
+	TimeSignature = 0x54
+	// LocalTimeSignature is the signature byte for a LocalTime object
+	LocalTimeSignature = 0x74
+)
+
+// Time Represents a Time structure, a time of day with a UTC offset.
+// The date is the Unix epoch.
+type Time struct {
+	time.Time
+}
+
+// NewTime Gets a new Time struct, offset by the given number of seconds from UTC
+func NewTime(hour, min, sec, nsec int, offset int) Time {
+	return Time{time.Date(1970, time.January, 1, hour, min, sec, nsec, time.FixedZone("", offset))}
+}
+
+// TimeFromNanos Gets the Time the given number of nanoseconds after
+// midnight, offset by the given number of seconds from UTC
+func TimeFromNanos(nanos int64, offset int64) Time {
+	local := time.Unix(0, nanos).UTC()
+	return NewTime(local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), int(offset))
+}
+
+// Signature gets the signature byte for the struct
+func (t Time) Signature() int {
+	return TimeSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (t Time) AllFields() []interface{} {
+	_, offset := t.Zone()
+	return []interface{}{nanosOfDay(t.Time), int64(offset)}
+}
+
+// LocalTime Represents a LocalTime structure, a time of day without a
+// time zone.  The date is the Unix epoch, in UTC.
+type LocalTime struct {
+	time.Time
+}
+
+// NewLocalTime Gets a new LocalTime struct
+func NewLocalTime(hour, min, sec, nsec int) LocalTime {
+	return LocalTime{time.Date(1970, time.January, 1, hour, min, sec, nsec, time.UTC)}
+}
+
+// LocalTimeFromNanos Gets the LocalTime the given number of nanoseconds after midnight
+func LocalTimeFromNanos(nanos int64) LocalTime {
+	return LocalTime{time.Unix(0, nanos).UTC()}
+}
+
+// Signature gets the signature byte for the struct
+func (t LocalTime) Signature() int {
+	return LocalTimeSignature
+}
+
+// AllFields gets the fields to encode for the struct
+func (t LocalTime) AllFields() []interface{} {
+	return []interface{}{nanosOfDay(t.Time)}
+}
+
+// nanosOfDay gets the nanoseconds from midnight to the clock time of t
+func nanosOfDay(t time.Time) int64 {
+	return int64(t.Hour())*int64(time.Hour) + int64(t.Minute())*int64(time.Minute) +
+		int64(t.Second())*int64(time.Second) + int64(t.Nanosecond())
+}
@@ -0,0 +1,66 @@
+package golangNeo4jBoltDriver
+
+import (
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+)
+
+// Summary is the typed summary of a query, built from the metadata
+// Neo4j returns when the query starts and when its result is consumed
+type Summary struct {
+	// Type is the type of query: "r" (read only), "rw" (read/write),
+	// "w" (write only) or "s" (schema)
+	Type string
+	// Stats are the update counters for the query, e.g. "nodes-created"
+	Stats map[string]int64
+	// ResultAvailableAfter is how long the server took to start streaming the result
+	ResultAvailableAfter time.Duration
+	// ResultConsumedAfter is how long the server took to stream the result
+	ResultConsumedAfter time.Duration
+	// Plan is the query plan, if the query was run with EXPLAIN
+	Plan map[string]interface{}
+	// Profile is the query profile, if the query was run with PROFILE
+	Profile map[string]interface{}
+	// Notifications are the warnings Neo4j reported for the query
+	Notifications []map[string]interface{}
+	// Metadata is all of the metadata the summary was built from
+	Metadata map[string]interface{}
+}
+
+// newSummary builds a summary from all of the metadata returned for a query.
+// Later metadata takes precedence.  Lazy metadata values are decoded.
+func newSummary(metadata ...map[string]interface{}) Summary {
+	summary := Summary{
+		Stats:    map[string]int64{},
+		Metadata: map[string]interface{}{},
+	}
+	for _, m := range metadata {
+		for k, v := range m {
+			summary.Metadata[k] = encoding.Resolve(v)
+		}
+	}
+
+	summary.Type, _ = summary.Metadata["type"].(string)
+	for k, v := range messages.NewSuccessMessage(summary.Metadata).Stats() {
+		summary.Stats[k] = v
+	}
+	if after, ok := summary.Metadata["result_available_after"].(int64); ok {
+		summary.ResultAvailableAfter = time.Duration(after) * time.Millisecond
+	}
+	if after, ok := summary.Metadata["result_consumed_after"].(int64); ok {
+		summary.ResultConsumedAfter = time.Duration(after) * time.Millisecond
+	}
+	summary.Plan, _ = summary.Metadata["plan"].(map[string]interface{})
+	summary.Profile, _ = summary.Metadata["profile"].(map[string]interface{})
+	if notifications, ok := summary.Metadata["notifications"].([]interface{}); ok {
+		for _, n := range notifications {
+			if notification, ok := n.(map[string]interface{}); ok {
+				summary.Notifications = append(summary.Notifications, notification)
+			}
+		}
+	}
+
+	return summary
+}
@@ -1,8 +1,9 @@
 package golangNeo4jBoltDriver
 
 import (
+	"fmt"
+
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
-	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
 )
 
@@ -12,11 +13,55 @@ type Tx interface {
 	Commit() error
 	// Rollback rolls back the transaction
 	Rollback() error
+	// RunBatch runs the statements in order, returning the result of each.
+	// If a statement fails, the rest aren't run and a *BatchError tells which
+	// one failed.  The transaction can then only be rolled back.
+	RunBatch(statements []Statement) ([]Result, error)
+	// ExecPipeline pipelines the queries in the transaction, sending them
+	// all before reading their results, instead of waiting for each one.
+	// A failure makes the transaction rollback only, like RunBatch.
+	ExecPipeline(queries []string, params ...map[string]interface{}) ([]Result, error)
+	// QueryPipeline pipelines the queries in the transaction, returning
+	// the rows of each in turn
+	QueryPipeline(queries []string, params ...map[string]interface{}) (PipelineRows, error)
+	// ExecNeo executes a query that returns no rows in the transaction
+	ExecNeo(query string, params map[string]interface{}) (Result, error)
+	// QueryNeo runs a query that returns rows in the transaction.  The rows
+	// must be closed before the next query, and are closed on Commit.
+	QueryNeo(query string, params map[string]interface{}) (Rows, error)
+}
+
+// Statement is a query and its parameters, run in a batch with Tx.RunBatch,
+// or bound with Bind to be run on any connection
+type Statement struct {
+	Query  string
+	Params map[string]interface{}
+}
+
+// BatchError is returned by Tx.RunBatch when a statement in the batch fails.
+// Index is the position of the failed statement in the batch, and Results
+// holds the results of the statements run before it.
+type BatchError struct {
+	Index     int
+	Statement Statement
+	Results   []Result
+	Err       error
+}
+
+// Error implements the error interface
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("Statement %d of batch failed: %s", e.Index, e.Err)
 }
 
 type boltTx struct {
-	conn   *boltConn
-	closed bool
+	conn    *boltConn
+	closed  bool
+	failure *messages.FailureMessage
+	// reset is set when a RESET ended the transaction on the server, e.g. to
+	// acknowledge a failure from Bolt v3 on, so there's nothing to roll back
+	reset bool
+	// bookmarks are the bookmarks the transaction waited on when it began
+	bookmarks []string
 }
 
 func newTx(conn *boltConn) *boltTx {
@@ -30,13 +75,20 @@ func (t *boltTx) Commit() error {
 	if t.closed {
 		return errors.New("Transaction already closed")
 	}
+	if t.failure != nil {
+		failure := *t.failure
+		if err := t.Rollback(); err != nil {
+			return errors.Wrap(err, "An error occurred rolling back failed transaction on Commit")
+		}
+		return &TxFailedError{Failure: failure, RolledBack: true}
+	}
 	if t.conn.statement != nil {
 		if err := t.conn.statement.Close(); err != nil {
 			return errors.Wrap(err, "An error occurred closing open rows in transaction Commit")
 		}
 	}
 
-	successInt, pullInt, err := t.conn.sendRunPullAllConsumeSingle("COMMIT", nil)
+	successInt, pullInt, err := t.conn.sendTxControl("COMMIT", nil)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred committing transaction")
 	}
@@ -46,20 +98,82 @@ func (t *boltTx) Commit() error {
 		return errors.New("Unrecognized response type committing transaction: %#v", success)
 	}
 
-	log.Infof("Got success message committing transaction: %#v", success)
+	t.conn.logger.Infof("Got success message committing transaction: %#v", success)
 
 	pull, ok := pullInt.(messages.SuccessMessage)
 	if !ok {
 		return errors.New("Unrecognized response type pulling transaction:  %#v", pull)
 	}
 
-	log.Infof("Got success message pulling transaction: %#v", pull)
+	t.conn.logger.Infof("Got success message pulling transaction: %#v", pull)
 
+	t.conn.updateBookmark(pull.Metadata, t.bookmarks)
 	t.conn.transaction = nil
 	t.closed = true
 	return err
 }
 
+// ExecNeo executes a query that returns no rows in the transaction
+func (t *boltTx) ExecNeo(query string, params map[string]interface{}) (Result, error) {
+	if t.closed {
+		return nil, errors.New("Transaction already closed")
+	}
+	return t.conn.ExecNeo(query, params)
+}
+
+// QueryNeo runs a query that returns rows in the transaction
+func (t *boltTx) QueryNeo(query string, params map[string]interface{}) (Rows, error) {
+	if t.closed {
+		return nil, errors.New("Transaction already closed")
+	}
+	return t.conn.QueryNeo(query, params)
+}
+
+// RunBatch runs the statements in the transaction, stopping at the first failure
+func (t *boltTx) RunBatch(statements []Statement) ([]Result, error) {
+	if t.closed {
+		return nil, errors.New("Transaction already closed")
+	}
+
+	results := make([]Result, 0, len(statements))
+	for i, statement := range statements {
+		result, err := t.conn.ExecNeo(statement.Query, statement.Params)
+		if err != nil {
+			batchErr := &BatchError{Index: i, Statement: statement, Results: results, Err: err}
+			if t.failure == nil {
+				// Failures that didn't come from the server, or with legacy
+				// failures, still leave the batch half done
+				t.failure = &messages.FailureMessage{Metadata: map[string]interface{}{"message": batchErr.Error()}}
+			}
+			return results, batchErr
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ExecPipeline pipelines the queries in the transaction
+func (t *boltTx) ExecPipeline(queries []string, params ...map[string]interface{}) ([]Result, error) {
+	if t.closed {
+		return nil, errors.New("Transaction already closed")
+	}
+
+	results, err := t.conn.ExecPipeline(queries, params...)
+	if err != nil && t.failure == nil {
+		// Some of the pipeline may have run, so it can't be committed
+		t.failure = &messages.FailureMessage{Metadata: map[string]interface{}{"message": err.Error()}}
+	}
+	return results, err
+}
+
+// QueryPipeline pipelines the queries in the transaction, returning their rows
+func (t *boltTx) QueryPipeline(queries []string, params ...map[string]interface{}) (PipelineRows, error) {
+	if t.closed {
+		return nil, errors.New("Transaction already closed")
+	}
+	return t.conn.QueryPipeline(queries, params...)
+}
+
 // Rollback rolls back and closes the transaction
 func (t *boltTx) Rollback() error {
 	if t.closed {
@@ -70,9 +184,20 @@ func (t *boltTx) Rollback() error {
 			return errors.Wrap(err, "An error occurred closing open rows in transaction Rollback")
 		}
 	}
+	if t.reset {
+		t.conn.logger.Info("Transaction was ended by a reset, so isn't rolled back")
+		t.conn.transaction = nil
+		t.closed = true
+		return nil
+	}
 
-	successInt, pullInt, err := t.conn.sendRunPullAllConsumeSingle("ROLLBACK", nil)
+	successInt, pullInt, err := t.conn.sendTxControl("ROLLBACK", nil)
 	if err != nil {
+		if t.failure != nil {
+			// The server may have already ended the failed transaction
+			t.conn.transaction = nil
+			t.closed = true
+		}
 		return errors.Wrap(err, "An error occurred rolling back transaction")
 	}
 
@@ -81,14 +206,14 @@ func (t *boltTx) Rollback() error {
 		return errors.New("Unrecognized response type rolling back transaction: %#v", success)
 	}
 
-	log.Infof("Got success message rolling back transaction: %#v", success)
+	t.conn.logger.Infof("Got success message rolling back transaction: %#v", success)
 
 	pull, ok := pullInt.(messages.SuccessMessage)
 	if !ok {
 		return errors.New("Unrecognized response type pulling transaction: %#v", pull)
 	}
 
-	log.Infof("Got success message pulling transaction: %#v", pull)
+	t.conn.logger.Infof("Got success message pulling transaction: %#v", pull)
 
 	t.conn.transaction = nil
 	t.closed = true
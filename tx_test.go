@@ -1,8 +1,14 @@
 package golangNeo4jBoltDriver
 
 import (
+	"bytes"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
 	"io"
+	"io/ioutil"
+	"net"
 	"testing"
 )
 
@@ -165,3 +171,180 @@ func TestBoltTx_Rollback(t *testing.T) {
 		t.Fatalf("Error closing connection: %s", err)
 	}
 }
+
+func TestBoltTx_CommitFailed(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	tx := newTx(c)
+	c.transaction = tx
+	tx.failure = &messages.FailureMessage{Metadata: map[string]interface{}{"code": "Neo.ClientError.Statement.SyntaxError"}}
+
+	if _, err := c.ExecNeo("RETURN 1", nil); err == nil {
+		t.Fatal("Expected an error running a query in a failed transaction")
+	} else if _, ok := err.(*TxFailedError); !ok {
+		t.Fatalf("Expected TxFailedError running a query in a failed transaction. Got: %#v", err)
+	}
+
+	// The server acknowledges the RUN ROLLBACK and PULL_ALL the commit is turned into
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+		server.Write(append(append([]byte{}, success...), success...))
+	}()
+
+	err := tx.Commit()
+	failed, ok := err.(*TxFailedError)
+	if !ok || !failed.RolledBack {
+		t.Fatalf("Expected TxFailedError with the transaction rolled back. Got: %#v", err)
+	}
+	if c.transaction != nil || c.state() != stateReady {
+		t.Fatalf("Expected the connection to be ready after the rollback. Got: %s", c.state())
+	}
+}
+
+func TestBoltTx_CommitFailedAfterReset(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.serverVersion = []byte{0x00, 0x00, 0x00, 0x03}
+	tx := newTx(c)
+	c.transaction = tx
+
+	sent := make(chan byte, 10)
+	go func() {
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		for {
+			msg, err := decoder.Decode()
+			if err != nil {
+				close(sent)
+				return
+			}
+			switch msg := msg.(type) {
+			case structures.Raw:
+				sent <- msg.Signature
+			case structures.Structure:
+				sent <- byte(msg.Signature())
+			}
+		}
+	}()
+	go func() {
+		success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+		failure := []byte{0x00, 0x14, 0xB1, messages.FailureMessageSignature, 0xA2,
+			0x84, 'c', 'o', 'd', 'e', 0x81, 'X', 0x87, 'm', 'e', 's', 's', 'a', 'g', 'e', 0x81, 'Y', 0x00, 0x00}
+		ignored := []byte{0x00, 0x02, 0xB0, messages.IgnoredMessageSignature, 0x00, 0x00}
+		// The RUN fails, its DISCARD_ALL is ignored, and the RESET acknowledging
+		// the failure ends the transaction
+		for _, msg := range [][]byte{failure, ignored, success} {
+			server.Write(msg)
+		}
+	}()
+
+	if _, err := tx.ExecNeo("CREATE (", nil); err == nil {
+		t.Fatal("Expected the query to fail")
+	}
+
+	err := tx.Commit()
+	failed, ok := err.(*TxFailedError)
+	if !ok || !failed.RolledBack {
+		t.Fatalf("Expected TxFailedError with the transaction rolled back. Got: %#v", err)
+	}
+	if c.transaction != nil || c.state() != stateReady {
+		t.Fatalf("Expected the connection to be ready after the commit. Got: %s", c.state())
+	}
+
+	client.Close()
+	var signatures []byte
+	for signature := range sent {
+		signatures = append(signatures, signature)
+	}
+	expected := []byte{messages.RunMessageSignature, messages.DiscardAllMessageSignature, messages.ResetMessageSignature}
+	if !bytes.Equal(signatures, expected) {
+		t.Fatalf("Expected no ROLLBACK after the reset. Got messages: %x", signatures)
+	}
+}
+
+func TestBoltTx_RunBatch(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	c.serverVersion = []byte{0x00, 0x00, 0x00, 0x01}
+	tx := newTx(c)
+	c.transaction = tx
+
+	go io.Copy(ioutil.Discard, server)
+	go func() {
+		success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+		failure := []byte{0x00, 0x14, 0xB1, messages.FailureMessageSignature, 0xA2,
+			0x84, 'c', 'o', 'd', 'e', 0x81, 'X', 0x87, 'm', 'e', 's', 's', 'a', 'g', 'e', 0x81, 'Y', 0x00, 0x00}
+		ignored := []byte{0x00, 0x02, 0xB0, messages.IgnoredMessageSignature, 0x00, 0x00}
+		// The first statement succeeds, the second fails, with its DISCARD_ALL
+		// ignored until the failure is acknowledged
+		for _, msg := range [][]byte{success, success, failure, ignored, success} {
+			server.Write(msg)
+		}
+	}()
+
+	results, err := tx.RunBatch([]Statement{
+		{Query: "CREATE (n)"},
+		{Query: "CREATE (", Params: map[string]interface{}{"a": 1}},
+		{Query: "CREATE (m)"},
+	})
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("Expected BatchError. Got: %#v", err)
+	}
+	if batchErr.Index != 1 || batchErr.Statement.Query != "CREATE (" {
+		t.Fatalf("Expected the second statement to fail. Got: %#v", batchErr)
+	}
+	if len(results) != 1 || len(batchErr.Results) != 1 {
+		t.Fatalf("Expected the result of the first statement. Got: %#v", results)
+	}
+	if c.state() != stateTxFailed {
+		t.Fatalf("Expected the transaction to be rollback only. Got: %s", c.state())
+	}
+}
+
+func TestBoltTx_ExecPipeline(t *testing.T) {
+	client, server := net.Pipe()
+	c := createBoltConn("")
+	c.conn = client
+	tx := newTx(c)
+	c.transaction = tx
+
+	sent := make(chan int, 1)
+	go func() {
+		// Every statement is sent before any result is read
+		decoder := encoding.NewDecoder(server)
+		decoder.SetRawStructures(true)
+		count := 0
+		for ; count < 6; count++ {
+			if _, err := decoder.Decode(); err != nil {
+				break
+			}
+		}
+		sent <- count
+
+		success := []byte{0x00, 0x03, 0xB1, messages.SuccessMessageSignature, 0xA0, 0x00, 0x00}
+		for i := 0; i < 6; i++ {
+			server.Write(success)
+		}
+	}()
+
+	results, err := tx.ExecPipeline([]string{"CREATE (a)", "CREATE (b)", "CREATE (c)"}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("An error occurred running pipeline: %s", err)
+	}
+	if count := <-sent; count != 6 {
+		t.Fatalf("Expected 6 messages sent before the results. Got %d", count)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results. Got: %#v", results)
+	}
+
+	tx.closed = true
+	if _, err := tx.ExecPipeline([]string{"CREATE (d)"}, nil); err == nil {
+		t.Fatalf("Expected an error running a pipeline in a closed transaction")
+	}
+}
@@ -0,0 +1,76 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/log"
+)
+
+// TransactionWork is the work run in a transaction by ReadTransaction and
+// WriteTransaction.  Returning an error rolls the transaction back.  It may
+// be run more than once, so it shouldn't have side effects outside of the
+// transaction.
+type TransactionWork func(tx Tx) error
+
+// ReadTransaction runs the work in a read transaction on a connection from
+// the pool.  See runTransaction.
+func (d *boltDriverPool) ReadTransaction(work TransactionWork) error {
+	return runTransaction(d.OpenPool, d.poolLogger(), true, work, d.retry(), time.Sleep)
+}
+
+// WriteTransaction runs the work in a write transaction on a connection
+// from the pool.  See runTransaction.
+func (d *boltDriverPool) WriteTransaction(work TransactionWork) error {
+	return runTransaction(d.OpenPool, d.poolLogger(), false, work, d.retry(), time.Sleep)
+}
+
+// runTransaction borrows a connection, begins a transaction, runs the work
+// and commits, or rolls back if the work fails or panics, then returns the
+// connection to the pool.  A transient failure or broken connection runs
+// it all again on a fresh connection, as the retry policy allows.
+func runTransaction(open func() (Conn, error), logger *log.Logger, read bool, work TransactionWork, policy RetryPolicy, sleep func(time.Duration)) error {
+	return retryWork(logger, policy, IsRetryable, sleep, func() error {
+		return runTransactionOnce(open, logger, read, work)
+	})
+}
+
+func runTransactionOnce(open func() (Conn, error), logger *log.Logger, read bool, work TransactionWork) error {
+	conn, err := open()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var driverTx driver.Tx
+	if read {
+		driverTx, err = conn.BeginReadWithBookmarks()
+	} else {
+		driverTx, err = conn.Begin()
+	}
+	if err != nil {
+		return err
+	}
+	tx, ok := driverTx.(Tx)
+	if !ok {
+		return errors.New("Unrecognized transaction type: %T", driverTx)
+	}
+
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		if err := tx.Rollback(); err != nil {
+			logger.Errorf("An error occurred rolling back transaction: %s", err)
+		}
+	}()
+
+	if err := work(tx); err != nil {
+		return err
+	}
+	// A failed commit rolls back on its own, or leaves nothing to roll back
+	finished = true
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+)
+
+// workConn begins workTxs, recording how each ended
+type workConn struct {
+	Conn
+	ended *[]string
+}
+
+func (c workConn) Begin() (driver.Tx, error) {
+	return &workTx{ended: c.ended}, nil
+}
+
+func (c workConn) BeginReadWithBookmarks(bookmarks ...string) (driver.Tx, error) {
+	*c.ended = append(*c.ended, "begun read")
+	return c.Begin()
+}
+
+func (c workConn) Close() error {
+	*c.ended = append(*c.ended, "closed")
+	return nil
+}
+
+type workTx struct {
+	Tx
+	ended *[]string
+}
+
+func (t *workTx) Commit() error {
+	*t.ended = append(*t.ended, "committed")
+	return nil
+}
+
+func (t *workTx) Rollback() error {
+	*t.ended = append(*t.ended, "rolled back")
+	return nil
+}
+
+func TestRunTransaction(t *testing.T) {
+	var ended []string
+	open := func() (Conn, error) { return workConn{ended: &ended}, nil }
+	policy := RetryPolicy{MaxRetries: 2}
+	sleep := func(time.Duration) {}
+
+	if err := runTransaction(open, nil, false, func(tx Tx) error { return nil }, policy, sleep); err != nil {
+		t.Fatalf("Unexpected error running the transaction: %s", err)
+	}
+	if len(ended) != 2 || ended[0] != "committed" || ended[1] != "closed" {
+		t.Fatalf("Expected the transaction to be committed and the connection closed. Got: %v", ended)
+	}
+
+	ended = nil
+	failed := errors.New("failed")
+	if err := runTransaction(open, nil, false, func(tx Tx) error { return failed }, policy, sleep); err != failed {
+		t.Fatalf("Expected the work's error. Got: %#v", err)
+	}
+	if len(ended) != 2 || ended[0] != "rolled back" {
+		t.Fatalf("Expected a failed transaction to be rolled back, and not retried. Got: %v", ended)
+	}
+
+	ended = nil
+	attempts := 0
+	err := runTransaction(open, nil, true, func(tx Tx) error {
+		attempts++
+		if attempts < 3 {
+			return driver.ErrBadConn
+		}
+		return nil
+	}, policy, sleep)
+	if err != nil || attempts != 3 || len(ended) != 9 || ended[6] != "begun read" || ended[7] != "committed" {
+		t.Fatalf("Expected the transaction to be retried on a new connection until it commits. Got: %v after %d attempts: %v", err, attempts, ended)
+	}
+
+	ended = nil
+	func() {
+		defer func() { recover() }()
+		runTransaction(open, nil, false, func(tx Tx) error { panic("work panicked") }, policy, sleep)
+	}()
+	if len(ended) != 2 || ended[0] != "rolled back" || ended[1] != "closed" {
+		t.Fatalf("Expected a panicking transaction to be rolled back and the connection closed. Got: %v", ended)
+	}
+}
@@ -2,10 +2,10 @@ package golangNeo4jBoltDriver
 
 import (
 	"database/sql/driver"
-	"errors"
 	"fmt"
 
 	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
 )
 
 // sprintByteHex returns a formatted string of the byte array in hexadecimal
@@ -28,13 +28,29 @@ func sprintByteHex(b []byte) string {
 }
 
 // driverArgsToMap turns driver.Value list into a parameter map
-// for neo4j parameters
+// for neo4j parameters.  Each arg is either a map of parameters, a map
+// encoded with encoding.Marshal, or a parameter name followed by its value.
 func driverArgsToMap(args []driver.Value) (map[string]interface{}, error) {
 	output := map[string]interface{}{}
-	for _, arg := range args {
-		argBytes, ok := arg.([]byte)
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case map[string]interface{}:
+			for k, v := range arg {
+				output[k] = v
+			}
+			continue
+		case string:
+			if i+1 >= len(args) {
+				return nil, errors.New("No value passed for parameter %s in the Exec/Query args", arg)
+			}
+			output[arg] = args[i+1]
+			i++
+			continue
+		}
+
+		argBytes, ok := args[i].([]byte)
 		if !ok {
-			return nil, errors.New("You must pass only a gob encoded map to the Exec/Query args")
+			return nil, errors.New("You must pass only maps, structs, maps encoded with encoding.Marshal, or parameter name and value pairs to the Exec/Query args")
 		}
 
 		m, err := encoding.Unmarshal(argBytes)
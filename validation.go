@@ -0,0 +1,157 @@
+package golangNeo4jBoltDriver
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/temporal"
+)
+
+// PropertyError is returned when a parameter value can't be stored as
+// a Neo4j property.  Path is the key path to the offending value,
+// for example `props.tags[2]`.
+type PropertyError struct {
+	Path   string
+	Value  interface{}
+	Reason string
+}
+
+// Error implements the error interface
+func (e *PropertyError) Error() string {
+	return fmt.Sprintf("Invalid property value at %s (%T %+v): %s", e.Path, e.Value, e.Value, e.Reason)
+}
+
+// ValidateProperties checks the given parameters against Neo4j's property
+// storage rules, so invalid values fail before a round-trip to the server.
+//
+// Neo4j can only store booleans, integers, floats, strings, temporal values and
+// points, or homogeneous lists of those, as properties.  Each parameter is checked as a property value,
+// except for maps, which are checked as a map of properties (e.g. `CREATE (n {props})`),
+// and lists of maps, which are checked as a list of property maps (e.g. `UNWIND {rows} AS row`).
+//
+// The first offending value is returned as a *PropertyError.
+func ValidateProperties(params map[string]interface{}) error {
+	for key, value := range params {
+		var err error
+		switch val := value.(type) {
+		case map[string]interface{}:
+			err = validatePropertyMap(key, val)
+		default:
+			if maps, ok := propertyMapSlice(val); ok {
+				for i, m := range maps {
+					if err = validatePropertyMap(fmt.Sprintf("%s[%d]", key, i), m); err != nil {
+						break
+					}
+				}
+			} else {
+				err = validateProperty(key, val)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePropertyMap(path string, props map[string]interface{}) error {
+	for key, value := range props {
+		if err := validateProperty(path+"."+key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateProperty(path string, value interface{}) error {
+	value = encoding.Underlying(value)
+	if value == nil {
+		// Setting a property to null removes it, which is allowed
+		return nil
+	}
+
+	kind, ok := propertyKind(value)
+	if ok {
+		return nil
+	}
+
+	if kind != reflect.Slice && kind != reflect.Array {
+		return &PropertyError{Path: path, Value: value, Reason: "type can't be stored as a property"}
+	}
+
+	list := reflect.ValueOf(value)
+	var listKind reflect.Kind
+	for i := 0; i < list.Len(); i++ {
+		item := encoding.Underlying(list.Index(i).Interface())
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if item == nil {
+			return &PropertyError{Path: itemPath, Value: item, Reason: "lists stored as properties can't contain null"}
+		}
+
+		itemKind, ok := propertyKind(item)
+		if !ok {
+			return &PropertyError{Path: itemPath, Value: item, Reason: "lists stored as properties can only contain booleans, integers, floats, strings, temporal values or points"}
+		}
+
+		if i == 0 {
+			listKind = itemKind
+		} else if itemKind != listKind {
+			return &PropertyError{Path: itemPath, Value: item, Reason: "lists stored as properties must contain a single type"}
+		}
+	}
+
+	return nil
+}
+
+// propertyKind normalizes the kind of a value for property storage,
+// returning true if it is a storable scalar
+func propertyKind(value interface{}) (reflect.Kind, bool) {
+	switch value.(type) {
+	case time.Time, temporal.Date, temporal.Time, temporal.LocalTime, temporal.LocalDateTime, temporal.Duration:
+		return reflect.Struct, true
+	case graph.Point2D, graph.Point3D:
+		// A distinct kind, so a list can't mix points with temporal values
+		return reflect.Interface, true
+	}
+
+	kind := reflect.TypeOf(value).Kind()
+	switch kind {
+	case reflect.Bool, reflect.String:
+		return kind, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.Int64, true
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64, true
+	default:
+		return kind, false
+	}
+}
+
+// propertyMapSlice returns the items of a non-empty list if they are all property maps
+func propertyMapSlice(value interface{}) ([]map[string]interface{}, bool) {
+	switch val := value.(type) {
+	case []map[string]interface{}:
+		return val, len(val) > 0
+	case []interface{}:
+		if len(val) == 0 {
+			return nil, false
+		}
+		maps := make([]map[string]interface{}, len(val))
+		for i, item := range val {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			maps[i] = m
+		}
+		return maps, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,54 @@
+package golangNeo4jBoltDriver
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestValidateProperties(t *testing.T) {
+	name := "name"
+	valid := map[string]interface{}{
+		"a": 1,
+		"b": 34234.34323,
+		"c": "string",
+		"d": []interface{}{int64(1), 2, int8(3)},
+		"e": true,
+		"f": nil,
+		"g": []string{"a", "b"},
+		"h": &name,
+		"i": (*int)(nil),
+		"j": sql.NullInt64{Int64: 1, Valid: true},
+		"k": []*string{&name},
+		"props": map[string]interface{}{
+			"foo": []interface{}{1.1, 2.2},
+		},
+		"rows": []interface{}{
+			map[string]interface{}{"foo": 1},
+			map[string]interface{}{"foo": 2},
+		},
+	}
+	if err := ValidateProperties(valid); err != nil {
+		t.Fatalf("Unexpected error validating properties: %s", err)
+	}
+
+	invalid := []struct {
+		params map[string]interface{}
+		path   string
+	}{
+		{map[string]interface{}{"d": []interface{}{int64(1), "2", int64(3), true, nil}}, "d[1]"},
+		{map[string]interface{}{"d": []interface{}{int64(1), nil}}, "d[1]"},
+		{map[string]interface{}{"d": []interface{}{[]interface{}{1}}}, "d[0]"},
+		{map[string]interface{}{"props": map[string]interface{}{"foo": map[string]interface{}{}}}, "props.foo"},
+		{map[string]interface{}{"rows": []interface{}{map[string]interface{}{"foo": 1}, map[string]interface{}{"foo": []interface{}{1, 2.2}}}}, "rows[1].foo[1]"},
+	}
+	for _, test := range invalid {
+		err := ValidateProperties(test.params)
+		propErr, ok := err.(*PropertyError)
+		if !ok {
+			t.Fatalf("Expected property error for %#v. Got: %#v", test.params, err)
+		}
+		if propErr.Path != test.path {
+			t.Fatalf("Unexpected property error path. Expected %s. Got: %s", test.path, propErr.Path)
+		}
+	}
+}